@@ -0,0 +1,115 @@
+package iac
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/nullstone-io/iac/advisor"
+	"github.com/nullstone-io/iac/config"
+	"github.com/nullstone-io/iac/core"
+	"gopkg.in/nullstone-io/go-api-client.v0/artifacts"
+)
+
+// AdviseUpgrades analyzes every newer version of the module for each block and capability in the IaC files
+// This must be called after Initialize; modules that failed to resolve are omitted
+func AdviseUpgrades(ctx context.Context, input ConfigFiles, resolver core.ModuleVersionResolver) (advisor.Report, error) {
+	report := advisor.Report{}
+	overrideNames := slices.Sorted(maps.Keys(input.Overrides))
+	var base []config.ModuleUsage
+	if input.Config != nil {
+		base = input.Config.ModuleUsages()
+		// Variables and connections set in an overrides file for an inherited block also apply to the module in the primary config file
+		inherited := make([]config.ModuleUsage, 0)
+		for _, name := range overrideNames {
+			inherited = append(inherited, input.Overrides[name].InheritedUsages(input.Config)...)
+		}
+		cur, err := adviseEnvUpgrades(ctx, resolver, input.Config, inherited)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, cur...)
+	}
+	for _, name := range overrideNames {
+		cur, err := adviseEnvUpgrades(ctx, resolver, input.Overrides[name], base)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, cur...)
+	}
+	return report, nil
+}
+
+// adviseEnvUpgrades analyzes the blocks in a single IaC file
+// related contains usages from other IaC files whose variables and connections also apply to the same block or capability
+func adviseEnvUpgrades(ctx context.Context, resolver core.ModuleVersionResolver, ec *config.EnvConfiguration, related []config.ModuleUsage) (advisor.Report, error) {
+	report := advisor.Report{}
+	for _, usage := range ec.ModuleUsages() {
+		if usage.ModuleVersion == nil {
+			continue
+		}
+		ms, err := artifacts.ParseSource(usage.ModuleSource)
+		if err != nil {
+			return nil, fmt.Errorf("invalid module %q: %w", usage.ModuleSource, err)
+		}
+		m, _, err := resolver.ResolveModuleVersion(ctx, *ms, "latest")
+		if err != nil {
+			return nil, fmt.Errorf("error looking up versions of module %q: %w", usage.ModuleSource, err)
+		}
+		if m == nil {
+			continue
+		}
+
+		br := advisor.BlockReport{
+			IacContext:        ec.IacContext,
+			ObjectPathContext: usage.ObjectPathContext,
+			BlockName:         usage.BlockName,
+			CapabilityName:    usage.CapabilityName,
+			ModuleSource:      usage.ModuleSource,
+			ModuleConstraint:  usage.ModuleConstraint,
+			CurrentVersion:    usage.ModuleVersion.Version,
+			Upgrades:          make([]advisor.Upgrade, 0),
+		}
+		configured := adviceUsage(usage, related)
+		for _, mv := range advisor.NewerVersions(*m, usage.ModuleVersion.Version) {
+			upgrade := advisor.Analyze(*usage.ModuleVersion, mv, configured)
+			upgrade.AllowedByConstraint = isAllowedByConstraint(usage.ModuleConstraint, mv.Version)
+			br.Upgrades = append(br.Upgrades, upgrade)
+		}
+		report = append(report, br)
+	}
+	return report, nil
+}
+
+// adviceUsage collects the variables and connections set for a block or capability
+// Values set in usage take precedence over values set in related usages
+func adviceUsage(usage config.ModuleUsage, related []config.ModuleUsage) advisor.Usage {
+	result := advisor.Usage{Variables: map[string]any{}, Connections: map[string]bool{}}
+	add := func(cur config.ModuleUsage) {
+		for name, v := range cur.Variables {
+			result.Variables[name] = v.Value
+		}
+		for name := range cur.Connections {
+			result.Connections[name] = true
+		}
+	}
+	for _, cur := range related {
+		if cur.BlockName == usage.BlockName && cur.CapabilityName == usage.CapabilityName {
+			add(cur)
+		}
+	}
+	add(usage)
+	return result
+}
+
+func isAllowedByConstraint(constraint, version string) bool {
+	if constraint == "" || constraint == "latest" {
+		return true
+	}
+	if !core.IsVersionConstraint(constraint) {
+		return constraint == version
+	}
+	c, err := core.ParseVersionConstraint(constraint)
+	return err == nil && c.Check(version)
+}
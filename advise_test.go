@@ -0,0 +1,142 @@
+package iac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nullstone-io/iac/advisor"
+	"github.com/nullstone-io/module/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+func TestAdviseUpgrades(t *testing.T) {
+	service := lockTestModule("nullstone", "aws-fargate-service", types.CategoryApp, "0.12.1", "0.12.4", "0.13.0")
+	service.Versions[0].Manifest.Variables["legacy"] = config.Variable{Type: "string", Default: ""}
+	service.Versions[1].Manifest.Variables["memory"] = config.Variable{Type: "number", Default: 512}
+	service.Versions[2].Manifest.Variables["memory"] = config.Variable{Type: "number", Default: 512}
+	service.Versions[2].Manifest.Variables["image"] = config.Variable{Type: "string"}
+	cdn := lockTestModule("nullstone", "aws-s3-cdn", types.CategoryCapability, "1.0.0")
+	resolver := lockResolver{
+		modules: map[string]*types.Module{
+			"nullstone/aws-fargate-service": service,
+			"nullstone/aws-s3-cdn":          cdn,
+		},
+	}
+
+	input, err := ParseMap("", "acme/api", map[string]string{
+		".nullstone/config.yml": `version: "0.2"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+    module_version: "0.12.1"
+    vars:
+      legacy: "yes"
+    capabilities:
+      - name: cdn
+        module: nullstone/aws-s3-cdn
+`,
+		".nullstone/prod.yml": `version: "0.2"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+    module_version: "~> 0.12.0"
+`,
+		".nullstone/lock.yml": `version: 1
+overrides:
+  prod:
+    api:
+      module: nullstone/aws-fargate-service
+      module_version: "~> 0.12.0"
+      resolved: 0.12.1
+`,
+	})
+	require.NoError(t, err)
+	require.Empty(t, Initialize(context.Background(), input, resolver))
+
+	report, err := AdviseUpgrades(context.Background(), input, resolver)
+	require.NoError(t, err)
+	require.Len(t, report, 3)
+
+	api := report[0]
+	assert.Equal(t, "apps.api", api.ObjectPathContext.Context())
+	assert.Equal(t, "0.12.1", api.CurrentVersion)
+	require.Len(t, api.Upgrades, 2)
+	assert.Equal(t, "0.12.4", api.Upgrades[0].Version)
+	assert.False(t, api.Upgrades[0].AllowedByConstraint)
+	assert.Equal(t, advisor.VerdictBreaking, api.Upgrades[0].Verdict)
+	assert.Equal(t, advisor.VerdictBreaking, api.Latest().Verdict)
+
+	cdnReport := report[1]
+	assert.Equal(t, "cdn", cdnReport.CapabilityName)
+	assert.Nil(t, cdnReport.Latest())
+
+	// The lock file pins prod to 0.12.1 and the overrides file inherits `vars.legacy` from the primary config file
+	prod := report[2]
+	assert.Equal(t, ".nullstone/prod.yml", prod.IacContext.Filename)
+	require.Len(t, prod.Upgrades, 2)
+	assert.True(t, prod.Upgrades[0].AllowedByConstraint)
+	assert.Equal(t, advisor.VerdictBreaking, prod.Upgrades[0].Verdict)
+	assert.False(t, prod.Upgrades[1].AllowedByConstraint)
+	assert.Equal(t, []advisor.Finding{
+		{Kind: advisor.FindingVariableAdded, Name: "image", Verdict: advisor.VerdictNeedsEdits, Message: "Variable \"image\" was added and is required, it must be set in `vars`"},
+		{Kind: advisor.FindingVariableRemoved, Name: "legacy", Verdict: advisor.VerdictBreaking, Message: "Variable \"legacy\" was removed, but it is set in `vars`"},
+		{Kind: advisor.FindingVariableAdded, Name: "memory", Verdict: advisor.VerdictSafe, Message: `Variable "memory" was added`},
+	}, prod.Upgrades[1].Findings)
+}
+
+func TestAdviseUpgrades_inheritedUsage(t *testing.T) {
+	service := lockTestModule("nullstone", "aws-fargate-service", types.CategoryApp, "0.12.1", "0.12.4")
+	service.Versions[0].Manifest.Variables["legacy"] = config.Variable{Type: "string", Default: ""}
+	cdn := lockTestModule("nullstone", "aws-s3-cdn", types.CategoryCapability, "1.0.0", "1.1.0")
+	cdn.Versions[0].Manifest.Variables["enable_www"] = config.Variable{Type: "bool", Default: false}
+	resolver := lockResolver{
+		modules: map[string]*types.Module{
+			"nullstone/aws-fargate-service": service,
+			"nullstone/aws-s3-cdn":          cdn,
+		},
+	}
+
+	input, err := ParseMap("", "acme/api", map[string]string{
+		".nullstone/config.yml": `version: "0.2"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+    module_version: "0.12.1"
+    capabilities:
+      - name: cdn
+        module: nullstone/aws-s3-cdn
+        module_version: "1.0.0"
+`,
+		".nullstone/prod.yml": `version: "0.2"
+apps:
+  api:
+    vars:
+      legacy: "yes"
+    capabilities:
+      - name: cdn
+        vars:
+          enable_www: true
+`,
+	})
+	require.NoError(t, err)
+	require.Empty(t, Initialize(context.Background(), input, resolver))
+
+	report, err := AdviseUpgrades(context.Background(), input, resolver)
+	require.NoError(t, err)
+	require.Len(t, report, 2, "blocks that inherit their module are reported with the primary config file")
+
+	// Variables set in prod.yml are removed by the upgrade of the modules declared in config.yml
+	api := report[0]
+	assert.Equal(t, ".nullstone/config.yml", api.IacContext.Filename)
+	assert.Equal(t, "apps.api", api.ObjectPathContext.Context())
+	assert.Equal(t, advisor.VerdictBreaking, api.Latest().Verdict)
+	assert.Equal(t, []advisor.Finding{
+		{Kind: advisor.FindingVariableRemoved, Name: "legacy", Verdict: advisor.VerdictBreaking, Message: "Variable \"legacy\" was removed, but it is set in `vars`"},
+	}, api.Latest().Findings)
+
+	cdnReport := report[1]
+	assert.Equal(t, "cdn", cdnReport.CapabilityName)
+	assert.Equal(t, advisor.VerdictBreaking, cdnReport.Latest().Verdict)
+}
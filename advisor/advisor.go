@@ -0,0 +1,268 @@
+// Package advisor analyzes newer versions of a module to determine whether a block can be upgraded without editing its IaC configuration
+package advisor
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/nullstone-io/iac/core"
+	"github.com/nullstone-io/iac/tftype"
+	"github.com/nullstone-io/iac/workspace"
+	"github.com/nullstone-io/module/config"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+type Verdict string
+
+const (
+	// VerdictSafe means the upgrade requires no changes to the IaC configuration
+	VerdictSafe Verdict = "safe"
+	// VerdictNeedsEdits means the IaC configuration must add variables or connections before upgrading
+	VerdictNeedsEdits Verdict = "needs-edits"
+	// VerdictBreaking means the IaC configuration uses something that was removed or changed incompatibly
+	VerdictBreaking Verdict = "breaking"
+)
+
+func (v Verdict) rank() int {
+	switch v {
+	case VerdictNeedsEdits:
+		return 1
+	case VerdictBreaking:
+		return 2
+	}
+	return 0
+}
+
+// Worse returns the more severe of two verdicts
+func Worse(a, b Verdict) Verdict {
+	if b.rank() > a.rank() {
+		return b
+	}
+	return a
+}
+
+type FindingKind string
+
+const (
+	FindingVariableAdded     FindingKind = "variable-added"
+	FindingVariableRemoved   FindingKind = "variable-removed"
+	FindingVariableChanged   FindingKind = "variable-changed"
+	FindingConnectionAdded   FindingKind = "connection-added"
+	FindingConnectionRemoved FindingKind = "connection-removed"
+	FindingConnectionChanged FindingKind = "connection-changed"
+)
+
+// Finding is a single manifest change between two module versions
+type Finding struct {
+	Kind    FindingKind `json:"kind"`
+	Name    string      `json:"name"`
+	Verdict Verdict     `json:"verdict"`
+	Message string      `json:"message"`
+}
+
+// Upgrade is the analysis of upgrading to a single module version
+type Upgrade struct {
+	Version string `json:"version"`
+	// AllowedByConstraint is true if the current module_version already permits this version
+	AllowedByConstraint bool      `json:"allowedByConstraint"`
+	Verdict             Verdict   `json:"verdict"`
+	Findings            []Finding `json:"findings"`
+}
+
+// BlockReport lists the upgrades available for a block or capability
+type BlockReport struct {
+	IacContext        core.IacContext        `json:"iacContext"`
+	ObjectPathContext core.ObjectPathContext `json:"objectPathContext"`
+	BlockName         string                 `json:"blockName"`
+	// CapabilityName is empty for a block
+	CapabilityName   string `json:"capabilityName,omitempty"`
+	ModuleSource     string `json:"moduleSource"`
+	ModuleConstraint string `json:"moduleConstraint"`
+	CurrentVersion   string `json:"currentVersion"`
+	// Upgrades contains an analysis for each newer module version, lowest first
+	Upgrades []Upgrade `json:"upgrades"`
+}
+
+// Latest returns the analysis of the newest module version or nil if the block is up to date
+func (r BlockReport) Latest() *Upgrade {
+	if len(r.Upgrades) == 0 {
+		return nil
+	}
+	return &r.Upgrades[len(r.Upgrades)-1]
+}
+
+// Report contains a BlockReport for each block and capability in the IaC files
+type Report []BlockReport
+
+// Usage is the IaC configuration for a block or capability that is affected by a module upgrade
+type Usage struct {
+	// Variables contains the values of variables set in the IaC configuration
+	Variables map[string]any
+	// Connections contains the names of connections set in the IaC configuration
+	Connections map[string]bool
+}
+
+// Analyze classifies the manifest changes from cur to des against the IaC configuration
+func Analyze(cur, des types.ModuleVersion, usage Usage) Upgrade {
+	changes := workspace.DiffModuleConfig(moduleConfig(cur), moduleConfig(des))
+	result := Upgrade{Version: des.Version, Verdict: VerdictSafe, Findings: make([]Finding, 0)}
+	for _, key := range slices.Sorted(maps.Keys(changes)) {
+		change := changes[key]
+		var finding *Finding
+		switch change.ChangeType {
+		case types.ChangeTypeVariable:
+			finding = analyzeVariable(*change, usage)
+		case types.ChangeTypeConnection:
+			finding = analyzeConnection(*change, usage)
+		}
+		if finding == nil {
+			continue
+		}
+		result.Findings = append(result.Findings, *finding)
+		result.Verdict = Worse(result.Verdict, finding.Verdict)
+	}
+	return result
+}
+
+func moduleConfig(mv types.ModuleVersion) types.ModuleConfig {
+	mc := types.ModuleConfig{
+		SourceVersion:  mv.Version,
+		SourceToolName: mv.ToolName,
+		Variables:      types.Variables{},
+		Connections:    types.Connections{},
+	}
+	workspace.ModuleSchema(mv.Manifest).UpdateSchema(mc.Variables, mc.Connections, nil)
+	return mc
+}
+
+func analyzeVariable(change types.WorkspaceChange, usage Usage) *Finding {
+	name := change.Identifier
+	value, isSet := usage.Variables[name]
+	switch change.Action {
+	case types.ChangeActionAdd:
+		b := change.Desired.(types.Variable)
+		if b.Default == nil && !isSet {
+			return &Finding{Kind: FindingVariableAdded, Name: name, Verdict: VerdictNeedsEdits,
+				Message: fmt.Sprintf("Variable %q was added and is required, it must be set in `vars`", name)}
+		}
+		return &Finding{Kind: FindingVariableAdded, Name: name, Verdict: VerdictSafe,
+			Message: fmt.Sprintf("Variable %q was added", name)}
+	case types.ChangeActionDelete:
+		if isSet {
+			return &Finding{Kind: FindingVariableRemoved, Name: name, Verdict: VerdictBreaking,
+				Message: fmt.Sprintf("Variable %q was removed, but it is set in `vars`", name)}
+		}
+		return &Finding{Kind: FindingVariableRemoved, Name: name, Verdict: VerdictSafe,
+			Message: fmt.Sprintf("Variable %q was removed", name)}
+	case types.ChangeActionUpdate:
+		a, b := change.Current.(types.Variable), change.Desired.(types.Variable)
+		return analyzeVariableUpdate(name, a.Variable, b.Variable, value, isSet)
+	}
+	return nil
+}
+
+func analyzeVariableUpdate(name string, a, b config.Variable, value any, isSet bool) *Finding {
+	finding := &Finding{Kind: FindingVariableChanged, Name: name, Verdict: VerdictSafe}
+	var reasons []string
+	if a.Type != b.Type {
+		reasons = append(reasons, fmt.Sprintf("type changed from %s to %s", a.Type, b.Type))
+		if isSet && !isValueCompatible(value, b.Type) {
+			finding.Verdict = VerdictBreaking
+			reasons = append(reasons, "the value in `vars` is not compatible")
+		}
+	}
+	if !isSet && a.Default != nil && b.Default == nil {
+		finding.Verdict = Worse(finding.Verdict, VerdictNeedsEdits)
+		reasons = append(reasons, "no longer has a default and must be set in `vars`")
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "schema changed")
+	}
+	finding.Message = fmt.Sprintf("Variable %q %s", name, strings.Join(reasons, "; "))
+	return finding
+}
+
+func analyzeConnection(change types.WorkspaceChange, usage Usage) *Finding {
+	name := change.Identifier
+	isSet := usage.Connections[name]
+	switch change.Action {
+	case types.ChangeActionAdd:
+		b := change.Desired.(types.Connection)
+		if !b.Optional && !isSet {
+			return &Finding{Kind: FindingConnectionAdded, Name: name, Verdict: VerdictNeedsEdits,
+				Message: fmt.Sprintf("Connection %q (%s) was added and is required, it must be set in `connections`", name, b.Contract)}
+		}
+		return &Finding{Kind: FindingConnectionAdded, Name: name, Verdict: VerdictSafe,
+			Message: fmt.Sprintf("Connection %q (%s) was added", name, b.Contract)}
+	case types.ChangeActionDelete:
+		if isSet {
+			return &Finding{Kind: FindingConnectionRemoved, Name: name, Verdict: VerdictBreaking,
+				Message: fmt.Sprintf("Connection %q was removed, but it is set in `connections`", name)}
+		}
+		return &Finding{Kind: FindingConnectionRemoved, Name: name, Verdict: VerdictSafe,
+			Message: fmt.Sprintf("Connection %q was removed", name)}
+	case types.ChangeActionUpdate:
+		a, b := change.Current.(types.Connection), change.Desired.(types.Connection)
+		return analyzeConnectionUpdate(name, a.Connection, b.Connection, isSet)
+	}
+	return nil
+}
+
+func analyzeConnectionUpdate(name string, a, b config.Connection, isSet bool) *Finding {
+	finding := &Finding{Kind: FindingConnectionChanged, Name: name, Verdict: VerdictSafe}
+	var reasons []string
+	if a.Contract != b.Contract {
+		reasons = append(reasons, fmt.Sprintf("contract changed from %s to %s", a.Contract, b.Contract))
+		if isSet {
+			// The connected block was chosen to satisfy the old contract
+			finding.Verdict = VerdictBreaking
+		}
+	}
+	if !isSet && a.Optional && !b.Optional {
+		finding.Verdict = Worse(finding.Verdict, VerdictNeedsEdits)
+		reasons = append(reasons, "is no longer optional and must be set in `connections`")
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "schema changed")
+	}
+	finding.Message = fmt.Sprintf("Connection %q %s", name, strings.Join(reasons, "; "))
+	return finding
+}
+
+// isValueCompatible checks a configured value against a Terraform type expression
+// Unknown types are treated as compatible to avoid false positives
+func isValueCompatible(value any, varType string) bool {
+	t, err := tftype.Parse(varType)
+	if err != nil {
+		return true
+	}
+	return len(t.Validate(value)) == 0
+}
+
+// NewerVersions returns the versions of a module that are newer than current, lowest first
+// Prerelease versions are omitted unless current is a prerelease
+func NewerVersions(m types.Module, current string) []types.ModuleVersion {
+	cur, err := core.ParseVersion(current)
+	if err != nil {
+		return nil
+	}
+	result := make([]types.ModuleVersion, 0)
+	for _, mv := range m.Versions {
+		v, err := core.ParseVersion(mv.Version)
+		if err != nil || v.Compare(cur) <= 0 {
+			continue
+		}
+		if v.Prerelease != "" && cur.Prerelease == "" {
+			continue
+		}
+		result = append(result, mv)
+	}
+	slices.SortFunc(result, func(a, b types.ModuleVersion) int {
+		va, _ := core.ParseVersion(a.Version)
+		vb, _ := core.ParseVersion(b.Version)
+		return va.Compare(vb)
+	})
+	return result
+}
@@ -0,0 +1,150 @@
+package advisor
+
+import (
+	"testing"
+
+	"github.com/nullstone-io/module/config"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+func TestAnalyze(t *testing.T) {
+	cur := types.ModuleVersion{
+		Version: "1.0.0",
+		Manifest: config.Manifest{
+			Variables: map[string]config.Variable{
+				"port":     {Type: "number", Default: 80},
+				"replicas": {Type: "number", Default: 1},
+				"legacy":   {Type: "string", Default: ""},
+			},
+			Connections: map[string]config.Connection{
+				"network": {Contract: "network/aws/vpc"},
+				"cluster": {Contract: "cluster/aws/ecs:fargate"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		des      config.Manifest
+		usage    Usage
+		verdict  Verdict
+		findings []Finding
+	}{
+		{
+			name:     "no changes",
+			des:      cur.Manifest,
+			usage:    Usage{},
+			verdict:  VerdictSafe,
+			findings: []Finding{},
+		},
+		{
+			name: "unused variable removed and optional variable added",
+			des: config.Manifest{
+				Variables: map[string]config.Variable{
+					"port":     {Type: "number", Default: 80},
+					"replicas": {Type: "number", Default: 1},
+					"memory":   {Type: "number", Default: 512},
+				},
+				Connections: cur.Manifest.Connections,
+			},
+			usage:   Usage{Variables: map[string]any{"port": 8080}},
+			verdict: VerdictSafe,
+			findings: []Finding{
+				{Kind: FindingVariableRemoved, Name: "legacy", Verdict: VerdictSafe, Message: `Variable "legacy" was removed`},
+				{Kind: FindingVariableAdded, Name: "memory", Verdict: VerdictSafe, Message: `Variable "memory" was added`},
+			},
+		},
+		{
+			name: "removed variable is set",
+			des: config.Manifest{
+				Variables: map[string]config.Variable{
+					"port":     {Type: "number", Default: 80},
+					"replicas": {Type: "number", Default: 1},
+				},
+				Connections: cur.Manifest.Connections,
+			},
+			usage:   Usage{Variables: map[string]any{"legacy": "x"}},
+			verdict: VerdictBreaking,
+			findings: []Finding{
+				{Kind: FindingVariableRemoved, Name: "legacy", Verdict: VerdictBreaking, Message: "Variable \"legacy\" was removed, but it is set in `vars`"},
+			},
+		},
+		{
+			name: "newly required variable and connection",
+			des: config.Manifest{
+				Variables: map[string]config.Variable{
+					"port":     {Type: "number", Default: 80},
+					"replicas": {Type: "number"},
+					"legacy":   {Type: "string", Default: ""},
+					"image":    {Type: "string"},
+				},
+				Connections: map[string]config.Connection{
+					"network": {Contract: "network/aws/vpc"},
+					"cluster": {Contract: "cluster/aws/ecs:fargate"},
+					"domain":  {Contract: "domain/aws/route53"},
+				},
+			},
+			usage:   Usage{Connections: map[string]bool{"network": true, "cluster": true}},
+			verdict: VerdictNeedsEdits,
+			findings: []Finding{
+				{Kind: FindingConnectionAdded, Name: "domain", Verdict: VerdictNeedsEdits, Message: "Connection \"domain\" (domain/aws/route53) was added and is required, it must be set in `connections`"},
+				{Kind: FindingVariableAdded, Name: "image", Verdict: VerdictNeedsEdits, Message: "Variable \"image\" was added and is required, it must be set in `vars`"},
+				{Kind: FindingVariableChanged, Name: "replicas", Verdict: VerdictNeedsEdits, Message: "Variable \"replicas\" no longer has a default and must be set in `vars`"},
+			},
+		},
+		{
+			name: "type change with compatible and incompatible values",
+			des: config.Manifest{
+				Variables: map[string]config.Variable{
+					"port":     {Type: "list(number)", Default: []any{80}},
+					"replicas": {Type: "string", Default: "1"},
+					"legacy":   {Type: "string", Default: ""},
+				},
+				Connections: cur.Manifest.Connections,
+			},
+			usage:   Usage{Variables: map[string]any{"port": 8080, "replicas": "2"}},
+			verdict: VerdictBreaking,
+			findings: []Finding{
+				{Kind: FindingVariableChanged, Name: "port", Verdict: VerdictBreaking, Message: "Variable \"port\" type changed from number to list(number); the value in `vars` is not compatible"},
+				{Kind: FindingVariableChanged, Name: "replicas", Verdict: VerdictSafe, Message: "Variable \"replicas\" type changed from number to string"},
+			},
+		},
+		{
+			name: "contract change on a connection",
+			des: config.Manifest{
+				Variables: cur.Manifest.Variables,
+				Connections: map[string]config.Connection{
+					"network": {Contract: "network/aws/vpc"},
+					"cluster": {Contract: "cluster/aws/ecs:ec2"},
+				},
+			},
+			usage:   Usage{Connections: map[string]bool{"cluster": true}},
+			verdict: VerdictBreaking,
+			findings: []Finding{
+				{Kind: FindingConnectionChanged, Name: "cluster", Verdict: VerdictBreaking, Message: `Connection "cluster" contract changed from cluster/aws/ecs:fargate to cluster/aws/ecs:ec2`},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Analyze(cur, types.ModuleVersion{Version: "1.1.0", Manifest: test.des}, test.usage)
+			assert.Equal(t, "1.1.0", got.Version)
+			assert.Equal(t, test.verdict, got.Verdict)
+			assert.Equal(t, test.findings, got.Findings)
+		})
+	}
+}
+
+func TestNewerVersions(t *testing.T) {
+	m := types.Module{}
+	for _, v := range []string{"0.9.0", "1.2.0", "1.0.0", "1.1.0", "2.0.0-beta.1", "1.1.1"} {
+		m.Versions = append(m.Versions, types.ModuleVersion{Version: v})
+	}
+	var got []string
+	for _, mv := range NewerVersions(m, "1.0.0") {
+		got = append(got, mv.Version)
+	}
+	assert.Equal(t, []string{"1.1.0", "1.1.1", "1.2.0"}, got)
+}
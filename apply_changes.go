@@ -1,20 +1,84 @@
 package iac
 
 import (
+	"slices"
+
 	"github.com/nullstone-io/iac/core"
 	"gopkg.in/nullstone-io/go-api-client.v0/types"
 )
 
-func ApplyChangesTo(input ConfigFiles, block types.Block, env types.Environment, updater core.WorkspaceConfigUpdater) error {
+// ApplyChangesTo applies the IaC configuration for block in env to the workspace config
+// If updater renders templates (see core.TemplateContextUpdater), the block, env, and repository are provided to templates
+// This returns the values that were dropped or renamed because a module upgrade removed the variable or connection
+func ApplyChangesTo(input ConfigFiles, block types.Block, env types.Environment, updater core.WorkspaceConfigUpdater) (core.SchemaFindings, error) {
+	if tcu, ok := updater.(core.TemplateContextUpdater); ok {
+		updater = tcu.WithTemplateContext(block, env, input.RepoName, input.RepoUrl)
+	}
+	findings := core.SchemaFindings{}
+	updater = schemaFindingsUpdater{WorkspaceConfigUpdater: updater, findings: &findings}
+	overrides := input.GetOverrides(env)
+	if overrides != nil {
+		// Capabilities in the overrides file that refer to the primary config file update the inherited capability
+		overrides.InheritModules(input.Config)
+	}
 	if input.Config != nil {
-		if err := input.Config.ApplyChangesTo(block, updater); err != nil {
-			return err
+		primaryUpdater := updater
+		if overrides != nil {
+			// Capabilities added by the overrides file on a previous sync are not removed by the primary config file
+			primaryUpdater = keepCapabilitiesUpdater{WorkspaceConfigUpdater: updater, keep: overrides.DeclaredCapabilities(block.Name)}
+		}
+		if err := input.Config.ApplyChangesTo(block, primaryUpdater); err != nil {
+			return nil, err
 		}
 	}
-	if overrides := input.GetOverrides(env); overrides != nil {
+	if overrides != nil {
 		if err := overrides.ApplyChangesTo(block, updater); err != nil {
-			return err
+			return nil, err
 		}
 	}
+	return findings, nil
+}
+
+// keepCapabilitiesUpdater keeps capabilities in keep when removing capabilities that are not in the IaC file
+type keepCapabilitiesUpdater struct {
+	core.WorkspaceConfigUpdater
+	keep core.CapabilityIdentities
+}
+
+func (u keepCapabilitiesUpdater) RemoveCapabilitiesNotIn(identities core.CapabilityIdentities) {
+	u.WorkspaceConfigUpdater.RemoveCapabilitiesNotIn(append(slices.Clone(identities), u.keep...))
+}
+
+// schemaFindingsUpdater collects the findings from UpdateSchema of the workspace and its capabilities
+type schemaFindingsUpdater struct {
+	core.WorkspaceConfigUpdater
+	findings *core.SchemaFindings
+}
+
+func (u schemaFindingsUpdater) UpdateSchema(moduleSource, moduleConstraint string, moduleVersion *types.ModuleVersion, renamedVariables map[string]string) core.SchemaFindings {
+	findings := u.WorkspaceConfigUpdater.UpdateSchema(moduleSource, moduleConstraint, moduleVersion, renamedVariables)
+	*u.findings = append(*u.findings, findings...)
+	return findings
+}
+
+func (u schemaFindingsUpdater) GetCapabilityUpdater(identity core.CapabilityIdentity) core.CapabilityConfigUpdater {
+	if capUpdater := u.WorkspaceConfigUpdater.GetCapabilityUpdater(identity); capUpdater != nil {
+		return schemaFindingsCapabilityUpdater{CapabilityConfigUpdater: capUpdater, findings: u.findings}
+	}
 	return nil
 }
+
+func (u schemaFindingsUpdater) AddCapability(id int64, name string) core.CapabilityConfigUpdater {
+	return schemaFindingsCapabilityUpdater{CapabilityConfigUpdater: u.WorkspaceConfigUpdater.AddCapability(id, name), findings: u.findings}
+}
+
+type schemaFindingsCapabilityUpdater struct {
+	core.CapabilityConfigUpdater
+	findings *core.SchemaFindings
+}
+
+func (u schemaFindingsCapabilityUpdater) UpdateSchema(moduleSource, moduleConstraint string, moduleVersion *types.ModuleVersion, renamedVariables map[string]string) core.SchemaFindings {
+	findings := u.CapabilityConfigUpdater.UpdateSchema(moduleSource, moduleConstraint, moduleVersion, renamedVariables)
+	*u.findings = append(*u.findings, findings...)
+	return findings
+}
@@ -6,8 +6,10 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/nullstone-io/iac/core"
 	"github.com/nullstone-io/iac/workspace"
 	moduleConfig "github.com/nullstone-io/module/config"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/nullstone-io/go-api-client.v0/types"
 )
@@ -47,6 +49,79 @@ func TestApplyChanges(t *testing.T) {
 		},
 	}
 
+	// input2 contains legacy capabilities that were created without a name
+	subdomainConn := func(blockName string) types.Connection {
+		return types.Connection{
+			Connection:    moduleConfig.Connection{Contract: "subdomain/aws/route53"},
+			DesiredTarget: &types.ConnectionTarget{BlockName: blockName},
+		}
+	}
+	input2 := types.WorkspaceConfig{
+		Source:        "nullstone/aws-fargate-service",
+		SourceVersion: "0.1.0",
+		Variables:     types.Variables{},
+		Connections:   types.Connections{},
+		Capabilities: types.CapabilityConfigs{
+			types.CapabilityConfig{
+				Id:            1,
+				TfId:          "cap_1",
+				Source:        "nullstone/fake-cap",
+				SourceVersion: "0.1.2",
+				Variables:     types.Variables{},
+				Connections:   types.Connections{"subdomain": subdomainConn("old-subdomain")},
+			},
+			types.CapabilityConfig{
+				Id:            2,
+				TfId:          "cap_2",
+				Source:        "nullstone/fake-cap",
+				SourceVersion: "0.1.2",
+				Variables:     types.Variables{},
+				Connections:   types.Connections{"subdomain": subdomainConn("old-other-subdomain")},
+			},
+		},
+	}
+	updatedSubdomainConn := func(blockName string) types.Connection {
+		conn := subdomainConn(blockName)
+		conn.EffectiveTarget = &types.ConnectionTarget{}
+		conn.OldReference = &types.ConnectionTarget{}
+		return conn
+	}
+
+	// input5 contains legacy capabilities in a different order than the config file whose connections partially changed
+	clusterConn := func(blockName string) types.Connection {
+		return types.Connection{
+			Connection:    moduleConfig.Connection{Contract: "cluster/aws/ecs:fargate"},
+			DesiredTarget: &types.ConnectionTarget{BlockName: blockName},
+		}
+	}
+	updatedClusterConn := func(blockName string) types.Connection {
+		conn := clusterConn(blockName)
+		conn.EffectiveTarget = &types.ConnectionTarget{}
+		conn.OldReference = &types.ConnectionTarget{}
+		return conn
+	}
+	input5 := mustClone(t, input2)
+	input5.Capabilities[0].Connections = types.Connections{"subdomain": subdomainConn("subdomain-b"), "cluster": clusterConn("old-cluster")}
+	input5.Capabilities[1].Connections = types.Connections{"subdomain": subdomainConn("subdomain-a"), "cluster": clusterConn("old-cluster")}
+	want5 := mustClone(t, input5)
+	want5.Capabilities[0].Name = "fake-cap-2"
+	want5.Capabilities[0].Connections = types.Connections{"subdomain": updatedSubdomainConn("subdomain-b"), "cluster": updatedClusterConn("new-cluster")}
+	want5.Capabilities[1].Name = "fake-cap"
+	want5.Capabilities[1].Connections = types.Connections{"subdomain": updatedSubdomainConn("subdomain-a"), "cluster": updatedClusterConn("new-cluster")}
+
+	// input3 contains a legacy capability that is named in the config file
+	input3 := mustClone(t, input1)
+	input3.Capabilities[0].Name = ""
+	want3 := mustClone(t, input1)
+	want3.Capabilities[0].Name = "renamed-cap"
+	want3.EnvVariables = types.EnvVariables{}
+
+	// want4 removes the inherited capability and adds an env-specific capability
+	want4 := mustClone(t, input1)
+	want4.Capabilities = types.CapabilityConfigs{
+		types.CapabilityConfig{Name: "debug-sidecar", TfId: "debug-sidecar"},
+	}
+
 	app1 := types.Block{Type: string(types.BlockTypeApplication), Name: "app1"}
 	previewEnv1 := types.Environment{Type: types.EnvTypePreview, Name: "f-123-something"}
 
@@ -177,6 +252,59 @@ func TestApplyChanges(t *testing.T) {
 				},
 			},
 		},
+		"name-less capabilities with changed connections are migrated and updated in place": {
+			input:           input2,
+			block:           app1,
+			env:             previewEnv1,
+			testFixturesDir: "scenario5",
+			want: types.WorkspaceConfig{
+				Source:        "nullstone/aws-fargate-service",
+				SourceVersion: "0.1.0",
+				Variables:     types.Variables{},
+				Connections:   types.Connections{},
+				Capabilities: types.CapabilityConfigs{
+					types.CapabilityConfig{
+						Id:            1,
+						Name:          "fake-cap",
+						TfId:          "cap_1",
+						Source:        "nullstone/fake-cap",
+						SourceVersion: "0.1.2",
+						Variables:     types.Variables{},
+						Connections:   types.Connections{"subdomain": updatedSubdomainConn("new-subdomain")},
+					},
+					types.CapabilityConfig{
+						Id:            2,
+						Name:          "fake-cap-2",
+						TfId:          "cap_2",
+						Source:        "nullstone/fake-cap",
+						SourceVersion: "0.1.2",
+						Variables:     types.Variables{},
+						Connections:   types.Connections{"subdomain": updatedSubdomainConn("other-subdomain")},
+					},
+				},
+			},
+		},
+		"legacy capabilities are matched by module and connections before position": {
+			input:           input5,
+			block:           app1,
+			env:             previewEnv1,
+			testFixturesDir: "scenario8",
+			want:            want5,
+		},
+		"name-less capability takes the name given in config": {
+			input:           input3,
+			block:           app1,
+			env:             previewEnv1,
+			testFixturesDir: "scenario6",
+			want:            want3,
+		},
+		"previews adds and removes capabilities": {
+			input:           input1,
+			block:           app1,
+			env:             previewEnv1,
+			testFixturesDir: "scenario7",
+			want:            want4,
+		},
 	}
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -193,15 +321,25 @@ func TestApplyChanges(t *testing.T) {
 					EnvIsProd: false,
 				},
 			}
-			err = ApplyChangesTo(*pmr, test.block, test.env, updater)
+			_, err = ApplyChangesTo(*pmr, test.block, test.env, updater)
 			require.NoError(t, err, "unexpected error")
 			if diff := cmp.Diff(test.want, got); diff != "" {
 				t.Errorf("-want, +got:\n%s", diff)
 			}
+			// CapabilityConfig defines its own equality, so verify which capability received each name
+			assert.Equal(t, capabilityNamesByTfId(test.want), capabilityNamesByTfId(got))
 		})
 	}
 }
 
+func capabilityNamesByTfId(wc types.WorkspaceConfig) map[string]string {
+	result := map[string]string{}
+	for _, cur := range wc.Capabilities {
+		result[cur.TfId] = cur.Name
+	}
+	return result
+}
+
 func mustClone[T any](t *testing.T, wc T) T {
 	raw, err := json.Marshal(wc)
 	require.NoError(t, err, "marshaling clone")
@@ -209,3 +347,113 @@ func mustClone[T any](t *testing.T, wc T) T {
 	require.NoError(t, json.Unmarshal(raw, &result), "unmarshaling clone")
 	return result
 }
+
+func TestApplyChangesTo_templates(t *testing.T) {
+	configYml := `version: "0.2"
+apps:
+  api:
+    environment:
+      QUEUE_NAME: "{{ NULLSTONE_BLOCK }}-{{ NULLSTONE_ENV_TYPE }}"
+      REPO: "{{ NULLSTONE_REPO }}"
+      HELM_VALUE: "{{ .Values.image.tag }}"
+`
+	input, err := ParseMap("https://github.com/acme/api", "acme/api", map[string]string{".nullstone/config.yml": configYml})
+	require.NoError(t, err)
+	require.Empty(t, Validate(input))
+
+	block := types.Block{OrgName: "acme", Name: "api", Type: "Application"}
+	env := types.Environment{OrgName: "acme", Name: "dev", Type: types.EnvTypePipeline}
+	got := &types.WorkspaceConfig{}
+	_, err = ApplyChangesTo(input, block, env, workspace.ConfigUpdater{Config: got})
+	require.NoError(t, err)
+	assert.Equal(t, types.EnvVariables{
+		"QUEUE_NAME": {Value: "api-" + string(types.EnvTypePipeline)},
+		"REPO":       {Value: "acme/api"},
+		"HELM_VALUE": {Value: "{{ .Values.image.tag }}"},
+	}, got.EnvVariables)
+}
+
+func TestApplyChangesTo_overridesCapabilityAcrossSyncs(t *testing.T) {
+	pmr, err := ParseConfigDir("", "TestApplyChanges", filepath.Join("test-fixtures", "scenario9"))
+	require.NoError(t, err)
+	app1 := types.Block{Type: string(types.BlockTypeApplication), Name: "app1"}
+	previewEnv := types.Environment{Type: types.EnvTypePreview, Name: "f-123-something"}
+
+	got := &types.WorkspaceConfig{
+		Capabilities: types.CapabilityConfigs{
+			{Id: 1, Name: "fake-cap", TfId: "fake-cap", Source: "nullstone/fake-cap"},
+		},
+	}
+	_, err = ApplyChangesTo(*pmr, app1, previewEnv, workspace.ConfigUpdater{Config: got})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"fake-cap": "fake-cap", "debug-sidecar": "debug-sidecar"}, capabilityNamesByTfId(*got))
+
+	// Nullstone assigns an id to the capability once it is created
+	for i, cur := range got.Capabilities {
+		if cur.Name == "debug-sidecar" {
+			got.Capabilities[i].Id = 2
+			got.Capabilities[i].Namespace = "sidecars"
+		}
+	}
+	_, err = ApplyChangesTo(*pmr, app1, previewEnv, workspace.ConfigUpdater{Config: got})
+	require.NoError(t, err)
+	require.Len(t, got.Capabilities, 2)
+	assert.Equal(t, int64(1), got.Capabilities[0].Id)
+	assert.Equal(t, "debug-sidecar", got.Capabilities[1].Name)
+	assert.Equal(t, int64(2), got.Capabilities[1].Id, "the capability added by the overrides file keeps its id")
+	assert.Equal(t, "sidecars", got.Capabilities[1].Namespace)
+}
+
+func TestApplyChangesTo_schemaFindings(t *testing.T) {
+	configYml := `version: "0.2"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+    module_version: "0.2.0"
+    renamed_vars:
+      cpu: service_cpu
+    capabilities:
+      - name: sidecar
+        module: nullstone/aws-sidecar
+        module_version: "0.2.0"
+`
+	input, err := ParseMap("", "acme/api", map[string]string{".nullstone/config.yml": configYml})
+	require.NoError(t, err)
+	// The module versions are loaded during Initialize, this simulates an upgrade that removed variables and a connection
+	app := input.Config.Applications["api"]
+	app.ModuleVersion = &types.ModuleVersion{
+		Version:  "0.2.0",
+		Manifest: moduleConfig.Manifest{Variables: map[string]moduleConfig.Variable{"service_cpu": {Type: "number"}}},
+	}
+	app.Capabilities[0].ModuleVersion = &types.ModuleVersion{Version: "0.2.0", Manifest: moduleConfig.Manifest{}}
+
+	got := &types.WorkspaceConfig{
+		Variables: types.Variables{
+			"cpu":      {Variable: moduleConfig.Variable{Type: "number"}, Value: 256},
+			"password": {Variable: moduleConfig.Variable{Type: "string", Sensitive: true}, Value: "hunter2"},
+			"unset":    {Variable: moduleConfig.Variable{Type: "string"}},
+		},
+		Connections: types.Connections{
+			"old_network": {DesiredTarget: &types.ConnectionTarget{BlockName: "network"}},
+		},
+		Capabilities: types.CapabilityConfigs{
+			{
+				Id:        1,
+				Name:      "sidecar",
+				TfId:      "sidecar",
+				Source:    "nullstone/aws-sidecar",
+				Variables: types.Variables{"legacy_image": {Variable: moduleConfig.Variable{Type: "string"}, Value: "nginx"}},
+			},
+		},
+	}
+	block := types.Block{Type: string(types.BlockTypeApplication), Name: "api"}
+	findings, err := ApplyChangesTo(input, block, types.Environment{Name: "dev"}, workspace.ConfigUpdater{Config: got})
+	require.NoError(t, err)
+	assert.Equal(t, core.SchemaFindings{
+		{ChangeType: types.ChangeTypeConnection, Name: "old_network", Action: core.SchemaFindingDropped, Value: types.ConnectionTarget{BlockName: "network"}},
+		{ChangeType: types.ChangeTypeVariable, Name: "cpu", Action: core.SchemaFindingRenamed, RenamedTo: "service_cpu", Value: 256},
+		{ChangeType: types.ChangeTypeVariable, Name: "password", Action: core.SchemaFindingDropped},
+		{ChangeType: types.ChangeTypeVariable, CapabilityName: "sidecar", Name: "legacy_image", Action: core.SchemaFindingDropped, Value: "nginx"},
+	}, findings)
+	assert.Equal(t, 256, got.Variables["service_cpu"].Value)
+}
@@ -0,0 +1,132 @@
+package blocks
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/nullstone-io/iac"
+	"github.com/nullstone-io/iac/config"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+// ExistingBlock is a block that already exists in the stack
+type ExistingBlock struct {
+	types.Block
+	// OwningRepoUrl is the repository whose IaC files manage the block (empty if managed through the UI)
+	OwningRepoUrl string `json:"owningRepoUrl"`
+}
+
+// BlockPlan describes how a stack's blocks need to change to match a repo's IaC files
+// Nothing is deleted implicitly; blocks that disappear from IaC are reported as destroy candidates for approval
+type BlockPlan struct {
+	// Create contains blocks declared in IaC that do not exist in the stack
+	Create []types.Block `json:"create"`
+	// Update contains existing blocks whose type or module source differs from IaC
+	Update []BlockChange `json:"update"`
+	// Claim contains existing blocks that will be managed by this repo's IaC files
+	Claim []OwnershipChange `json:"claim"`
+	// DestroyCandidates contains blocks managed by this repo that are no longer declared in its IaC files
+	DestroyCandidates []DestroyCandidate `json:"destroyCandidates"`
+}
+
+// IsEmpty returns true if the stack already matches the IaC files
+func (p BlockPlan) IsEmpty() bool {
+	return len(p.Create) == 0 && len(p.Update) == 0 && len(p.Claim) == 0 && len(p.DestroyCandidates) == 0
+}
+
+type BlockChangeField string
+
+const (
+	BlockChangeFieldType         BlockChangeField = "type"
+	BlockChangeFieldModuleSource BlockChangeField = "moduleSource"
+)
+
+type BlockChange struct {
+	Current types.Block        `json:"current"`
+	Desired types.Block        `json:"desired"`
+	Fields  []BlockChangeField `json:"fields"`
+}
+
+type OwnershipChange struct {
+	Block           types.Block `json:"block"`
+	PreviousRepoUrl string      `json:"previousRepoUrl"`
+	RepoUrl         string      `json:"repoUrl"`
+	// RequiresApproval is true when the block is taken from another repo's IaC files
+	RequiresApproval bool `json:"requiresApproval"`
+}
+
+type DestroyCandidate struct {
+	Block         types.Block `json:"block"`
+	OwningRepoUrl string      `json:"owningRepoUrl"`
+}
+
+// Plan compares the blocks declared in a repo's IaC files (config and every overrides file) against the stack's existing blocks
+func Plan(input iac.ConfigFiles, orgName string, stackId int64, existing []ExistingBlock) BlockPlan {
+	desired := desiredBlocks(input, orgName, stackId)
+	current := map[string]ExistingBlock{}
+	for _, cur := range existing {
+		current[cur.Name] = cur
+	}
+
+	plan := BlockPlan{}
+	for _, name := range slices.Sorted(maps.Keys(desired)) {
+		des := desired[name]
+		cur, ok := current[name]
+		if !ok {
+			plan.Create = append(plan.Create, des)
+			continue
+		}
+
+		var fields []BlockChangeField
+		if cur.Type != des.Type {
+			fields = append(fields, BlockChangeFieldType)
+		}
+		if cur.ModuleSource != des.ModuleSource && des.ModuleSource != "" {
+			fields = append(fields, BlockChangeFieldModuleSource)
+		}
+		if len(fields) > 0 {
+			plan.Update = append(plan.Update, BlockChange{Current: cur.Block, Desired: des, Fields: fields})
+		}
+
+		if cur.OwningRepoUrl != input.RepoUrl {
+			plan.Claim = append(plan.Claim, OwnershipChange{
+				Block:            cur.Block,
+				PreviousRepoUrl:  cur.OwningRepoUrl,
+				RepoUrl:          input.RepoUrl,
+				RequiresApproval: cur.OwningRepoUrl != "",
+			})
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(current)) {
+		cur := current[name]
+		if _, ok := desired[name]; !ok && cur.OwningRepoUrl != "" && cur.OwningRepoUrl == input.RepoUrl {
+			plan.DestroyCandidates = append(plan.DestroyCandidates, DestroyCandidate{Block: cur.Block, OwningRepoUrl: cur.OwningRepoUrl})
+		}
+	}
+	return plan
+}
+
+// desiredBlocks collects blocks from config.yml, then overrides files in name order
+// A block declared in multiple files is taken from the first file
+// An overrides entry that does not declare its own module only changes the block's values in one env, it does not declare a block
+func desiredBlocks(input iac.ConfigFiles, orgName string, stackId int64) map[string]types.Block {
+	result := map[string]types.Block{}
+	add := func(ec *config.EnvConfiguration) {
+		for _, block := range ec.ToBlocks(orgName, stackId) {
+			if bc := ec.FindBlockConfigurationByName(block.Name); bc == nil || bc.ModuleSource == "" || bc.ModuleInherited {
+				continue
+			}
+			if _, ok := result[block.Name]; !ok {
+				result[block.Name] = block
+			}
+		}
+	}
+	if input.Config != nil {
+		add(input.Config)
+	}
+	for _, name := range slices.Sorted(maps.Keys(input.Overrides)) {
+		add(input.Overrides[name])
+	}
+	return result
+}
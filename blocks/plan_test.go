@@ -0,0 +1,103 @@
+package blocks
+
+import (
+	"testing"
+
+	"github.com/nullstone-io/iac"
+	"github.com/nullstone-io/iac/config"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+func TestPlan(t *testing.T) {
+	repoUrl := "https://github.com/acme/api"
+	app := func(name, module string) *config.AppConfiguration {
+		return &config.AppConfiguration{BlockConfiguration: config.BlockConfiguration{
+			Type: config.BlockTypeApplication, Name: name, ModuleSource: module,
+		}}
+	}
+	input := iac.ConfigFiles{
+		RepoUrl: repoUrl,
+		Config: &config.EnvConfiguration{
+			Applications: map[string]*config.AppConfiguration{
+				"api":    app("api", "nullstone/aws-fargate-service"),
+				"worker": app("worker", "nullstone/aws-fargate-service"),
+				"web":    app("web", "nullstone/aws-s3-site"),
+			},
+			Datastores: map[string]*config.DatastoreConfiguration{
+				"db": {BlockConfiguration: config.BlockConfiguration{Type: config.BlockTypeDatastore, Name: "db", ModuleSource: "nullstone/aws-rds-postgres"}},
+			},
+		},
+		Overrides: map[string]*config.EnvConfiguration{
+			"previews": {
+				Applications: map[string]*config.AppConfiguration{
+					"preview-tools": app("preview-tools", "nullstone/aws-fargate-service"),
+				},
+			},
+		},
+	}
+	existing := []ExistingBlock{
+		{Block: types.Block{Name: "api", Type: "Application", ModuleSource: "nullstone/aws-fargate-service"}, OwningRepoUrl: repoUrl},
+		{Block: types.Block{Name: "web", Type: "Application", ModuleSource: "nullstone/aws-beanstalk"}, OwningRepoUrl: repoUrl},
+		{Block: types.Block{Name: "db", Type: "Datastore", ModuleSource: "nullstone/aws-rds-postgres"}, OwningRepoUrl: "https://github.com/acme/infra"},
+		{Block: types.Block{Name: "worker", Type: "Application", ModuleSource: "nullstone/aws-fargate-service"}},
+		{Block: types.Block{Name: "legacy", Type: "Application", ModuleSource: "nullstone/aws-fargate-service"}, OwningRepoUrl: repoUrl},
+		{Block: types.Block{Name: "ui-managed", Type: "Application", ModuleSource: "nullstone/aws-fargate-service"}},
+	}
+
+	got := Plan(input, "acme", 1, existing)
+
+	names := func(blocks []types.Block) []string {
+		var result []string
+		for _, b := range blocks {
+			result = append(result, b.Name)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"preview-tools"}, names(got.Create))
+	if assert.Len(t, got.Update, 1) {
+		assert.Equal(t, "web", got.Update[0].Current.Name)
+		assert.Equal(t, []BlockChangeField{BlockChangeFieldModuleSource}, got.Update[0].Fields)
+	}
+	assert.Equal(t, []OwnershipChange{
+		{Block: existing[2].Block, PreviousRepoUrl: "https://github.com/acme/infra", RepoUrl: repoUrl, RequiresApproval: true},
+		{Block: existing[3].Block, PreviousRepoUrl: "", RepoUrl: repoUrl, RequiresApproval: false},
+	}, got.Claim)
+	assert.Equal(t, []DestroyCandidate{{Block: existing[4].Block, OwningRepoUrl: repoUrl}}, got.DestroyCandidates)
+	assert.False(t, got.IsEmpty())
+}
+
+func TestPlan_overridesWithoutModule(t *testing.T) {
+	repoUrl := "https://github.com/acme/api"
+	input := iac.ConfigFiles{
+		RepoUrl: repoUrl,
+		Config: &config.EnvConfiguration{
+			Applications: map[string]*config.AppConfiguration{
+				"api": {BlockConfiguration: config.BlockConfiguration{Type: config.BlockTypeApplication, Name: "api", ModuleSource: "nullstone/aws-fargate-service"}},
+			},
+		},
+		Overrides: map[string]*config.EnvConfiguration{
+			"prod": {
+				Applications: map[string]*config.AppConfiguration{
+					// Inherits its module from config.yml
+					"api": {BlockConfiguration: config.BlockConfiguration{
+						Type: config.BlockTypeApplication, Name: "api", ModuleSource: "nullstone/aws-fargate-service", ModuleInherited: true,
+					}},
+					// Managed through the UI
+					"ui-managed": {BlockConfiguration: config.BlockConfiguration{Type: config.BlockTypeApplication, Name: "ui-managed"}},
+					// Does not exist
+					"typo": {BlockConfiguration: config.BlockConfiguration{Type: config.BlockTypeApplication, Name: "typo"}},
+				},
+			},
+		},
+	}
+	existing := []ExistingBlock{
+		{Block: types.Block{Name: "api", Type: "Application", ModuleSource: "nullstone/aws-fargate-service"}, OwningRepoUrl: repoUrl},
+		{Block: types.Block{Name: "ui-managed", Type: "Application", ModuleSource: "nullstone/aws-fargate-service"}},
+	}
+
+	got := Plan(input, "acme", 1, existing)
+	assert.Empty(t, got.Create)
+	assert.Empty(t, got.Claim)
+	assert.True(t, got.IsEmpty())
+}
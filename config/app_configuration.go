@@ -2,7 +2,6 @@ package config
 
 import (
 	"context"
-	"strings"
 
 	"github.com/nullstone-io/iac/core"
 	"github.com/nullstone-io/iac/yaml"
@@ -16,9 +15,9 @@ var (
 type AppConfiguration struct {
 	BlockConfiguration
 
-	Framework    string                   `json:"framework"`
-	EnvVariables map[string]string        `json:"envVars"`
-	Capabilities CapabilityConfigurations `json:"capabilities"`
+	Framework    string                    `json:"framework"`
+	EnvVariables EnvVariableConfigurations `json:"envVars"`
+	Capabilities CapabilityConfigurations  `json:"capabilities"`
 }
 
 func convertCapabilities(parsed yaml.CapabilityConfigurations) CapabilityConfigurations {
@@ -35,6 +34,8 @@ func convertCapabilities(parsed yaml.CapabilityConfigurations) CapabilityConfigu
 			Variables:        convertVariables(capValue.Variables),
 			Connections:      convertConnections(capValue.Connections),
 			Namespace:        capValue.Namespace,
+			RenamedVariables: capValue.RenamedVariables,
+			Disabled:         capValue.Enabled != nil && !*capValue.Enabled,
 		}
 	}
 	return result
@@ -47,7 +48,7 @@ func convertAppConfigurations(parsed map[string]yaml.AppConfiguration) map[strin
 		apps[name] = &AppConfiguration{
 			BlockConfiguration: *bc,
 			Framework:          value.Framework,
-			EnvVariables:       value.EnvVariables,
+			EnvVariables:       convertEnvVariables(value.EnvVariables),
 			Capabilities:       convertCapabilities(value.Capabilities),
 		}
 	}
@@ -62,6 +63,7 @@ func (a *AppConfiguration) Initialize(ctx context.Context, resolver core.Initial
 
 func (a *AppConfiguration) Resolve(ctx context.Context, resolver core.ResolveResolver, finder core.IacFinder, ic core.IacContext, pc core.ObjectPathContext) core.ResolveErrors {
 	errs := a.BlockConfiguration.Resolve(ctx, resolver, finder, ic, pc)
+	errs = append(errs, a.EnvVariables.Resolve(ctx, resolver, pc)...)
 	errs = append(errs, a.Capabilities.Resolve(ctx, resolver, finder, ic, pc)...)
 	return errs
 }
@@ -73,6 +75,11 @@ func (a *AppConfiguration) Validate(ic core.IacContext, pc core.ObjectPathContex
 	return errs
 }
 
+func (a *AppConfiguration) Warnings(ic core.IacContext, pc core.ObjectPathContext, sm core.SourceMap) core.Diagnostics {
+	result := a.BlockConfiguration.Warnings(ic, pc, sm)
+	return append(result, a.Capabilities.Warnings(ic, pc, sm)...)
+}
+
 func hasInvalidChars(r rune) bool {
 	return (r < 'A' || r > 'z') && r != '_' && (r < '0' || r > '9')
 }
@@ -82,25 +89,7 @@ func startsWithNumber(s string) bool {
 }
 
 func (a *AppConfiguration) ValidateEnvVariables(pc core.ObjectPathContext) core.ValidateErrors {
-	if len(a.EnvVariables) == 0 {
-		return nil
-	}
-
-	errs := core.ValidateErrors{}
-	for k, _ := range a.EnvVariables {
-		curpc := pc.SubKey("environment", k)
-		if startsWithNumber(k) {
-			errs = append(errs, core.EnvVariableKeyStartsWithNumberError(curpc))
-		}
-		if strings.IndexFunc(k, hasInvalidChars) != -1 {
-			errs = append(errs, core.EnvVariableKeyInvalidCharsError(curpc))
-		}
-	}
-
-	if len(errs) > 0 {
-		return errs
-	}
-	return nil
+	return a.EnvVariables.Validate(pc)
 }
 
 func (a *AppConfiguration) Normalize(ctx context.Context, pc core.ObjectPathContext, resolver core.ConnectionResolver) core.NormalizeErrors {
@@ -121,14 +110,12 @@ func (a *AppConfiguration) ApplyChangesTo(ic core.IacContext, updater core.Works
 		return err
 	}
 
-	if ic.IsOverrides {
-		for name, value := range a.EnvVariables {
-			updater.AddOrUpdateEnvVariable(name, value, false)
-		}
-	} else {
-		updater.RemoveEnvVariablesNotIn(a.EnvVariables)
-		for name, value := range a.EnvVariables {
-			updater.AddOrUpdateEnvVariable(name, value, false)
+	if !ic.IsOverrides {
+		updater.RemoveEnvVariablesNotIn(a.EnvVariables.Values())
+	}
+	for name, c := range a.EnvVariables {
+		if err := updater.AddOrUpdateEnvVariable(name, c.EffectiveValue(), c.IsSensitive()); err != nil {
+			return err
 		}
 	}
 
@@ -3,6 +3,8 @@ package config
 import (
 	"context"
 	"fmt"
+	"path"
+	"strings"
 
 	"github.com/nullstone-io/iac/core"
 	"github.com/nullstone-io/iac/yaml"
@@ -37,7 +39,16 @@ type BlockConfiguration struct {
 	Variables        VariableConfigurations   `json:"vars"`
 	Connections      ConnectionConfigurations `json:"connections"`
 	IsShared         bool                     `json:"isShared"`
-	Metadata         MetadataConfiguration    `json:"metadata"`
+	// RenamedVariables maps a variable removed by a module upgrade to the variable that replaces it
+	RenamedVariables map[string]string     `json:"renamedVars,omitempty"`
+	Metadata         MetadataConfiguration `json:"metadata"`
+
+	// ModuleInherited is true when a block in an overrides file omits the module
+	// ModuleSource and ModuleConstraint are then taken from the primary config file or the existing workspace
+	ModuleInherited bool `json:"moduleInherited"`
+	// LockedVersion is the module version pinned by the lock file
+	// Initialize resolves this version instead of ModuleConstraint; the workspace still records ModuleConstraint
+	LockedVersion string `json:"lockedVersion,omitempty"`
 
 	// These fields are populated via Resolve()
 	Module        *types.Module        `json:"module"`
@@ -98,6 +109,7 @@ func blockConfigFromYaml(name string, value yaml.BlockConfiguration, blockType B
 		Variables:        convertVariables(value.Variables),
 		Connections:      convertConnections(value.Connections),
 		IsShared:         value.IsShared,
+		RenamedVariables: value.RenamedVariables,
 		Metadata:         convertMetadata(value.Metadata),
 	}
 }
@@ -118,15 +130,20 @@ func (b *BlockConfiguration) Initialize(ctx context.Context, resolver core.Initi
 		b.Connections = ConnectionConfigurations{}
 	}
 	if ic.IsOverrides && b.ModuleSource == "" {
-		// TODO: Add support for validating variables and connections in an overrides file that has no module source
-		return nil
+		if err := b.inheritWorkspaceModule(ctx, resolver, ic, pc); err != nil {
+			return core.InitializeErrors{*err}
+		}
+		if b.ModuleSource == "" {
+			// The block doesn't exist yet, there is no module to validate against
+			return nil
+		}
 	}
 
 	errs := core.InitializeErrors{}
 
 	contract := types.ModuleContractName{Category: string(b.Category), Provider: "*", Platform: "*"}
 	manifest := config.Manifest{Variables: map[string]config.Variable{}, Connections: map[string]config.Connection{}}
-	m, mv, err := core.GetModuleVersion(ctx, resolver, pc, b.ModuleSource, b.ModuleConstraint, contract)
+	m, mv, err := core.GetModuleVersion(ctx, resolver, pc, b.ModuleSource, lockedOr(b.LockedVersion, b.ModuleConstraint), contract)
 	if err != nil {
 		errs = append(errs, *err)
 	} else {
@@ -140,6 +157,54 @@ func (b *BlockConfiguration) Initialize(ctx context.Context, resolver core.Initi
 	return errs
 }
 
+// lockedOr returns the locked version if the module is pinned by the lock file
+func lockedOr(lockedVersion, moduleConstraint string) string {
+	if lockedVersion != "" {
+		return lockedVersion
+	}
+	return moduleConstraint
+}
+
+// InheritModule sets the module for a block in an overrides file that omits the module
+func (b *BlockConfiguration) InheritModule(moduleSource, moduleConstraint string) {
+	if b.ModuleSource != "" || moduleSource == "" {
+		return
+	}
+	b.ModuleSource = moduleSource
+	b.ModuleConstraint = moduleConstraint
+	b.ModuleInherited = true
+}
+
+// inheritWorkspaceModule loads the module from the block's workspace in the environment of the overrides file
+// This is skipped if the resolver is unable to look up workspace module configs
+func (b *BlockConfiguration) inheritWorkspaceModule(ctx context.Context, resolver core.InitializeResolver, ic core.IacContext, pc core.ObjectPathContext) *core.InitializeError {
+	wmcResolver, ok := resolver.(core.WorkspaceModuleConfigResolver)
+	if !ok {
+		return nil
+	}
+	ct := types.ConnectionTarget{BlockName: b.Name, EnvName: overridesEnvName(ic.Filename)}
+	moduleConfig, err := wmcResolver.ResolveWorkspaceModuleConfig(ctx, ct)
+	if err != nil {
+		if core.IsMissingResource(err) {
+			return nil
+		}
+		return core.InheritModuleLookupFailedError(pc, err)
+	}
+	b.InheritModule(moduleConfig.Module, moduleConfig.ModuleConstraint)
+	return nil
+}
+
+// overridesEnvName returns the name of the environment that an overrides file applies to
+// `previews.yml` applies to every preview environment, so this returns "" to use the resolver's current environment
+func overridesEnvName(filename string) string {
+	_, name := path.Split(filename)
+	name = strings.TrimSuffix(name, path.Ext(name))
+	if name == "previews" {
+		return ""
+	}
+	return name
+}
+
 func (b *BlockConfiguration) Resolve(ctx context.Context, resolver core.ResolveResolver, finder core.IacFinder, ic core.IacContext, pc core.ObjectPathContext) core.ResolveErrors {
 	errs := core.ResolveErrors{}
 	errs = append(errs, b.Connections.Resolve(ctx, resolver, finder, ic, pc)...)
@@ -148,14 +213,14 @@ func (b *BlockConfiguration) Resolve(ctx context.Context, resolver core.ResolveR
 
 func (b *BlockConfiguration) Validate(ic core.IacContext, pc core.ObjectPathContext) core.ValidateErrors {
 	if b.Module == nil {
-		// TODO: Add support for validating variables and connections in an overrides file that has no module source
+		// We can't perform validation if the module isn't loaded
 		return nil
 	}
 
 	moduleName := fmt.Sprintf("%s/%s@%s", b.Module.OrgName, b.Module.Name, b.ModuleVersion.Version)
 
 	errs := core.ValidateErrors{}
-	errs = append(errs, b.Variables.Validate(pc, moduleName)...)
+	errs = append(errs, b.Variables.Validate(ic, pc, moduleName, b.ModuleVersion.Manifest)...)
 	errs = append(errs, b.Connections.Validate(pc, moduleName)...)
 	errs = append(errs, b.validateDataClassification(pc)...)
 	if len(errs) > 0 {
@@ -171,13 +236,31 @@ func (b *BlockConfiguration) validateDataClassification(pc core.ObjectPathContex
 	if level == "" || level.Valid() {
 		return nil
 	}
+	return core.ValidateErrors{
+		*core.InvalidDataClassificationError(pc.SubField("metadata").SubField("dataclassification"), string(level), classificationLevels()),
+	}
+}
+
+func classificationLevels() []string {
 	allowed := make([]string, 0, len(types.AllClassificationLevels()))
 	for _, l := range types.AllClassificationLevels() {
 		allowed = append(allowed, string(l))
 	}
-	return core.ValidateErrors{
-		*core.InvalidDataClassificationError(pc.SubField("metadata").SubField("dataclassification"), string(level), allowed),
+	return allowed
+}
+
+// Warnings reports configuration that is valid, but deprecated or risky
+// Checks on how the file is written use the file's SourceMap and are skipped if it's empty
+func (b *BlockConfiguration) Warnings(ic core.IacContext, pc core.ObjectPathContext, sm core.SourceMap) core.Diagnostics {
+	var result core.Diagnostics
+	if sm.Has(pc.SubField("module")) && !sm.Has(pc.SubField("module_version")) {
+		version := ""
+		if b.ModuleVersion != nil {
+			version = b.ModuleVersion.Version
+		}
+		result = append(result, core.DefaultModuleVersionWarning(pc.SubField("module"), b.ModuleSource, version))
 	}
+	return result
 }
 
 func (b *BlockConfiguration) Normalize(ctx context.Context, pc core.ObjectPathContext, resolver core.ConnectionResolver) core.NormalizeErrors {
@@ -200,9 +283,14 @@ func (b *BlockConfiguration) ToBlock(orgName string, stackId int64) types.Block
 }
 
 func (b *BlockConfiguration) ApplyChangesTo(ic core.IacContext, updater core.WorkspaceConfigUpdater) error {
-	updater.UpdateSchema(b.ModuleSource, b.ModuleConstraint, b.ModuleVersion)
+	if !b.ModuleInherited {
+		// An inherited module is only used for validation, the schema is owned by the primary config file
+		updater.UpdateSchema(b.ModuleSource, b.ModuleConstraint, b.ModuleVersion, b.RenamedVariables)
+	}
 	for name, vc := range b.Variables {
-		updater.UpdateVariableValue(name, vc.Value)
+		if err := updater.UpdateVariableValue(name, vc.Value); err != nil {
+			return err
+		}
 	}
 	for name, cc := range b.Connections {
 		updater.UpdateConnectionTarget(name, cc.DesiredTarget, cc.EffectiveTarget)
@@ -1,13 +1,18 @@
 package config
 
 import (
+	"context"
+	"maps"
+	"slices"
 	"testing"
 
 	"github.com/nullstone-io/iac/core"
 	"github.com/nullstone-io/iac/workspace"
 	"github.com/nullstone-io/iac/yaml"
+	"github.com/nullstone-io/module/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/nullstone-io/go-api-client.v0/artifacts"
 	"gopkg.in/nullstone-io/go-api-client.v0/types"
 )
 
@@ -56,6 +61,7 @@ func TestBlockConfiguration_validateDataClassification(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "datastores.customer-db.metadata", Field: "dataclassification"},
 					ErrorMessage:      "Invalid data classification value (top-secret), must be one of: public, operational, customer-content, restricted, critical",
+					Code:              core.CodeInvalidDataClassification,
 				},
 			},
 		},
@@ -88,3 +94,132 @@ func TestBlockConfiguration_ApplyChangesTo_dataClassification(t *testing.T) {
 		assert.Equal(t, types.ClassificationLevel(""), wc.Metadata.DataClassification)
 	})
 }
+
+// ApplyChangesTo should carry renamed variables over when a module upgrade drops them
+func TestBlockConfiguration_ApplyChangesTo_droppedValues(t *testing.T) {
+	wc := &types.WorkspaceConfig{
+		Variables: types.Variables{
+			"size":     {Variable: config.Variable{Type: "string"}, Value: "db.t3.micro"},
+			"legacy":   {Variable: config.Variable{Type: "number"}, Value: 1},
+			"password": {Variable: config.Variable{Type: "string", Sensitive: true}, Value: "hunter2"},
+			"unset":    {Variable: config.Variable{Type: "string"}},
+		},
+		Connections: types.Connections{
+			"old_network": {DesiredTarget: &types.ConnectionTarget{BlockName: "network"}},
+			"unset":       {},
+		},
+	}
+	updater := workspace.ConfigUpdater{Config: wc}
+	bc := &BlockConfiguration{
+		ModuleSource:     "nullstone/aws-rds-postgres",
+		ModuleConstraint: "latest",
+		RenamedVariables: map[string]string{"size": "instance_class", "legacy": "missing"},
+		ModuleVersion: &types.ModuleVersion{
+			Version: "0.2.0",
+			Manifest: config.Manifest{
+				Variables:   map[string]config.Variable{"instance_class": {Type: "string"}},
+				Connections: map[string]config.Connection{"network": {Contract: "network/aws/vpc"}},
+			},
+		},
+	}
+	require.NoError(t, bc.ApplyChangesTo(core.IacContext{}, updater))
+
+	assert.Equal(t, []string{"instance_class"}, slices.Sorted(maps.Keys(wc.Variables)))
+	assert.Equal(t, "db.t3.micro", wc.Variables["instance_class"].Value)
+	assert.Equal(t, []string{"network"}, slices.Sorted(maps.Keys(wc.Connections)))
+}
+
+type inheritModuleResolver struct {
+	moduleConfigs map[string]core.WorkspaceModuleConfig
+	module        *types.Module
+	moduleVersion *types.ModuleVersion
+}
+
+func (r inheritModuleResolver) ResolveModuleVersion(ctx context.Context, source artifacts.ModuleSource, version string) (*types.Module, *types.ModuleVersion, error) {
+	return r.module, r.moduleVersion, nil
+}
+
+func (r inheritModuleResolver) ResolveWorkspaceModuleConfig(ctx context.Context, ct types.ConnectionTarget) (core.WorkspaceModuleConfig, error) {
+	return r.moduleConfigs[ct.EnvName+"/"+ct.BlockName], nil
+}
+
+// An overrides file that omits the module is validated against the module in config.yml or the existing workspace
+func TestBlockConfiguration_Initialize_inheritedModule(t *testing.T) {
+	resolver := inheritModuleResolver{
+		moduleConfigs: map[string]core.WorkspaceModuleConfig{
+			"prod/workspace-db":  {Module: "nullstone/aws-rds-postgres", ModuleConstraint: "latest"},
+			"staging/staging-db": {Module: "nullstone/aws-rds-postgres", ModuleConstraint: "latest"},
+		},
+		module: &types.Module{OrgName: "nullstone", Name: "aws-rds-postgres", Category: types.CategoryDatastore},
+		moduleVersion: &types.ModuleVersion{
+			Version: "0.1.0",
+			Manifest: config.Manifest{
+				Variables:   map[string]config.Variable{"instance_class": {Type: "string"}},
+				Connections: map[string]config.Connection{},
+			},
+		},
+	}
+	base := &EnvConfiguration{
+		Blocks: map[string]*BlockConfiguration{
+			"config-db": {Name: "config-db", ModuleSource: "nullstone/aws-rds-postgres", ModuleConstraint: "0.1.0"},
+		},
+	}
+	ic := core.IacContext{Filename: "prod.yml", IsOverrides: true}
+
+	tests := []struct {
+		name          string
+		blockName     string
+		wantInherited bool
+		want          core.ValidateErrors
+	}{
+		{
+			name:          "inherits module from config.yml",
+			blockName:     "config-db",
+			wantInherited: true,
+			want: core.ValidateErrors{
+				*core.VariableDoesNotExistError(core.ObjectPathContext{Path: "blocks.config-db", Field: "vars", Key: "instance_clas"}, "nullstone/aws-rds-postgres@0.1.0"),
+			},
+		},
+		{
+			name:          "inherits module from workspace",
+			blockName:     "workspace-db",
+			wantInherited: true,
+			want: core.ValidateErrors{
+				*core.VariableDoesNotExistError(core.ObjectPathContext{Path: "blocks.workspace-db", Field: "vars", Key: "instance_clas"}, "nullstone/aws-rds-postgres@0.1.0"),
+			},
+		},
+		{
+			name:          "workspace only exists in another env",
+			blockName:     "staging-db",
+			wantInherited: false,
+			want:          nil,
+		},
+		{
+			name:          "block does not exist yet",
+			blockName:     "new-db",
+			wantInherited: false,
+			want:          nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overrides := &EnvConfiguration{
+				IacContext: ic,
+				Blocks: map[string]*BlockConfiguration{
+					tt.blockName: {
+						Type:      BlockTypeBlock,
+						Category:  types.CategoryDatastore,
+						Name:      tt.blockName,
+						Variables: VariableConfigurations{"instance_clas": {Value: "db.t3.micro"}},
+					},
+				},
+			}
+			overrides.InheritModules(base)
+			pc := core.NewObjectPathContextKey("blocks", tt.blockName)
+			block := overrides.Blocks[tt.blockName]
+			assert.Empty(t, block.Initialize(context.Background(), resolver, ic, pc))
+			assert.Equal(t, tt.wantInherited, block.ModuleInherited)
+			assert.Equal(t, tt.want, block.Validate(ic, pc))
+		})
+	}
+}
@@ -52,7 +52,7 @@ func (c CapabilityConfigurations) Validate(ic core.IacContext, pc core.ObjectPat
 
 func (c CapabilityConfigurations) ToCapabilities() []types.Capability {
 	var result []types.Capability
-	for _, cur := range c {
+	for _, cur := range c.Enabled() {
 		capability := types.Capability{
 			IdModel:             types.IdModel{Id: cur.Id},
 			Name:                cur.Name,
@@ -71,12 +71,24 @@ func (c CapabilityConfigurations) ToCapabilities() []types.Capability {
 func (c CapabilityConfigurations) ApplyChangesTo(ic core.IacContext, updater core.WorkspaceConfigUpdater) error {
 	if ic.IsOverrides {
 		for _, cur := range c {
-			// TODO: Add support to add capabilities in an overrides file?
-			cur.UpdateCapability(ic, updater)
+			switch {
+			case cur.Disabled:
+				updater.RemoveCapability(cur.Identity())
+			case cur.IsDeclaration():
+				// A capability with a module is added if it's not inherited from the primary config file
+				if err := cur.ApplyChangesTo(ic, updater); err != nil {
+					return err
+				}
+			default:
+				if err := cur.UpdateCapability(ic, updater); err != nil {
+					return err
+				}
+			}
 		}
 	} else {
-		updater.RemoveCapabilitiesNotIn(c.Identities())
-		for _, cur := range c {
+		enabled := c.Enabled()
+		updater.RemoveCapabilitiesNotIn(enabled.Identities())
+		for _, cur := range enabled {
 			if err := cur.ApplyChangesTo(ic, updater); err != nil {
 				return err
 			}
@@ -86,6 +98,85 @@ func (c CapabilityConfigurations) ApplyChangesTo(ic core.IacContext, updater cor
 	return nil
 }
 
+// Enabled returns the capabilities that are not disabled
+func (c CapabilityConfigurations) Enabled() CapabilityConfigurations {
+	result := make(CapabilityConfigurations, 0, len(c))
+	for _, cur := range c {
+		if !cur.Disabled {
+			result = append(result, cur)
+		}
+	}
+	return result
+}
+
+// Warnings reports capabilities that are valid, but deprecated or risky
+func (c CapabilityConfigurations) Warnings(ic core.IacContext, pc core.ObjectPathContext, sm core.SourceMap) core.Diagnostics {
+	var result core.Diagnostics
+	for i, cur := range c {
+		if cur.Disabled || !cur.IsDeclaration() {
+			continue
+		}
+		cpc := pc.SubIndex("capabilities", i)
+		if cur.Name == "" || cur.GeneratedName {
+			result = append(result, core.UnnamedCapabilityWarning(cpc, cur.Name))
+		}
+		if sm.Has(cpc.SubField("module")) && !sm.Has(cpc.SubField("module_version")) {
+			version := ""
+			if cur.ModuleVersion != nil {
+				version = cur.ModuleVersion.Version
+			}
+			result = append(result, core.DefaultModuleVersionWarning(cpc.SubField("module"), cur.ModuleSource, version))
+		}
+	}
+	return result
+}
+
+// assignNames names capabilities that were declared without a name
+// This keeps a capability's identity stable when its connections change
+// This is only used for the primary config file since an overrides file refers to inherited capabilities without a name
+func (c CapabilityConfigurations) assignNames() {
+	names := make([]string, len(c))
+	moduleSources := make([]string, len(c))
+	for i, cur := range c {
+		names[i] = cur.Name
+		moduleSources[i] = cur.ModuleSource
+	}
+	for i, name := range core.AssignCapabilityNames(names, moduleSources) {
+		if c[i].Name == "" && name != "" {
+			c[i].Name = name
+			c[i].GeneratedName = true
+		}
+	}
+}
+
+// inherit marks each capability in an overrides file that refers to a capability in base
+// Only a capability with a name that base does not have is declared by the overrides file
+func (c CapabilityConfigurations) inherit(base CapabilityConfigurations) {
+	for _, cur := range c {
+		if cur.Name == "" || base.findByName(cur.Name) != nil {
+			cur.Inherited = true
+		}
+	}
+}
+
+func (c CapabilityConfigurations) findByIdentity(identity core.CapabilityIdentity) *CapabilityConfiguration {
+	for _, cur := range c {
+		if cur.Identity().Match(identity) {
+			return cur
+		}
+	}
+	return nil
+}
+
+func (c CapabilityConfigurations) findByName(name string) *CapabilityConfiguration {
+	for _, cur := range c {
+		if cur.Name == name {
+			return cur
+		}
+	}
+	return nil
+}
+
 func (c CapabilityConfigurations) Initialize(ctx context.Context, resolver core.InitializeResolver, ic core.IacContext,
 	pc core.ObjectPathContext, appModule *types.Module) core.InitializeErrors {
 	if len(c) == 0 {
@@ -122,12 +213,22 @@ type CapabilityConfiguration struct {
 	// It is not used in the IaC representation
 	Id int64 `json:"id"`
 
-	Name             string                   `json:"name"`
+	Name string `json:"name"`
+	// GeneratedName is true if the capability was declared without a name and Name was generated from its module
+	GeneratedName    bool                     `json:"generatedName,omitempty"`
 	ModuleSource     string                   `json:"moduleSource"`
 	ModuleConstraint string                   `json:"moduleConstraint"`
 	Variables        VariableConfigurations   `json:"vars"`
 	Connections      ConnectionConfigurations `json:"connections"`
 	Namespace        *string                  `json:"namespace"`
+	// RenamedVariables maps a variable removed by a module upgrade to the variable that replaces it
+	RenamedVariables map[string]string `json:"renamedVars,omitempty"`
+	// Disabled removes an inherited capability when set in an overrides file
+	Disabled bool `json:"disabled"`
+	// Inherited is true when a capability in an overrides file refers to a capability in the primary config file
+	Inherited bool `json:"inherited,omitempty"`
+	// LockedVersion is the module version pinned by the lock file
+	LockedVersion string `json:"lockedVersion,omitempty"`
 
 	Module        *types.Module        `json:"module"`
 	ModuleVersion *types.ModuleVersion `json:"moduleVersion"`
@@ -141,6 +242,21 @@ func (c *CapabilityConfiguration) Identity() core.CapabilityIdentity {
 	}
 }
 
+// IsDeclaration returns true if the capability declares its module and is not inherited from the primary config file
+// In an overrides file, this adds an env-specific capability and is validated like a capability in the primary config file
+// Otherwise, the capability only updates a capability inherited from the primary config file
+func (c *CapabilityConfiguration) IsDeclaration() bool {
+	return c.ModuleSource != "" && !c.Inherited
+}
+
+// declarationContext treats a capability declared in an overrides file as if it were in the primary config file
+func (c *CapabilityConfiguration) declarationContext(ic core.IacContext) core.IacContext {
+	if c.IsDeclaration() {
+		ic.IsOverrides = false
+	}
+	return ic
+}
+
 func (c *CapabilityConfiguration) Initialize(ctx context.Context, resolver core.InitializeResolver, ic core.IacContext, pc core.ObjectPathContext, appModule *types.Module) core.InitializeErrors {
 	if c.Variables == nil {
 		c.Variables = VariableConfigurations{}
@@ -148,10 +264,11 @@ func (c *CapabilityConfiguration) Initialize(ctx context.Context, resolver core.
 	if c.Connections == nil {
 		c.Connections = ConnectionConfigurations{}
 	}
-	if ic.IsOverrides && c.ModuleSource == "" {
-		// TODO: Add support for loading module in overrides file
+	if c.Disabled || (ic.IsOverrides && c.ModuleSource == "") {
+		// TODO: Add support for loading the inherited module in overrides file
 		return nil
 	}
+	ic = c.declarationContext(ic)
 
 	errs := core.InitializeErrors{}
 
@@ -165,7 +282,7 @@ func (c *CapabilityConfiguration) Initialize(ctx context.Context, resolver core.
 	}
 
 	manifest := config.Manifest{Variables: map[string]config.Variable{}, Connections: map[string]config.Connection{}}
-	m, mv, err := core.GetModuleVersion(ctx, resolver, pc, c.ModuleSource, c.ModuleConstraint, contract)
+	m, mv, err := core.GetModuleVersion(ctx, resolver, pc, c.ModuleSource, lockedOr(c.LockedVersion, c.ModuleConstraint), contract)
 	if err != nil {
 		errs = append(errs, *err)
 	} else {
@@ -191,6 +308,14 @@ func (c *CapabilityConfiguration) Validate(ic core.IacContext, pc core.ObjectPat
 	//	err := core.MissingCapabilityNameError(pc)
 	//	errs = append(errs, *err)
 	//}
+	if c.Disabled {
+		// A disabled capability must identify the capability to remove
+		if c.Name == "" && c.ModuleSource == "" {
+			errs = append(errs, *core.MissingCapabilityNameError(pc))
+			return errs
+		}
+		return nil
+	}
 
 	if c.Module == nil {
 		// We can't perform validation if the module isn't loaded
@@ -200,6 +325,7 @@ func (c *CapabilityConfiguration) Validate(ic core.IacContext, pc core.ObjectPat
 		// TODO: Add support for validating variables and connections in an overrides file
 		return errs
 	}
+	ic = c.declarationContext(ic)
 
 	// check to make sure the capability module supports the subcategory
 	// examples are "container", "serverless", "static-site", "server"
@@ -220,7 +346,7 @@ func (c *CapabilityConfiguration) Validate(ic core.IacContext, pc core.ObjectPat
 	//   1. validate each of the variables to ensure the module supports them
 	//   2. validate each of the connections to ensure the block matches the connection contract
 	moduleName := fmt.Sprintf("%s@%s", c.ModuleSource, c.ModuleConstraint)
-	errs = append(errs, c.Variables.Validate(pc, moduleName)...)
+	errs = append(errs, c.Variables.Validate(ic, pc, moduleName, c.ModuleVersion.Manifest)...)
 	errs = append(errs, c.Connections.Validate(pc, moduleName)...)
 	if len(errs) > 0 {
 		return errs
@@ -230,30 +356,30 @@ func (c *CapabilityConfiguration) Validate(ic core.IacContext, pc core.ObjectPat
 
 func (c *CapabilityConfiguration) ApplyChangesTo(ic core.IacContext, updater core.WorkspaceConfigUpdater) error {
 	capUpdater := updater.GetCapabilityUpdater(c.Identity())
-	if capUpdater != nil {
-		// Update capability that already exists in the workspace config
-		c.doUpdateCapability(capUpdater)
-	} else {
+	if capUpdater == nil {
 		// Add capability that doesn't exist in the workspace config yet
-		c.doUpdateCapability(updater.AddCapability(c.Id, c.Name))
+		capUpdater = updater.AddCapability(c.Id, c.Name)
 	}
-	return nil
+	return c.doUpdateCapability(capUpdater)
 }
 
-func (c *CapabilityConfiguration) UpdateCapability(ic core.IacContext, updater core.WorkspaceConfigUpdater) {
-	c.doUpdateCapability(updater.GetCapabilityUpdater(c.Identity()))
+func (c *CapabilityConfiguration) UpdateCapability(ic core.IacContext, updater core.WorkspaceConfigUpdater) error {
+	return c.doUpdateCapability(updater.GetCapabilityUpdater(c.Identity()))
 }
 
-func (c *CapabilityConfiguration) doUpdateCapability(capUpdater core.CapabilityConfigUpdater) {
+func (c *CapabilityConfiguration) doUpdateCapability(capUpdater core.CapabilityConfigUpdater) error {
 	if capUpdater == nil {
-		return
+		return nil
 	}
-	capUpdater.UpdateSchema(c.ModuleSource, c.ModuleConstraint, c.ModuleVersion)
+	capUpdater.UpdateSchema(c.ModuleSource, c.ModuleConstraint, c.ModuleVersion, c.RenamedVariables)
 	capUpdater.UpdateNamespace(c.Namespace)
 	for name, vc := range c.Variables {
-		capUpdater.UpdateVariableValue(name, vc.Value)
+		if err := capUpdater.UpdateVariableValue(name, vc.Value); err != nil {
+			return err
+		}
 	}
 	for name, cc := range c.Connections {
 		capUpdater.UpdateConnectionTarget(name, cc.DesiredTarget, cc.EffectiveTarget)
 	}
+	return nil
 }
@@ -2,13 +2,11 @@ package config
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
 	"github.com/nullstone-io/iac/core"
 	"github.com/nullstone-io/module/config"
 	"gopkg.in/nullstone-io/go-api-client.v0/artifacts"
-	"gopkg.in/nullstone-io/go-api-client.v0/find"
 	"gopkg.in/nullstone-io/go-api-client.v0/types"
 )
 
@@ -104,17 +102,22 @@ func (c *ConnectionConfiguration) Resolve(ctx context.Context, resolver core.Res
 		return nil
 	}
 
-	if err := c.resolveTarget(ctx, resolver, pc); err != nil {
+	// A block declared in IaC may not have been synced yet (e.g. a new block in another stack)
+	iacModule := finder.FindBlockModuleInIac(ctx, c.EffectiveTarget)
+	if err := c.resolveTarget(ctx, resolver, iacModule != nil, pc); err != nil {
 		return err
 	}
 
-	return c.resolveModule(ctx, resolver, finder, pc)
+	return c.resolveModule(ctx, resolver, iacModule, pc)
 }
 
-func (c *ConnectionConfiguration) resolveTarget(ctx context.Context, resolver core.ResolveResolver, pc core.ObjectPathContext) *core.ResolveError {
+func (c *ConnectionConfiguration) resolveTarget(ctx context.Context, resolver core.ResolveResolver, inIac bool, pc core.ObjectPathContext) *core.ResolveError {
 	found, err := resolver.ResolveBlock(ctx, c.EffectiveTarget)
 	if err != nil {
-		if find.IsMissingResource(err) {
+		if core.IsMissingResource(err) {
+			if inIac {
+				return nil
+			}
 			return core.MissingConnectionTargetError(pc, err)
 		}
 		return core.LookupConnectionTargetFailedError(pc, err)
@@ -123,11 +126,10 @@ func (c *ConnectionConfiguration) resolveTarget(ctx context.Context, resolver co
 	return nil
 }
 
-func (c *ConnectionConfiguration) resolveModule(ctx context.Context, resolver core.ResolveResolver, finder core.IacFinder, pc core.ObjectPathContext) *core.ResolveError {
-	// First, attempt to find the module in the current IaC configuration (this avoids extra API calls since we might have it already)
-	module := finder.FindBlockModuleInIac(ctx, c.EffectiveTarget)
-	if module != nil {
-		c.Module = module
+func (c *ConnectionConfiguration) resolveModule(ctx context.Context, resolver core.ResolveResolver, iacModule *types.Module, pc core.ObjectPathContext) *core.ResolveError {
+	// First, use the module found in the IaC configuration (this avoids extra API calls since we might have it already)
+	if iacModule != nil {
+		c.Module = iacModule
 		return nil
 	}
 
@@ -178,7 +180,7 @@ func (c *ConnectionConfiguration) Validate(pc core.ObjectPathContext, moduleName
 		Subplatform: c.Module.Subplatform,
 	}
 	if ok := mcn1.Match(mcn2); !ok {
-		return core.MismatchedConnectionContractError(pc, c.Block.Name, c.Schema.Contract)
+		return core.MismatchedConnectionContractError(pc, c.EffectiveTarget.BlockName, c.Schema.Contract)
 	}
 
 	return nil
@@ -191,10 +193,7 @@ func (c *ConnectionConfiguration) Normalize(ctx context.Context, pc core.ObjectP
 	}
 	ct, err := resolver.ResolveConnection(ctx, c.DesiredTarget)
 	if err != nil {
-		return &core.NormalizeError{
-			ObjectPathContext: pc,
-			ErrorMessage:      fmt.Sprintf("Connection is invalid, %s", err),
-		}
+		return core.InvalidConnectionError(pc, err)
 	}
 	c.DesiredTarget.StackId = ct.StackId
 	c.DesiredTarget.StackName = ct.StackName
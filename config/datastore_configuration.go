@@ -1,6 +1,7 @@
 package config
 
 import (
+	"github.com/nullstone-io/iac/core"
 	"github.com/nullstone-io/iac/yaml"
 	"gopkg.in/nullstone-io/go-api-client.v0/types"
 )
@@ -17,3 +18,11 @@ func convertDatastoreConfigurations(parsed map[string]yaml.DatastoreConfiguratio
 	}
 	return result
 }
+
+func (d *DatastoreConfiguration) Warnings(ic core.IacContext, pc core.ObjectPathContext, sm core.SourceMap) core.Diagnostics {
+	result := d.BlockConfiguration.Warnings(ic, pc, sm)
+	if !ic.IsOverrides && d.Metadata.DataClassification == "" {
+		result = append(result, core.MissingDataClassificationWarning(pc, classificationLevels()))
+	}
+	return result
+}
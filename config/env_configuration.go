@@ -2,6 +2,9 @@ package config
 
 import (
 	"context"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/nullstone-io/iac/core"
 	"github.com/nullstone-io/iac/yaml"
@@ -11,6 +14,10 @@ import (
 type EnvConfiguration struct {
 	IacContext core.IacContext `json:"iacContext"`
 
+	// SourceMap contains the position of each object in the IaC file
+	// This is used to attach line/column information to errors
+	SourceMap core.SourceMap `json:"sourceMap,omitempty"`
+
 	Events EventConfigurations `json:"events"`
 
 	Applications      map[string]*AppConfiguration              `json:"applications"`
@@ -44,6 +51,13 @@ func ConvertConfiguration(repoUrl, repoName, filename string, isOverrides bool,
 	result.Ingresses = convertIngressConfigurations(parsed.Ingresses)
 	result.Networks = convertNetworkConfigurations(parsed.Networks)
 	result.Subdomains = convertSubdomainConfigurations(parsed.Subdomains)
+	for _, app := range result.Applications {
+		if isOverrides {
+			app.Capabilities.inherit(nil)
+		} else {
+			app.Capabilities.assignNames()
+		}
+	}
 	return result
 }
 
@@ -195,6 +209,57 @@ func (e *EnvConfiguration) Validate() core.ValidateErrors {
 	return nil
 }
 
+// Warnings reports configuration that is valid, but deprecated or risky
+// Unlike Validate, these do not prevent the configuration from being applied
+func (e *EnvConfiguration) Warnings() core.Diagnostics {
+	result := core.Diagnostics{}
+
+	for _, app := range e.Applications {
+		pc := core.NewObjectPathContextKey("apps", app.Name)
+		result = append(result, app.Warnings(e.IacContext, pc, e.SourceMap)...)
+	}
+	for _, block := range e.Blocks {
+		pc := core.NewObjectPathContextKey("blocks", block.Name)
+		result = append(result, block.Warnings(e.IacContext, pc, e.SourceMap)...)
+	}
+	for _, cluster := range e.Clusters {
+		pc := core.NewObjectPathContextKey("clusters", cluster.Name)
+		result = append(result, cluster.Warnings(e.IacContext, pc, e.SourceMap)...)
+	}
+	for _, clusterNamespace := range e.ClusterNamespaces {
+		pc := core.NewObjectPathContextKey("cluster_namespaces", clusterNamespace.Name)
+		result = append(result, clusterNamespace.Warnings(e.IacContext, pc, e.SourceMap)...)
+	}
+	for _, ds := range e.Datastores {
+		pc := core.NewObjectPathContextKey("datastores", ds.Name)
+		result = append(result, ds.Warnings(e.IacContext, pc, e.SourceMap)...)
+	}
+	for _, domain := range e.Domains {
+		pc := core.NewObjectPathContextKey("domains", domain.Name)
+		result = append(result, domain.Warnings(e.IacContext, pc, e.SourceMap)...)
+	}
+	for _, ingress := range e.Ingresses {
+		pc := core.NewObjectPathContextKey("ingresses", ingress.Name)
+		result = append(result, ingress.Warnings(e.IacContext, pc, e.SourceMap)...)
+	}
+	for _, network := range e.Networks {
+		pc := core.NewObjectPathContextKey("networks", network.Name)
+		result = append(result, network.Warnings(e.IacContext, pc, e.SourceMap)...)
+	}
+	for _, sub := range e.Subdomains {
+		pc := core.NewObjectPathContextKey("subdomains", sub.Name)
+		result = append(result, sub.Warnings(e.IacContext, pc, e.SourceMap)...)
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(result, func(a, b core.Diagnostic) int {
+		return strings.Compare(a.ObjectPathContext.Context(), b.ObjectPathContext.Context())
+	})
+	return result
+}
+
 func (e *EnvConfiguration) Normalize(ctx context.Context, resolver core.NormalizeResolver) core.NormalizeErrors {
 	errs := core.NormalizeErrors{}
 
@@ -312,6 +377,101 @@ func (e *EnvConfiguration) ApplyChangesTo(block types.Block, updater core.Worksp
 	return ca.ApplyChangesTo(e.IacContext, updater)
 }
 
+// DeclaredCapabilities returns the identities of the capabilities that the IaC file declares for the app named blockName
+// For an overrides file, this excludes capabilities inherited from the primary config file
+func (e *EnvConfiguration) DeclaredCapabilities(blockName string) core.CapabilityIdentities {
+	result := core.CapabilityIdentities{}
+	app, ok := e.Applications[blockName]
+	if !ok {
+		return result
+	}
+	for _, cur := range app.Capabilities {
+		if !cur.Disabled && cur.IsDeclaration() {
+			result = append(result, cur.Identity())
+		}
+	}
+	return result
+}
+
+// InheritModules sets the module for each block that omits the module using the matching block in base
+// This allows an overrides file to be validated against the module declared in the primary config file
+// Capabilities that refer to a capability of the matching app in base are marked as inherited
+func (e *EnvConfiguration) InheritModules(base *EnvConfiguration) {
+	if base == nil {
+		return
+	}
+	for name, app := range e.Applications {
+		if found, ok := base.Applications[name]; ok {
+			app.Capabilities.inherit(found.Capabilities)
+		}
+	}
+	for _, entry := range e.blockConfigurations() {
+		if found := base.FindBlockConfigurationByName(entry.block.Name); found != nil {
+			entry.block.InheritModule(found.ModuleSource, found.ModuleConstraint)
+			if entry.block.ModuleInherited {
+				entry.block.LockedVersion = found.LockedVersion
+			}
+		}
+	}
+}
+
+type blockEntry struct {
+	pc    core.ObjectPathContext
+	block *BlockConfiguration
+}
+
+func (e *EnvConfiguration) blockConfigurations() []blockEntry {
+	result := make([]blockEntry, 0)
+	for _, cur := range e.Applications {
+		result = append(result, blockEntry{pc: core.NewObjectPathContextKey("apps", cur.Name), block: &cur.BlockConfiguration})
+	}
+	for _, cur := range e.Blocks {
+		result = append(result, blockEntry{pc: core.NewObjectPathContextKey("blocks", cur.Name), block: cur})
+	}
+	for _, cur := range e.Clusters {
+		result = append(result, blockEntry{pc: core.NewObjectPathContextKey("clusters", cur.Name), block: &cur.BlockConfiguration})
+	}
+	for _, cur := range e.ClusterNamespaces {
+		result = append(result, blockEntry{pc: core.NewObjectPathContextKey("cluster_namespaces", cur.Name), block: &cur.BlockConfiguration})
+	}
+	for _, cur := range e.Datastores {
+		result = append(result, blockEntry{pc: core.NewObjectPathContextKey("datastores", cur.Name), block: &cur.BlockConfiguration})
+	}
+	for _, cur := range e.Domains {
+		result = append(result, blockEntry{pc: core.NewObjectPathContextKey("domains", cur.Name), block: &cur.BlockConfiguration})
+	}
+	for _, cur := range e.Ingresses {
+		result = append(result, blockEntry{pc: core.NewObjectPathContextKey("ingresses", cur.Name), block: &cur.BlockConfiguration})
+	}
+	for _, cur := range e.Networks {
+		result = append(result, blockEntry{pc: core.NewObjectPathContextKey("networks", cur.Name), block: &cur.BlockConfiguration})
+	}
+	for _, cur := range e.Subdomains {
+		result = append(result, blockEntry{pc: core.NewObjectPathContextKey("subdomains", cur.Name), block: &cur.BlockConfiguration})
+	}
+	return result
+}
+
+// ConnectionVisitor is called with the name of the block that holds the connection
+type ConnectionVisitor func(blockName string, pc core.ObjectPathContext, connection *ConnectionConfiguration)
+
+// VisitConnections calls visit for each connection of each block, including connections of app capabilities
+func (e *EnvConfiguration) VisitConnections(visit ConnectionVisitor) {
+	for _, entry := range e.blockConfigurations() {
+		for name, conn := range entry.block.Connections {
+			visit(entry.block.Name, entry.pc.SubKey("connections", name), conn)
+		}
+	}
+	for _, app := range e.Applications {
+		pc := core.NewObjectPathContextKey("apps", app.Name)
+		for i, capability := range app.Capabilities {
+			for name, conn := range capability.Connections {
+				visit(app.Name, pc.SubIndex("capabilities", i).SubKey("connections", name), conn)
+			}
+		}
+	}
+}
+
 func (e *EnvConfiguration) BlockNames() map[string]bool {
 	names := map[string]bool{}
 	for _, cur := range e.Applications {
@@ -344,6 +504,27 @@ func (e *EnvConfiguration) BlockNames() map[string]bool {
 	return names
 }
 
+// ConnectionTargets lists the desired target of every connection in blocks and capabilities
+func (e *EnvConfiguration) ConnectionTargets() []types.ConnectionTarget {
+	result := make([]types.ConnectionTarget, 0)
+	add := func(connections ConnectionConfigurations) {
+		for _, name := range slices.Sorted(maps.Keys(connections)) {
+			if ct := connections[name].DesiredTarget; !ct.IsEmpty() {
+				result = append(result, ct)
+			}
+		}
+	}
+	for _, entry := range e.blockConfigurations() {
+		add(entry.block.Connections)
+	}
+	for _, app := range e.Applications {
+		for _, c := range app.Capabilities {
+			add(c.Connections)
+		}
+	}
+	return result
+}
+
 func (e *EnvConfiguration) FindBlockConfigurationByName(name string) *BlockConfiguration {
 	ptr := func(cur BlockConfiguration) *BlockConfiguration {
 		return &cur
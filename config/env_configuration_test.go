@@ -18,8 +18,10 @@ import (
 	config2 "github.com/nullstone-io/iac/yaml"
 	"github.com/nullstone-io/module/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/nullstone-io/go-api-client.v0/find"
 	"gopkg.in/nullstone-io/go-api-client.v0/types"
+	yaml3 "gopkg.in/yaml.v3"
 )
 
 type FactoryDefaults struct {
@@ -444,9 +446,9 @@ func TestConvertConfiguration(t *testing.T) {
 							Module:        ptr(comparable(fargateServiceModule)),
 							ModuleVersion: comparable(fargateServiceModule.LatestVersion),
 						},
-						EnvVariables: map[string]string{
-							"TESTING": "abc123",
-							"BLAH":    "blahblahblah",
+						EnvVariables: EnvVariableConfigurations{
+							"TESTING": {Value: "abc123"},
+							"BLAH":    {Value: "blahblahblah"},
 						},
 						Capabilities: CapabilityConfigurations{
 							{
@@ -503,6 +505,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs"},
 					ErrorMessage:      "Module is required",
+					Code:              core.CodeRequiredModule,
 				},
 			},
 			resolveErrors:    core.ResolveErrors(nil),
@@ -516,6 +519,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs", Field: "module"},
 					ErrorMessage:      "Module (nullstone/aws-invalid-module) does not exist",
+					Code:              core.CodeMissingModule,
 				},
 			},
 			resolveErrors:    core.ResolveErrors(nil),
@@ -529,6 +533,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs", Field: "module"},
 					ErrorMessage:      "Module (nullstone/aws-s3-cdn) must be app module and match the contract (app/*/*), it is defined as capability:ingress/aws/",
+					Code:              core.CodeInvalidModuleContract,
 				},
 			},
 			resolveErrors:    core.ResolveErrors(nil),
@@ -544,6 +549,7 @@ func TestConvertConfiguration(t *testing.T) {
 				core.ValidateError{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs", Field: "vars", Key: "service_count"},
 					ErrorMessage:      "Variable does not exist on the module (nullstone/aws-fargate-service@0.0.1)",
+					Code:              core.CodeVariableDoesNotExist,
 				},
 			},
 		},
@@ -555,6 +561,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs", Field: "capabilities", Index: ptr(0)},
 					ErrorMessage:      "Module is required",
+					Code:              core.CodeRequiredModule,
 				},
 			},
 			resolveErrors:    core.ResolveErrors(nil),
@@ -568,6 +575,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs.capabilities[0]", Field: "module"},
 					ErrorMessage:      "Module (nullstone/aws-invalid-module) does not exist",
+					Code:              core.CodeMissingModule,
 				},
 			},
 			resolveErrors:    core.ResolveErrors(nil),
@@ -581,6 +589,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs.capabilities[0]", Field: "module"},
 					ErrorMessage:      "Module (nullstone/aws-s3-site) must be capability module and match the contract (capability/aws/*), it is defined as app:static-site/aws/s3",
+					Code:              core.CodeInvalidModuleContract,
 				},
 			},
 			resolveErrors:    core.ResolveErrors(nil),
@@ -596,6 +605,7 @@ func TestConvertConfiguration(t *testing.T) {
 				core.ValidateError{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs.capabilities[0]", Field: "module"},
 					ErrorMessage:      "Module (nullstone/aws-postgres-access) does not support application category (static-site)",
+					Code:              core.CodeUnsupportedAppCategory,
 				},
 			},
 		},
@@ -609,6 +619,7 @@ func TestConvertConfiguration(t *testing.T) {
 				core.ValidateError{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs.capabilities[0]", Field: "vars", Key: "database_name"},
 					ErrorMessage:      "Variable does not exist on the module (nullstone/aws-load-balancer@latest)",
+					Code:              core.CodeVariableDoesNotExist,
 				},
 			},
 		},
@@ -621,6 +632,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs.capabilities[0]", Field: "connections", Key: "subdomain"},
 					ErrorMessage:      "Connection is invalid, block core/ns-sub-for-blah does not exist",
+					Code:              core.CodeInvalidConnection,
 				},
 			},
 			resolveErrors:    core.ResolveErrors(nil),
@@ -634,6 +646,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs", Field: "connections"},
 					ErrorMessage:      "Connection (cluster-namespace) is required",
+					Code:              core.CodeMissingRequiredConnection,
 				},
 			},
 			resolveErrors:    core.ResolveErrors(nil),
@@ -648,6 +661,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "subdomains.api-subdomain.dns", Field: "template"},
 					ErrorMessage:      "Invalid subdomain template \"{{ random() }}.xyz\": cannot have specify additional text when using `{{ random() }}`.",
+					Code:              core.CodeInvalidRandomSubdomainTemplate,
 				},
 			},
 			validationErrors: core.ValidateErrors(nil),
@@ -678,6 +692,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "subdomains.api-subdomain"},
 					ErrorMessage:      "Failed to reserve subdomain \"random()\": [Bad Request]\n  - reached random subdomain limit for organization",
+					Code:              core.CodeFailedSubdomainReservation,
 				},
 			},
 			validationErrors: core.ValidateErrors(nil),
@@ -708,6 +723,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "subdomains.api-subdomain"},
 					ErrorMessage:      "Failed to reserve subdomain \"awesome-app\": [Bad Request]\n  - requested subdomain is already in use by another subdomain",
+					Code:              core.CodeFailedSubdomainReservation,
 				},
 			},
 			validationErrors: core.ValidateErrors(nil),
@@ -790,6 +806,7 @@ func TestConvertConfiguration(t *testing.T) {
 				core.ValidateError{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs.capabilities[0]", Field: "connections", Key: "subdomain"},
 					ErrorMessage:      "Block (postgres) does not match the required contract (subdomain/aws/route53) for the capability connection",
+					Code:              core.CodeMismatchedConnectionContract,
 				},
 			},
 		},
@@ -803,6 +820,7 @@ func TestConvertConfiguration(t *testing.T) {
 				core.ValidateError{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs.capabilities[0]", Field: "connections", Key: "subdomain"},
 					ErrorMessage:      "Connection must have a block_name to identify which block it is connected to",
+					Code:              core.CodeMissingConnectionBlock,
 				},
 			},
 		},
@@ -816,6 +834,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "events.deployments", Field: "targets", Key: "webhook"},
 					ErrorMessage:      "When specifying `webhook`, `url` is required",
+					Code:              core.CodeInvalidWebhookUrl,
 				},
 			},
 		},
@@ -829,6 +848,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "events.deployments.targets.webhook", Field: "url"},
 					ErrorMessage:      "Invalid webhook URL",
+					Code:              core.CodeInvalidWebhookUrl,
 				},
 			},
 		},
@@ -842,6 +862,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs", Field: "vars", Key: "num_tasks"},
 					ErrorMessage:      "Specified variable value (string) is incompatible with expected variable type (number)",
+					Code:              core.CodeVariableIncompatibleType,
 				},
 			},
 		},
@@ -855,6 +876,7 @@ func TestConvertConfiguration(t *testing.T) {
 				{
 					ObjectPathContext: core.ObjectPathContext{Path: "apps.acme-docs.capabilities[0]", Field: "vars", Key: "enable_https"},
 					ErrorMessage:      "Specified variable value (string) is incompatible with expected variable type (bool)",
+					Code:              core.CodeVariableIncompatibleType,
 				},
 			},
 		},
@@ -898,9 +920,9 @@ func TestConvertConfiguration(t *testing.T) {
 							},
 							Connections: ConnectionConfigurations{},
 						},
-						EnvVariables: map[string]string{
-							"TESTING": "abc123",
-							"BLAH":    "blahblahblah",
+						EnvVariables: EnvVariableConfigurations{
+							"TESTING": {Value: "abc123"},
+							"BLAH":    {Value: "blahblahblah"},
 						},
 						Capabilities: CapabilityConfigurations{
 							{
@@ -1022,3 +1044,100 @@ func TestConvertConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvConfiguration_Warnings(t *testing.T) {
+	raw := `version: "0.2"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+    module_version: "0.1.0"
+    capabilities:
+      - module: nullstone/aws-s3-cdn
+        module_version: "0.2.0"
+      - name: logs
+        module: nullstone/aws-cloudwatch-logs
+datastores:
+  db:
+    module: nullstone/aws-rds-postgres
+    module_version: "0.3.0"
+  cache:
+    module: nullstone/aws-redis
+    module_version: "0.3.0"
+    metadata:
+      dataclassification: operational
+subdomains:
+  api-subdomain:
+    module: nullstone/aws-subdomain
+    module_version: "0.4.0"
+    dns_name: api
+`
+	var node yaml3.Node
+	assert.NoError(t, yaml3.Unmarshal([]byte(raw), &node))
+	var parsed config2.EnvConfiguration
+	assert.NoError(t, node.Decode(&parsed))
+	ec := ConvertConfiguration("", "", ".nullstone/config.yml", false, parsed)
+	ec.SourceMap = config2.BuildSourceMap(&node)
+
+	apiPc := core.NewObjectPathContextKey("apps", "api")
+	want := core.Diagnostics{
+		core.UnnamedCapabilityWarning(apiPc.SubIndex("capabilities", 0), "aws-s3-cdn"),
+		core.DefaultModuleVersionWarning(apiPc.SubIndex("capabilities", 1).SubField("module"), "nullstone/aws-cloudwatch-logs", ""),
+		core.MissingDataClassificationWarning(core.NewObjectPathContextKey("datastores", "db"), classificationLevels()),
+		core.DeprecatedDnsNameWarning(core.NewObjectPathContextKey("subdomains", "api-subdomain").SubField("dns_name"), "api.{{ NULLSTONE_ENV }}"),
+	}
+	got := ec.Warnings()
+	assert.Equal(t, want, got)
+	for _, w := range got {
+		assert.Equal(t, core.SeverityWarning, w.Severity)
+	}
+	assert.False(t, got.HasErrors())
+}
+
+func TestEnvConfiguration_InheritModules_capabilities(t *testing.T) {
+	convert := func(filename, raw string, isOverrides bool) *EnvConfiguration {
+		parsed, err := config2.ParseEnvConfiguration([]byte(raw))
+		require.NoError(t, err)
+		return ConvertConfiguration("", "", filename, isOverrides, *parsed)
+	}
+	base := convert(".nullstone/config.yml", `version: "0.1"
+apps:
+  acme-api:
+    module: nullstone/aws-fargate-service
+    capabilities:
+      - module: nullstone/aws-s3-cdn
+        connections:
+          subdomain: ns-sub-for-acme-docs
+      - name: logs
+        module: nullstone/aws-cloudwatch-logs
+`, false)
+	// The first capability has the same shape as test-fixtures/previews.yml
+	overrides := convert(".nullstone/previews.yml", `version: "0.1"
+apps:
+  acme-api:
+    capabilities:
+      - module: nullstone/aws-s3-cdn
+        connections:
+          subdomain: ns-sub-for-acme-docs
+        namespace: secondary
+        vars:
+          enable_www: true
+      - name: logs
+        module: nullstone/aws-cloudwatch-logs
+      - name: aws-s3-cdn
+        module: nullstone/aws-s3-cdn
+      - name: debug-sidecar
+        module: nullstone/debug-sidecar
+`, true)
+	caps := overrides.Applications["acme-api"].Capabilities
+
+	assert.False(t, caps[0].IsDeclaration(), "a capability without a name is never declared by an overrides file")
+	assert.Equal(t, "", caps[0].Name, "an overrides file does not generate names")
+
+	overrides.InheritModules(base)
+	got := make([]bool, len(caps))
+	for i, cur := range caps {
+		got[i] = cur.IsDeclaration()
+	}
+	assert.Equal(t, []bool{false, false, false, true}, got)
+	assert.Empty(t, overrides.Warnings(), "inherited capabilities are not reported as unnamed")
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"context"
+	"strings"
+
+	"github.com/nullstone-io/iac/core"
+	"github.com/nullstone-io/iac/yaml"
+)
+
+type EnvVariableConfigurations map[string]*EnvVariableConfiguration
+
+func convertEnvVariables(parsed yaml.EnvVariableConfigurations) EnvVariableConfigurations {
+	result := EnvVariableConfigurations{}
+	for name, value := range parsed {
+		result[name] = &EnvVariableConfiguration{
+			Value:     value.Value,
+			Sensitive: value.Sensitive,
+			Secret:    value.Secret,
+		}
+	}
+	return result
+}
+
+// Values returns the value to store for each env variable
+func (s EnvVariableConfigurations) Values() map[string]string {
+	result := map[string]string{}
+	for name, c := range s {
+		result[name] = c.EffectiveValue()
+	}
+	return result
+}
+
+func (s EnvVariableConfigurations) Resolve(ctx context.Context, resolver core.SecretReferenceResolver, pc core.ObjectPathContext) core.ResolveErrors {
+	errs := core.ResolveErrors{}
+	for name, c := range s {
+		if err := c.Resolve(ctx, resolver, pc.SubKey("environment", name)); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (s EnvVariableConfigurations) Validate(pc core.ObjectPathContext) core.ValidateErrors {
+	if len(s) == 0 {
+		return nil
+	}
+
+	errs := core.ValidateErrors{}
+	for k, c := range s {
+		curpc := pc.SubKey("environment", k)
+		if startsWithNumber(k) {
+			errs = append(errs, core.EnvVariableKeyStartsWithNumberError(curpc))
+		}
+		if strings.IndexFunc(k, hasInvalidChars) != -1 {
+			errs = append(errs, core.EnvVariableKeyInvalidCharsError(curpc))
+		}
+		errs = append(errs, c.Validate(curpc)...)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// EnvVariableConfiguration is an app env variable
+// Errors must never include Value since it may be sensitive
+type EnvVariableConfiguration struct {
+	Value     string `json:"value"`
+	Sensitive bool   `json:"sensitive"`
+	// Secret is a reference to a secret stored outside the repository (e.g. aws-sm://prod/db#password)
+	Secret string `json:"secret,omitempty"`
+
+	// SecretValue is populated via Resolve()
+	SecretValue string `json:"secretValue,omitempty"`
+}
+
+// IsSensitive returns true if the value should be stored as a sensitive env variable
+func (c *EnvVariableConfiguration) IsSensitive() bool {
+	return c.Sensitive || c.Secret != ""
+}
+
+// EffectiveValue returns the resolved secret for a secret reference, otherwise the literal value
+func (c *EnvVariableConfiguration) EffectiveValue() string {
+	if c.Secret != "" {
+		return c.SecretValue
+	}
+	return c.Value
+}
+
+func (c *EnvVariableConfiguration) Resolve(ctx context.Context, resolver core.SecretReferenceResolver, pc core.ObjectPathContext) *core.ResolveError {
+	if c.Secret == "" {
+		return nil
+	}
+	ref, err := core.ParseSecretReference(c.Secret)
+	if err != nil {
+		// Invalid references are reported during Validate
+		return nil
+	}
+	value, err := resolver.ResolveSecretReference(ctx, ref)
+	if err != nil {
+		rerr := core.SecretReferenceResolveFailedError(pc, ref, err)
+		return &rerr
+	}
+	c.SecretValue = value
+	return nil
+}
+
+func (c *EnvVariableConfiguration) Validate(pc core.ObjectPathContext) core.ValidateErrors {
+	if c.Secret == "" {
+		if c.Sensitive {
+			// Sensitive values are not rendered as templates
+			return nil
+		}
+		if err := core.ValidateTemplate(c.Value); err != nil {
+			return core.ValidateErrors{core.InvalidTemplateError(pc, err)}
+		}
+		return nil
+	}
+	if c.Value != "" {
+		return core.ValidateErrors{core.EnvVariableValueAndSecretError(pc)}
+	}
+	if _, err := core.ParseSecretReference(c.Secret); err != nil {
+		return core.ValidateErrors{core.InvalidSecretReferenceError(pc.SubField("secret"), err)}
+	}
+	return nil
+}
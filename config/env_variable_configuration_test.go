@@ -0,0 +1,152 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nullstone-io/iac/core"
+	"github.com/nullstone-io/iac/workspace"
+	"github.com/nullstone-io/iac/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// vaultDeniedResolver fails to resolve any vault:// reference
+type vaultDeniedResolver struct{}
+
+func (vaultDeniedResolver) ResolveSecretReference(ctx context.Context, ref core.SecretReference) (string, error) {
+	if ref.Scheme == "vault" {
+		return "", errors.New("access denied")
+	}
+	return ref.String(), nil
+}
+
+func TestEnvVariableConfigurations(t *testing.T) {
+	raw := `
+PORT: 8080
+DB_PASSWORD: { secret: "aws-sm://prod/db#password" }
+API_TOKEN: { value: "s3cr3t-token", sensitive: true }
+`
+	var parsed yaml.EnvVariableConfigurations
+	require.NoError(t, yaml3.Unmarshal([]byte(raw), &parsed))
+	envVars := convertEnvVariables(parsed)
+	pc := core.NewObjectPathContextKey("apps", "api")
+
+	require.Nil(t, envVars.Resolve(context.Background(), core.LiteralSecretReferenceResolver{}, pc))
+	require.Nil(t, envVars.Validate(pc))
+
+	wc := &types.WorkspaceConfig{}
+	app := &AppConfiguration{EnvVariables: envVars}
+	require.NoError(t, app.ApplyChangesTo(core.IacContext{}, workspace.ConfigUpdater{Config: wc}))
+	assert.Equal(t, types.EnvVariables{
+		"PORT":        {Value: "8080"},
+		"DB_PASSWORD": {Value: "aws-sm://prod/db#password", Sensitive: true},
+		"API_TOKEN":   {Value: "s3cr3t-token", Sensitive: true},
+	}, wc.EnvVariables)
+
+	t.Run("marshals back to the short form when not sensitive", func(t *testing.T) {
+		out, err := yaml3.Marshal(parsed)
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `PORT: "8080"`)
+		assert.Contains(t, string(out), "secret: aws-sm://prod/db#password")
+	})
+}
+
+func TestEnvVariableConfigurations_errors(t *testing.T) {
+	pc := core.NewObjectPathContextKey("apps", "api")
+	envVars := EnvVariableConfigurations{
+		"BAD_REF": {Secret: "prod/db"},
+		"BOTH":    {Value: "s3cr3t-token", Secret: "aws-sm://prod/db"},
+		"FAILS":   {Secret: "vault://kv/app#token"},
+	}
+
+	rerrs := envVars.Resolve(context.Background(), vaultDeniedResolver{}, pc)
+	assert.Equal(t, core.ResolveErrors{
+		{
+			ObjectPathContext: core.ObjectPathContext{Path: "apps.api", Field: "environment", Key: "FAILS"},
+			ErrorMessage:      "Unable to resolve secret reference (vault://kv/app#token): access denied",
+			Code:              core.CodeSecretReferenceResolveFailed,
+		},
+	}, rerrs)
+
+	verrs := envVars.Validate(pc)
+	assert.ElementsMatch(t, core.ValidateErrors{
+		{
+			ObjectPathContext: core.ObjectPathContext{Path: "apps.api.environment.BAD_REF", Field: "secret"},
+			ErrorMessage:      "Invalid secret reference: secret reference must be in the form <scheme>://<path>[#<key>]",
+			Code:              core.CodeInvalidSecretReference,
+		},
+		{
+			ObjectPathContext: core.ObjectPathContext{Path: "apps.api", Field: "environment", Key: "BOTH"},
+			ErrorMessage:      "Invalid environment variable, specify either value or secret, not both",
+			Code:              core.CodeEnvVariableValueAndSecret,
+		},
+	}, verrs)
+	for _, err := range verrs {
+		assert.NotContains(t, err.Error(), "s3cr3t-token")
+	}
+}
+
+func TestEnvVariableConfigurations_templates(t *testing.T) {
+	pc := core.NewObjectPathContextKey("apps", "api")
+	envVars := EnvVariableConfigurations{
+		"LOG_LEVEL":  {Value: "{{ if NULLSTONE_ENV_IS_PROD }}warn{{ else }}debug{{ end }}"},
+		"QUEUE_NAME": {Value: `{{ NULLSTONE_BLOCK }}-{{ NULLSTONE_ENV | replace "-" "_" | lower }}`},
+		"BRANCH":     {Value: `{{ default "main" NULLSTONE_ENV_TYPE }}`},
+		"API_TOKEN":  {Value: "{{ not-a-template", Sensitive: true},
+	}
+	require.Nil(t, envVars.Validate(pc))
+
+	apply := func(vars workspace.TemplateVars) types.EnvVariables {
+		wc := &types.WorkspaceConfig{}
+		app := &AppConfiguration{EnvVariables: envVars}
+		require.NoError(t, app.ApplyChangesTo(core.IacContext{}, workspace.ConfigUpdater{Config: wc, TemplateVars: vars}))
+		return wc.EnvVariables
+	}
+
+	got := apply(workspace.TemplateVars{EnvName: "Pr-12", BlockName: "api"})
+	assert.Equal(t, "debug", got["LOG_LEVEL"].Value)
+	assert.Equal(t, "api-pr_12", got["QUEUE_NAME"].Value)
+	assert.Equal(t, "main", got["BRANCH"].Value)
+	assert.Equal(t, "{{ not-a-template", got["API_TOKEN"].Value)
+
+	got = apply(workspace.TemplateVars{EnvName: "prod", EnvIsProd: true, EnvType: "PipelineEnv"})
+	assert.Equal(t, "warn", got["LOG_LEVEL"].Value)
+	assert.Equal(t, "PipelineEnv", got["BRANCH"].Value)
+
+	t.Run("unknown variables fail validation", func(t *testing.T) {
+		bad := EnvVariableConfigurations{"HOST": {Value: "{{ NULLSTONE_REGION }}.example.com"}}
+		assert.Equal(t, core.ValidateErrors{
+			{
+				ObjectPathContext: core.ObjectPathContext{Path: "apps.api", Field: "environment", Key: "HOST"},
+				ErrorMessage:      `Invalid template: unknown template variable or function "NULLSTONE_REGION"`,
+				Code:              core.CodeInvalidTemplate,
+			},
+		}, bad.Validate(pc))
+	})
+
+	t.Run("other templates are not rendered", func(t *testing.T) {
+		other := EnvVariableConfigurations{
+			"HELM":     {Value: "{{ .Values.image.tag }}"},
+			"JINJA":    {Value: "{{ user | upper }}"},
+			"MUSTACHE": {Value: "{{#items}}{{name}}{{/items}}"},
+		}
+		assert.Nil(t, other.Validate(pc))
+	})
+
+	t.Run("only template variables and functions are supported", func(t *testing.T) {
+		tests := map[string]string{
+			`{{ printf "%s" NULLSTONE_ENV }}`:          `unknown template variable or function "printf"`,
+			`{{ index NULLSTONE_ENV 0 }}`:              `unknown template variable or function "index"`,
+			`{{ NULLSTONE_ENV }}-{{ .Values.region }}`: `unsupported template syntax ".Values.region"`,
+			`{{ $env := NULLSTONE_ENV }}{{ $env }}`:    `unsupported template syntax "$env := NULLSTONE_ENV"`,
+		}
+		for value, want := range tests {
+			_, err := core.TemplateContext{}.Render(value)
+			assert.EqualError(t, err, want, value)
+		}
+	})
+}
@@ -2,7 +2,6 @@ package config
 
 import (
 	"context"
-	"fmt"
 	"slices"
 
 	"github.com/nullstone-io/iac/core"
@@ -93,10 +92,7 @@ func (c *EventConfiguration) Normalize(ctx context.Context, pc core.ObjectPathCo
 	for _, blockName := range c.BlockNames {
 		block, err := resolver.ResolveBlock(ctx, types.ConnectionTarget{BlockName: blockName})
 		if err != nil {
-			errs = append(errs, core.NormalizeError{
-				ObjectPathContext: pc.SubKey("blocks", blockName),
-				ErrorMessage:      fmt.Sprintf("Block must exist to subscribe to event, but it failed to resolve: %s", err),
-			})
+			errs = append(errs, core.EventBlockNotFoundError(pc.SubKey("blocks", blockName), err))
 		} else {
 			c.Blocks = append(c.Blocks, block)
 		}
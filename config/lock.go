@@ -0,0 +1,70 @@
+package config
+
+import (
+	"github.com/nullstone-io/iac/core"
+	"github.com/nullstone-io/iac/lockfile"
+)
+
+// ApplyLock pins the module version of each block and capability to the version in the lock file
+// This must be called before Initialize
+// If the module or module_version changed since the lock file was generated, an error is reported since the lock file is out of date
+func (e *EnvConfiguration) ApplyLock(locked lockfile.LockedBlocks) core.InitializeErrors {
+	errs := core.InitializeErrors{}
+	for _, entry := range e.moduleEntries() {
+		// Clear the version pinned by a previous lock file so that the same input can be initialized again
+		*entry.lockedVersion = ""
+		lm := locked.Find(entry.blockName, entry.capabilityName)
+		if lm == nil {
+			// The block was added after the lock file was generated
+			continue
+		}
+		if lm.Module != entry.moduleSource || lm.ModuleVersion != entry.moduleConstraint {
+			errs = append(errs, *core.ModuleLockMismatchError(entry.pc, entry.moduleSource, entry.moduleConstraint, lm.Module, lm.ModuleVersion))
+			continue
+		}
+		*entry.lockedVersion = lm.Resolved
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// VerifyLock reports module versions whose manifest differs from the digest in the lock file
+// This must be called after Initialize
+func (e *EnvConfiguration) VerifyLock(locked lockfile.LockedBlocks) core.InitializeErrors {
+	errs := core.InitializeErrors{}
+	for _, entry := range e.moduleEntries() {
+		lm := locked.Find(entry.blockName, entry.capabilityName)
+		mv := *entry.moduleVersion
+		if lm == nil || lm.Digest == "" || mv == nil || *entry.lockedVersion == "" {
+			continue
+		}
+		if lockfile.ManifestDigest(mv.Manifest) != lm.Digest {
+			errs = append(errs, *core.ModuleDigestMismatchError(entry.pc, entry.moduleSource, mv.Version))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// LockedBlocks records the module version resolved for each block and capability
+// This must be called after Initialize; modules that failed to resolve are omitted
+func (e *EnvConfiguration) LockedBlocks() lockfile.LockedBlocks {
+	result := lockfile.LockedBlocks{}
+	for _, entry := range e.moduleEntries() {
+		mv := *entry.moduleVersion
+		if mv == nil {
+			continue
+		}
+		result.Set(entry.blockName, entry.capabilityName, lockfile.LockedModule{
+			Module:        entry.moduleSource,
+			ModuleVersion: entry.moduleConstraint,
+			Resolved:      mv.Version,
+			Digest:        lockfile.ManifestDigest(mv.Manifest),
+		})
+	}
+	return result
+}
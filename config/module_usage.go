@@ -0,0 +1,145 @@
+package config
+
+import (
+	"github.com/nullstone-io/iac/core"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+// ModuleUsage describes a block or capability that declares its module in the IaC file
+// Blocks that inherit their module from the primary config file are omitted
+type ModuleUsage struct {
+	ObjectPathContext core.ObjectPathContext
+	BlockName         string
+	// CapabilityName is empty for a block
+	CapabilityName   string
+	ModuleSource     string
+	ModuleConstraint string
+	// ModuleVersion is populated via Initialize()
+	ModuleVersion *types.ModuleVersion
+	Variables     VariableConfigurations
+	Connections   ConnectionConfigurations
+}
+
+// ModuleUsages lists the blocks and capabilities that declare their module
+func (e *EnvConfiguration) ModuleUsages() []ModuleUsage {
+	result := make([]ModuleUsage, 0)
+	for _, entry := range e.moduleEntries() {
+		result = append(result, ModuleUsage{
+			ObjectPathContext: entry.pc,
+			BlockName:         entry.blockName,
+			CapabilityName:    entry.capabilityName,
+			ModuleSource:      entry.moduleSource,
+			ModuleConstraint:  entry.moduleConstraint,
+			ModuleVersion:     *entry.moduleVersion,
+			Variables:         entry.variables,
+			Connections:       entry.connections,
+		})
+	}
+	return result
+}
+
+// InheritedUsages lists the variables and connections set in an overrides file for blocks and capabilities that inherit their module from base
+// Each usage is identified by the block and capability name in base and has the module declared in base
+func (e *EnvConfiguration) InheritedUsages(base *EnvConfiguration) []ModuleUsage {
+	result := make([]ModuleUsage, 0)
+	if base == nil {
+		return result
+	}
+	for _, entry := range e.blockConfigurations() {
+		b := entry.block
+		if b.ModuleSource != "" && !b.ModuleInherited {
+			continue
+		}
+		found := base.FindBlockConfigurationByName(b.Name)
+		if found == nil || found.ModuleSource == "" {
+			continue
+		}
+		result = append(result, ModuleUsage{
+			ObjectPathContext: entry.pc,
+			BlockName:         b.Name,
+			ModuleSource:      found.ModuleSource,
+			ModuleConstraint:  found.ModuleConstraint,
+			ModuleVersion:     found.ModuleVersion,
+			Variables:         b.Variables,
+			Connections:       b.Connections,
+		})
+	}
+	for _, app := range e.Applications {
+		baseApp, ok := base.Applications[app.Name]
+		if !ok {
+			continue
+		}
+		for i, c := range app.Capabilities {
+			if c.Disabled || c.IsDeclaration() {
+				continue
+			}
+			found := baseApp.Capabilities.findByIdentity(c.Identity())
+			if found == nil || found.ModuleSource == "" {
+				continue
+			}
+			result = append(result, ModuleUsage{
+				ObjectPathContext: core.NewObjectPathContextKey("apps", app.Name).SubIndex("capabilities", i),
+				BlockName:         app.Name,
+				CapabilityName:    found.Name,
+				ModuleSource:      found.ModuleSource,
+				ModuleConstraint:  found.ModuleConstraint,
+				ModuleVersion:     found.ModuleVersion,
+				Variables:         c.Variables,
+				Connections:       c.Connections,
+			})
+		}
+	}
+	return result
+}
+
+// moduleEntry refers to a block or capability that declares its module in the IaC file
+type moduleEntry struct {
+	pc               core.ObjectPathContext
+	blockName        string
+	capabilityName   string
+	moduleSource     string
+	moduleConstraint string
+	lockedVersion    *string
+	moduleVersion    **types.ModuleVersion
+	variables        VariableConfigurations
+	connections      ConnectionConfigurations
+}
+
+func (e *EnvConfiguration) moduleEntries() []moduleEntry {
+	result := make([]moduleEntry, 0)
+	for _, entry := range e.blockConfigurations() {
+		b := entry.block
+		if b.ModuleSource == "" || b.ModuleInherited {
+			continue
+		}
+		result = append(result, moduleEntry{
+			pc:               entry.pc,
+			blockName:        b.Name,
+			moduleSource:     b.ModuleSource,
+			moduleConstraint: b.ModuleConstraint,
+			lockedVersion:    &b.LockedVersion,
+			moduleVersion:    &b.ModuleVersion,
+			variables:        b.Variables,
+			connections:      b.Connections,
+		})
+	}
+	for _, app := range e.Applications {
+		for i, c := range app.Capabilities {
+			if c.Disabled || !c.IsDeclaration() {
+				continue
+			}
+			result = append(result, moduleEntry{
+				pc:               core.NewObjectPathContextKey("apps", app.Name).SubIndex("capabilities", i),
+				blockName:        app.Name,
+				capabilityName:   c.Name,
+				moduleSource:     c.ModuleSource,
+				moduleConstraint: c.ModuleConstraint,
+				lockedVersion:    &c.LockedVersion,
+				moduleVersion:    &c.ModuleVersion,
+				variables:        c.Variables,
+				connections:      c.Connections,
+			})
+		}
+	}
+	return result
+}
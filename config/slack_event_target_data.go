@@ -2,7 +2,6 @@ package config
 
 import (
 	"context"
-	"fmt"
 	"github.com/nullstone-io/iac/core"
 	"github.com/nullstone-io/iac/yaml"
 	"gopkg.in/nullstone-io/go-api-client.v0/types"
@@ -50,10 +49,7 @@ func (d *SlackEventTargetData) Resolve(ctx context.Context, resolver core.EventC
 		if id, ok := allChannelIds[channelName]; ok {
 			d.ChannelIds[channelName] = id
 		} else {
-			errs = append(errs, core.ResolveError{
-				ObjectPathContext: pc,
-				ErrorMessage:      fmt.Sprintf("Slack channel %q could not be found in the connected workspace", channelName),
-			})
+			errs = append(errs, core.SlackChannelNotFoundError(pc, channelName))
 		}
 	}
 	return errs
@@ -108,6 +108,14 @@ func (s *SubdomainConfiguration) Validate(ic core.IacContext, pc core.ObjectPath
 	return errs
 }
 
+func (s *SubdomainConfiguration) Warnings(ic core.IacContext, pc core.ObjectPathContext, sm core.SourceMap) core.Diagnostics {
+	result := s.BlockConfiguration.Warnings(ic, pc, sm)
+	if sm.Has(pc.SubField("dns_name")) && s.SubdomainNameTemplate != nil {
+		result = append(result, core.DeprecatedDnsNameWarning(pc.SubField("dns_name"), *s.SubdomainNameTemplate))
+	}
+	return result
+}
+
 func (s *SubdomainConfiguration) ApplyChangesTo(ic core.IacContext, updater core.WorkspaceConfigUpdater) error {
 	if err := s.BlockConfiguration.ApplyChangesTo(ic, updater); err != nil {
 		return err
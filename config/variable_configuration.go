@@ -1,9 +1,12 @@
 package config
 
 import (
-	"strings"
+	"maps"
+	"slices"
 
 	"github.com/nullstone-io/iac/core"
+	"github.com/nullstone-io/iac/tftype"
+	"github.com/nullstone-io/iac/yaml"
 	"github.com/nullstone-io/module/config"
 )
 
@@ -25,16 +28,21 @@ func (s VariableConfigurations) Initialize(blockManifest config.Manifest) core.I
 	return nil
 }
 
-func (s VariableConfigurations) Validate(pc core.ObjectPathContext, moduleName string) core.ValidateErrors {
-	if len(s) == 0 {
-		return nil
-	}
+// Validate performs validation on all IaC variables by matching them against variables in the module
+// A non-overrides config must also specify every module variable that has no default
+// TODO: Reject `null` for non-nullable variables once the module manifest records `nullable` (config.Variable has no such field yet)
+func (s VariableConfigurations) Validate(ic core.IacContext, pc core.ObjectPathContext, moduleName string, blockManifest config.Manifest) core.ValidateErrors {
 	errs := core.ValidateErrors{}
-	for k, cur := range s {
-		if err := cur.Validate(pc.SubKey("vars", k), moduleName); err != nil {
-			errs = append(errs, *err)
+	if !ic.IsOverrides {
+		for _, name := range slices.Sorted(maps.Keys(blockManifest.Variables)) {
+			if _, ok := s[name]; !ok && blockManifest.Variables[name].Default == nil {
+				errs = append(errs, *core.MissingRequiredVariableError(pc.SubField("vars"), name))
+			}
 		}
 	}
+	for k, cur := range s {
+		errs = append(errs, cur.Validate(pc.SubKey("vars", k), moduleName)...)
+	}
 	if len(errs) > 0 {
 		return errs
 	}
@@ -46,72 +54,79 @@ type VariableConfiguration struct {
 	Schema *config.Variable `json:"schema"`
 }
 
-func (c *VariableConfiguration) Validate(pc core.ObjectPathContext, moduleName string) *core.ValidateError {
+func (c *VariableConfiguration) Validate(pc core.ObjectPathContext, moduleName string) core.ValidateErrors {
 	if c.Schema == nil {
-		return core.VariableDoesNotExistError(pc, moduleName)
+		return core.ValidateErrors{*core.VariableDoesNotExistError(pc, moduleName)}
 	}
-	if !isValueCompatibleWithType(c.Value, c.Schema.Type) {
-		return core.VariableIncompatibleTypeError(pc, c.Schema.Type, c.Value)
+	if c.Schema.Sensitive && isPlaintext(c.Value) {
+		return core.ValidateErrors{*core.SensitiveVariablePlaintextError(pc)}
 	}
-	return nil
+	var errs core.ValidateErrors
+	validateTemplates(&errs, pc, nil, c.Value)
+	if s, ok := c.Value.(string); ok && core.IsTemplate(s) {
+		// The type is checked by the module once the template is rendered
+		return errs
+	}
+	t, err := tftype.Parse(c.Schema.Type)
+	if err != nil {
+		// Unknown type — allow any value to avoid false positives
+		return errs
+	}
+	for _, m := range t.Validate(c.Value) {
+		errs = append(errs, *variableMismatchError(pc, m))
+	}
+	return errs
 }
 
-// isValueCompatibleWithType checks whether the given value (parsed from YAML) is compatible
-// with the declared Terraform variable type. The type string may be a simple type (e.g. "string",
-// "number", "bool") or a complex type expression (e.g. "list(string)", "map(number)",
-// "object({...})", "set(string)", "tuple([...])"). Only the base type name is used for the check.
-func isValueCompatibleWithType(value any, varType string) bool {
-	if value == nil {
-		return true
+// validateTemplates checks every template string within a variable value
+func validateTemplates(errs *core.ValidateErrors, pc core.ObjectPathContext, path tftype.Path, value any) {
+	switch v := value.(type) {
+	case string:
+		if err := core.ValidateTemplate(v); err != nil {
+			*errs = append(*errs, core.InvalidTemplateError(subPath(pc, path), err))
+		}
+	case []any:
+		for i, item := range v {
+			validateTemplates(errs, pc, append(path[:len(path):len(path)], tftype.PathStep{Index: &i}), item)
+		}
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			validateTemplates(errs, pc, append(path[:len(path):len(path)], tftype.PathStep{Key: key}), v[key])
+		}
 	}
+}
 
-	baseType := extractBaseType(varType)
-
-	switch baseType {
-	case "string":
-		_, ok := value.(string)
-		return ok
-	case "number":
-		return isNumeric(value)
-	case "bool":
-		_, ok := value.(bool)
-		return ok
-	case "list", "set", "tuple":
-		_, ok := value.([]any)
-		return ok
-	case "map", "object":
-		_, ok := value.(map[string]any)
-		return ok
-	default:
-		// Unknown type — allow any value to avoid false positives
-		return true
+func variableMismatchError(pc core.ObjectPathContext, m tftype.Mismatch) *core.ValidateError {
+	pc = subPath(pc, m.Path)
+	switch m.Kind {
+	case tftype.MismatchMissingAttribute:
+		return core.VariableMissingAttributeError(pc, m.Attribute)
+	case tftype.MismatchTupleLength:
+		return core.VariableTupleLengthError(pc, len(m.Type.Elems), len(m.Value.([]any)))
 	}
+	return core.VariableIncompatibleTypeError(pc, m.Type.String(), m.Value)
 }
 
-// extractBaseType returns the base type name from a Terraform type expression.
-// Examples:
-//
-//	"string"                          → "string"
-//	"list(string)"                    → "list"
-//	"map(number)"                     → "map"
-//	"object({ enabled : bool ... })"  → "object"
-//	"set(string)"                     → "set"
-//	"tuple([string, number])"         → "tuple"
-func extractBaseType(varType string) string {
-	varType = strings.TrimSpace(varType)
-	if idx := strings.Index(varType, "("); idx != -1 {
-		return strings.TrimSpace(varType[:idx])
+// subPath descends into a variable value (e.g. vars.scaling => vars.scaling.min or vars.ports[0])
+func subPath(pc core.ObjectPathContext, path tftype.Path) core.ObjectPathContext {
+	for _, step := range path {
+		if step.Index != nil {
+			pc = core.ObjectPathContext{Path: pc.Context(), Index: step.Index}
+		} else {
+			pc = core.ObjectPathContext{Path: pc.Context(), Field: step.Key}
+		}
 	}
-	return varType
+	return pc
 }
 
-// isNumeric returns true for any Go numeric type that YAML or JSON might produce.
-func isNumeric(value any) bool {
-	switch value.(type) {
-	case int, int8, int16, int32, int64,
-		uint, uint8, uint16, uint32, uint64,
-		float32, float64:
-		return true
+// isPlaintext returns true if a value would commit a secret to the repository
+// Values redacted during export are placeholders, not secrets
+func isPlaintext(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v != "" && v != yaml.RedactedValue
 	}
-	return false
+	return true
 }
@@ -3,315 +3,116 @@ package config
 import (
 	"testing"
 
+	"github.com/nullstone-io/iac/core"
 	"github.com/nullstone-io/module/config"
-	"gopkg.in/yaml.v3"
+	"github.com/stretchr/testify/assert"
 )
 
-func TestExtractBaseType(t *testing.T) {
+func TestVariableConfiguration_Validate_nested(t *testing.T) {
+	pc := core.NewObjectPathContextKey("apps", "api").SubKey("vars", "scaling")
+	vc := &VariableConfiguration{
+		Value: map[string]any{
+			"min":   "one",
+			"ports": []any{80, "http"},
+		},
+		Schema: &config.Variable{Type: "object({ min = number, max = number, ports = list(number) })"},
+	}
+	errs := vc.Validate(pc, "acme/api")
+
+	got := map[string]string{}
+	for _, err := range errs {
+		got[err.ObjectPathContext.Context()] = err.ErrorMessage
+	}
+	assert.Equal(t, map[string]string{
+		"apps.api.vars.scaling":          "Specified variable value is missing required attribute (max)",
+		"apps.api.vars.scaling.min":      "Specified variable value (string) is incompatible with expected variable type (number)",
+		"apps.api.vars.scaling.ports[1]": "Specified variable value (string) is incompatible with expected variable type (number)",
+	}, got)
+}
+
+func TestVariableConfigurations_Validate(t *testing.T) {
+	manifest := config.Manifest{
+		Variables: map[string]config.Variable{
+			"image":    {Type: "string"},
+			"cpu":      {Type: "number", Default: float64(256)},
+			"password": {Type: "string", Sensitive: true},
+		},
+	}
+	pc := core.NewObjectPathContextKey("apps", "api")
+	varsPc := pc.SubField("vars")
+
 	tests := []struct {
-		name     string
-		varType  string
-		expected string
+		name string
+		ic   core.IacContext
+		vars VariableConfigurations
+		want core.ValidateErrors
 	}{
 		{
-			name:     "primitive string",
-			varType:  "string",
-			expected: "string",
-		},
-		{
-			name:     "primitive number",
-			varType:  "number",
-			expected: "number",
-		},
-		{
-			name:     "primitive bool",
-			varType:  "bool",
-			expected: "bool",
-		},
-		{
-			name:     "list of strings",
-			varType:  "list(string)",
-			expected: "list",
-		},
-		{
-			name:     "map of numbers",
-			varType:  "map(number)",
-			expected: "map",
-		},
-		{
-			name:     "set of strings",
-			varType:  "set(string)",
-			expected: "set",
-		},
-		{
-			name:     "object with attributes",
-			varType:  "object({ enabled = bool, name = string })",
-			expected: "object",
+			name: "missing required variables",
+			vars: VariableConfigurations{"cpu": {Value: 512, Schema: &config.Variable{Type: "number"}}},
+			want: core.ValidateErrors{
+				*core.MissingRequiredVariableError(varsPc, "image"),
+				*core.MissingRequiredVariableError(varsPc, "password"),
+			},
 		},
 		{
-			name:     "tuple with mixed types",
-			varType:  "tuple([string, number, bool])",
-			expected: "tuple",
+			name: "overrides do not require variables",
+			ic:   core.IacContext{IsOverrides: true},
+			vars: VariableConfigurations{},
+			want: nil,
 		},
 		{
-			name:     "list with whitespace",
-			varType:  "  list(string)  ",
-			expected: "list",
+			name: "plaintext sensitive value",
+			vars: VariableConfigurations{
+				"image":    {Value: "nginx", Schema: &config.Variable{Type: "string"}},
+				"password": {Value: "hunter2", Schema: &config.Variable{Type: "string", Sensitive: true}},
+			},
+			want: core.ValidateErrors{*core.SensitiveVariablePlaintextError(pc.SubKey("vars", "password"))},
 		},
 		{
-			name:     "object with complex attributes",
-			varType:  "object({ enabled : bool spa_mode : bool document : string })",
-			expected: "object",
+			name: "redacted sensitive value",
+			vars: VariableConfigurations{
+				"image":    {Value: "nginx", Schema: &config.Variable{Type: "string"}},
+				"password": {Value: "••••••••••", Schema: &config.Variable{Type: "string", Sensitive: true}},
+			},
+			want: nil,
 		},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := extractBaseType(tt.varType)
-			if got != tt.expected {
-				t.Errorf("extractBaseType(%q) = %q, want %q", tt.varType, got, tt.expected)
-			}
+			assert.Equal(t, tt.want, tt.vars.Validate(tt.ic, pc, "acme/api", manifest))
 		})
 	}
 }
 
-func TestIsValueCompatibleWithType(t *testing.T) {
+func TestVariableConfiguration_Validate_templates(t *testing.T) {
+	pc := core.NewObjectPathContextKey("apps", "api").SubKey("vars", "cpu")
 	tests := []struct {
-		name     string
-		yamlVars string
-		schemas  map[string]config.Variable
-		expected map[string]bool // key: variable name, value: expected compatibility
+		name  string
+		value any
+		want  core.ValidateErrors
 	}{
 		{
-			name: "primitive types - valid",
-			yamlVars: `
-vars:
-  string_var: "hello world"
-  number_var: 42
-  bool_var: true
-`,
-			schemas: map[string]config.Variable{
-				"string_var": {Type: "string"},
-				"number_var": {Type: "number"},
-				"bool_var":   {Type: "bool"},
-			},
-			expected: map[string]bool{
-				"string_var": true,
-				"number_var": true,
-				"bool_var":   true,
-			},
+			name:  "templated value skips the type check",
+			value: "{{ if NULLSTONE_ENV_IS_PROD }}1024{{ else }}256{{ end }}",
 		},
 		{
-			name: "primitive types - invalid",
-			yamlVars: `
-vars:
-  string_var: 123
-  number_var: "not a number"
-  bool_var: "not a bool"
-`,
-			schemas: map[string]config.Variable{
-				"string_var": {Type: "string"},
-				"number_var": {Type: "number"},
-				"bool_var":   {Type: "bool"},
-			},
-			expected: map[string]bool{
-				"string_var": false,
-				"number_var": false,
-				"bool_var":   false,
-			},
-		},
-		{
-			name: "collection types - valid",
-			yamlVars: `
-vars:
-  list_var: ["item1", "item2", "item3"]
-  set_var: ["unique1", "unique2"]
-  tuple_var: ["string", 123, true]
-`,
-			schemas: map[string]config.Variable{
-				"list_var":  {Type: "list(string)"},
-				"set_var":   {Type: "set(string)"},
-				"tuple_var": {Type: "tuple([string, number, bool])"},
-			},
-			expected: map[string]bool{
-				"list_var":  true,
-				"set_var":   true,
-				"tuple_var": true,
-			},
-		},
-		{
-			name: "collection types - invalid",
-			yamlVars: `
-vars:
-  list_var: "not a list"
-  set_var: 123
-  tuple_var: {"not": "a tuple"}
-`,
-			schemas: map[string]config.Variable{
-				"list_var":  {Type: "list(string)"},
-				"set_var":   {Type: "set(string)"},
-				"tuple_var": {Type: "tuple([string, number, bool])"},
-			},
-			expected: map[string]bool{
-				"list_var":  false,
-				"set_var":   false,
-				"tuple_var": false,
-			},
-		},
-		{
-			name: "map/object types - valid",
-			yamlVars: `
-vars:
-  map_var:
-    key1: "value1"
-    key2: "value2"
-  object_var:
-    name: "test"
-    enabled: true
-    count: 42
-`,
-			schemas: map[string]config.Variable{
-				"map_var":    {Type: "map(string)"},
-				"object_var": {Type: "object({ name = string, enabled = bool, count = number })"},
-			},
-			expected: map[string]bool{
-				"map_var":    true,
-				"object_var": true,
-			},
-		},
-		{
-			name: "map/object types - invalid",
-			yamlVars: `
-vars:
-  map_var: "not a map"
-  object_var: ["not", "an", "object"]
-`,
-			schemas: map[string]config.Variable{
-				"map_var":    {Type: "map(string)"},
-				"object_var": {Type: "object({ name = string, enabled = bool, count = number })"},
-			},
-			expected: map[string]bool{
-				"map_var":    false,
-				"object_var": false,
-			},
-		},
-		{
-			name: "null values - should be compatible",
-			yamlVars: `
-vars:
-  string_var: null
-  number_var: null
-  bool_var: null
-  list_var: null
-  map_var: null
-  object_var: null
-`,
-			schemas: map[string]config.Variable{
-				"string_var": {Type: "string"},
-				"number_var": {Type: "number"},
-				"bool_var":   {Type: "bool"},
-				"list_var":   {Type: "list(string)"},
-				"map_var":    {Type: "map(string)"},
-				"object_var": {Type: "object({ name = string })"},
-			},
-			expected: map[string]bool{
-				"string_var": true,
-				"number_var": true,
-				"bool_var":   true,
-				"list_var":   true,
-				"map_var":    true,
-				"object_var": true,
-			},
-		},
-		{
-			name: "numeric types - valid",
-			yamlVars: `
-vars:
-  int_var: 42
-  float_var: 3.14
-  zero_var: 0
-  negative_var: -123
-`,
-			schemas: map[string]config.Variable{
-				"int_var":      {Type: "number"},
-				"float_var":    {Type: "number"},
-				"zero_var":     {Type: "number"},
-				"negative_var": {Type: "number"},
-			},
-			expected: map[string]bool{
-				"int_var":      true,
-				"float_var":    true,
-				"zero_var":     true,
-				"negative_var": true,
-			},
-		},
-		{
-			name: "complex nested structures",
-			yamlVars: `
-vars:
-  complex_list:
-    - name: "item1"
-      count: 10
-      enabled: true
-    - name: "item2"
-      count: 20
-      enabled: false
-  complex_map:
-    nested:
-      deep:
-        value: "found"
-    numbers: [1, 2, 3]
-`,
-			schemas: map[string]config.Variable{
-				"complex_list": {Type: "list(object({ name = string, count = number, enabled = bool }))"},
-				"complex_map":  {Type: "map(any)"},
-			},
-			expected: map[string]bool{
-				"complex_list": true, // YAML parses as []any, which is compatible with list
-				"complex_map":  true, // YAML parses as map[string]any, which is compatible with map
-			},
-		},
-		{
-			name: "unknown types - should be compatible",
-			yamlVars: `
-vars:
-  unknown_type_var: "anything"
-  another_unknown: 123
-`,
-			schemas: map[string]config.Variable{
-				"unknown_type_var": {Type: "custom_type"},
-				"another_unknown":  {Type: "unknown_format"},
-			},
-			expected: map[string]bool{
-				"unknown_type_var": true,
-				"another_unknown":  true,
+			name:  "nested unknown function",
+			value: map[string]any{"labels": []any{"{{ upper NULLSTONE_ENV }}"}},
+			want: core.ValidateErrors{
+				{
+					ObjectPathContext: core.ObjectPathContext{Path: "apps.api.vars.cpu.labels", Index: ptr(0)},
+					ErrorMessage:      `Invalid template: unknown template variable or function "upper"`,
+					Code:              core.CodeInvalidTemplate,
+				},
+				*core.VariableIncompatibleTypeError(pc, "number", map[string]any{"labels": []any{"{{ upper NULLSTONE_ENV }}"}}),
 			},
 		},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Parse YAML like the actual library does
-			var parsed struct {
-				Vars map[string]any `yaml:"vars"`
-			}
-			err := yaml.Unmarshal([]byte(tt.yamlVars), &parsed)
-			if err != nil {
-				t.Fatalf("Failed to parse YAML: %v", err)
-			}
-
-			// Test each variable
-			for varName, expectedCompatible := range tt.expected {
-				t.Run(varName, func(t *testing.T) {
-					value := parsed.Vars[varName]
-					schema := tt.schemas[varName]
-					got := isValueCompatibleWithType(value, schema.Type)
-					if got != expectedCompatible {
-						t.Errorf("isValueCompatibleWithType(%v (type: %T), %q) = %v, want %v",
-							value, value, schema.Type, got, expectedCompatible)
-					}
-				})
-			}
+			vc := &VariableConfiguration{Value: tt.value, Schema: &config.Variable{Type: "number"}}
+			assert.Equal(t, tt.want, vc.Validate(pc, "acme/api"))
 		})
 	}
 }
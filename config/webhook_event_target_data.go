@@ -2,7 +2,6 @@ package config
 
 import (
 	"context"
-	"fmt"
 	"net/url"
 
 	"github.com/nullstone-io/iac/core"
@@ -30,22 +29,13 @@ func (d *WebhookEventTargetData) Validate(ic core.IacContext, pc core.ObjectPath
 	}
 	errs := core.ValidateErrors{}
 	if d.Url == "" {
-		errs = append(errs, core.ValidateError{
-			ObjectPathContext: pc,
-			ErrorMessage:      fmt.Sprintf("When specifying `webhook`, `url` is required"),
-		})
+		errs = append(errs, core.MissingWebhookUrlError(pc))
 	} else {
 		parsed, err := url.Parse(d.Url)
 		if err != nil {
-			errs = append(errs, core.ValidateError{
-				ObjectPathContext: pc.SubField("url"),
-				ErrorMessage:      fmt.Sprintf("Invalid webhook URL: %s", err.Error()),
-			})
+			errs = append(errs, core.InvalidWebhookUrlError(pc.SubField("url"), err))
 		} else if parsed.Scheme == "" {
-			errs = append(errs, core.ValidateError{
-				ObjectPathContext: pc.SubField("url"),
-				ErrorMessage:      fmt.Sprintf("Invalid webhook URL"),
-			})
+			errs = append(errs, core.InvalidWebhookUrlError(pc.SubField("url"), nil))
 		}
 	}
 	return errs
@@ -3,6 +3,7 @@ package iac
 import (
 	"github.com/nullstone-io/iac/config"
 	"github.com/nullstone-io/iac/core"
+	"github.com/nullstone-io/iac/lockfile"
 	"gopkg.in/nullstone-io/go-api-client.v0/types"
 )
 
@@ -20,6 +21,10 @@ type ConfigFiles struct {
 	// Overrides contains `.nullstone/<env|previews>.yml` validated and normalized as Nullstone objects
 	// This was added to the state since TemporalIacSync.IacSync Overrides is intentionally redacted from json
 	Overrides map[string]*config.EnvConfiguration `json:"overrides"`
+
+	// Lock contains `.nullstone/lock.yml`, which pins the module version of each block and capability
+	// This is nil if the repository does not have a lock file
+	Lock *lockfile.Lock `json:"lock,omitempty"`
 }
 
 func (r ConfigFiles) BlockNames(env types.Environment) map[string]bool {
@@ -36,12 +41,16 @@ func (r ConfigFiles) BlockNames(env types.Environment) map[string]bool {
 }
 
 func (r ConfigFiles) GetOverrides(env types.Environment) *config.EnvConfiguration {
+	ec, _ := r.Overrides[overridesName(env)]
+	return ec
+}
+
+// overridesName returns the name of the overrides file for env (e.g. `previews` for every preview env)
+func overridesName(env types.Environment) string {
 	if env.Type == types.EnvTypePreview {
-		ec, _ := r.Overrides["previews"]
-		return ec
+		return "previews"
 	}
-	ec, _ := r.Overrides[env.Name]
-	return ec
+	return env.Name
 }
 
 func (r ConfigFiles) NewIacFinder(env types.Environment) core.IacFinder {
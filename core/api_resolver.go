@@ -19,9 +19,10 @@ var (
 )
 
 type ApiResolver struct {
-	ApiClient            *api.Client
-	ResourceResolver     *find.ResourceResolver
-	EventChannelResolver EventChannelResolver
+	ApiClient               *api.Client
+	ResourceResolver        *find.ResourceResolver
+	EventChannelResolver    EventChannelResolver
+	SecretReferenceResolver SecretReferenceResolver
 }
 
 func NewApiResolver(apiClient *api.Client, stackId, envId int64) *ApiResolver {
@@ -34,7 +35,8 @@ func NewApiResolver(apiClient *api.Client, stackId, envId int64) *ApiResolver {
 			StacksById:   map[int64]*find.StackResolver{},
 			StacksByName: map[string]*find.StackResolver{},
 		},
-		EventChannelResolver: &ApiEventChannelResolver{ApiClient: apiClient},
+		EventChannelResolver:    &ApiEventChannelResolver{ApiClient: apiClient},
+		SecretReferenceResolver: LiteralSecretReferenceResolver{},
 	}
 }
 
@@ -78,6 +80,13 @@ func (a *ApiResolver) ResolveModuleVersion(ctx context.Context, source artifacts
 	if version == "latest" {
 		return m, m.LatestVersion, nil
 	}
+	if IsVersionConstraint(version) {
+		constraint, err := ParseVersionConstraint(version)
+		if err != nil {
+			return m, nil, err
+		}
+		return m, constraint.HighestMatch(m.Versions), nil
+	}
 	mv, err := a.ApiClient.ModuleVersions().Get(ctx, source.OrgName, source.ModuleName, version)
 	return m, mv, err
 }
@@ -89,6 +98,13 @@ func (a *ApiResolver) ListChannels(ctx context.Context, tool string) ([]map[stri
 	return a.EventChannelResolver.ListChannels(ctx, tool)
 }
 
+func (a *ApiResolver) ResolveSecretReference(ctx context.Context, ref SecretReference) (string, error) {
+	if a.SecretReferenceResolver == nil {
+		return LiteralSecretReferenceResolver{}.ResolveSecretReference(ctx, ref)
+	}
+	return a.SecretReferenceResolver.ResolveSecretReference(ctx, ref)
+}
+
 func (a *ApiResolver) ReserveNullstoneSubdomain(ctx context.Context, blockName string, requested string) (*types.SubdomainReservation, error) {
 	block, err := a.ResolveBlock(ctx, types.ConnectionTarget{BlockName: blockName})
 	if err != nil {
@@ -0,0 +1,244 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/nullstone-io/go-api-client.v0/artifacts"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+var (
+	_ InitializeResolver = &CachingResolver{}
+	_ NormalizeResolver  = &CachingResolver{}
+	_ ResolveResolver    = &CachingResolver{}
+)
+
+type CacheOptions struct {
+	// TTL is how long a result is cached
+	// If zero, results are cached for the lifetime of the CachingResolver
+	TTL time.Duration
+	// NegativeTTL is how long a not-found result is cached (e.g. a module that does not exist)
+	// If zero, not-found results are not cached
+	NegativeTTL time.Duration
+	// IsNotFound reports whether an error means the requested object does not exist
+	// Errors are only cached when NegativeTTL is set and IsNotFound returns true; other errors are never cached
+	// If nil, IsMissingResource is used
+	IsNotFound func(err error) bool
+}
+
+// CacheStats counts the lookups made through a CachingResolver
+type CacheStats struct {
+	// Hits is the number of lookups served from the cache
+	Hits int64 `json:"hits"`
+	// Misses is the number of lookups sent to the wrapped resolver
+	Misses int64 `json:"misses"`
+	// Shared is the number of lookups that waited on an identical in-flight lookup instead of calling the wrapped resolver
+	Shared int64 `json:"shared"`
+}
+
+// CachingResolver wraps a resolver to cache lookups of modules, blocks, connections, and workspace configs
+// Identical lookups that are in flight at the same time only call the wrapped resolver once
+// A single CachingResolver can be shared across Initialize, Normalize, and Resolve
+// Cached values are shared between callers and must not be modified
+// ReserveNullstoneSubdomain and ResolveSecretReference are never cached
+type CachingResolver struct {
+	Resolver ResolveResolver
+	Options  CacheOptions
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	calls   map[string]*cacheCall
+	now     func() time.Time
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	shared atomic.Int64
+}
+
+type cacheEntry struct {
+	value   any
+	err     error
+	expires time.Time
+}
+
+type cacheCall struct {
+	// done is closed once value and err are set
+	done  chan struct{}
+	value any
+	err   error
+}
+
+func NewCachingResolver(resolver ResolveResolver, options CacheOptions) *CachingResolver {
+	return &CachingResolver{Resolver: resolver, Options: options}
+}
+
+// Stats returns the number of cache hits, misses, and shared lookups so far
+func (r *CachingResolver) Stats() CacheStats {
+	return CacheStats{
+		Hits:   r.hits.Load(),
+		Misses: r.misses.Load(),
+		Shared: r.shared.Load(),
+	}
+}
+
+// Reset removes every cached result
+func (r *CachingResolver) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+func (r *CachingResolver) ResolveModule(ctx context.Context, source artifacts.ModuleSource) (*types.Module, error) {
+	key := fmt.Sprintf("module|%s/%s", source.OrgName, source.ModuleName)
+	return cached(ctx, r, key, func(ctx context.Context) (*types.Module, bool, error) {
+		m, err := r.Resolver.ResolveModule(ctx, source)
+		return m, m == nil, err
+	})
+}
+
+func (r *CachingResolver) ResolveModuleVersion(ctx context.Context, source artifacts.ModuleSource, version string) (*types.Module, *types.ModuleVersion, error) {
+	type result struct {
+		m  *types.Module
+		mv *types.ModuleVersion
+	}
+	key := fmt.Sprintf("module-version|%s/%s|%s", source.OrgName, source.ModuleName, version)
+	res, err := cached(ctx, r, key, func(ctx context.Context) (result, bool, error) {
+		m, mv, err := r.Resolver.ResolveModuleVersion(ctx, source, version)
+		return result{m: m, mv: mv}, m == nil || mv == nil, err
+	})
+	return res.m, res.mv, err
+}
+
+func (r *CachingResolver) ResolveBlock(ctx context.Context, ct types.ConnectionTarget) (types.Block, error) {
+	return cached(ctx, r, "block|"+connectionTargetKey(ct), func(ctx context.Context) (types.Block, bool, error) {
+		block, err := r.Resolver.ResolveBlock(ctx, ct)
+		return block, false, err
+	})
+}
+
+func (r *CachingResolver) ResolveConnection(ctx context.Context, ct types.ConnectionTarget) (types.ConnectionTarget, error) {
+	cr, ok := r.Resolver.(ConnectionResolver)
+	if !ok {
+		return types.ConnectionTarget{}, fmt.Errorf("%T does not support resolving connections", r.Resolver)
+	}
+	return cached(ctx, r, "connection|"+connectionTargetKey(ct), func(ctx context.Context) (types.ConnectionTarget, bool, error) {
+		result, err := cr.ResolveConnection(ctx, ct)
+		return result, false, err
+	})
+}
+
+func (r *CachingResolver) ResolveWorkspaceModuleConfig(ctx context.Context, ct types.ConnectionTarget) (WorkspaceModuleConfig, error) {
+	return cached(ctx, r, "workspace-module-config|"+connectionTargetKey(ct), func(ctx context.Context) (WorkspaceModuleConfig, bool, error) {
+		wmc, err := r.Resolver.ResolveWorkspaceModuleConfig(ctx, ct)
+		return wmc, wmc.Module == "", err
+	})
+}
+
+func (r *CachingResolver) ListChannels(ctx context.Context, tool string) ([]map[string]any, error) {
+	return cached(ctx, r, "channels|"+tool, func(ctx context.Context) ([]map[string]any, bool, error) {
+		channels, err := r.Resolver.ListChannels(ctx, tool)
+		return channels, false, err
+	})
+}
+
+func (r *CachingResolver) ReserveNullstoneSubdomain(ctx context.Context, blockName string, requested string) (*types.SubdomainReservation, error) {
+	return r.Resolver.ReserveNullstoneSubdomain(ctx, blockName, requested)
+}
+
+func (r *CachingResolver) ResolveSecretReference(ctx context.Context, ref SecretReference) (string, error) {
+	return r.Resolver.ResolveSecretReference(ctx, ref)
+}
+
+// cached returns the cached result for key or calls fn once for all callers waiting on key
+// fn returns notFound=true if the result refers to an object that does not exist
+// fn runs with a ctx that is not cancelled with the caller's ctx since the result is shared with other callers
+// A caller waiting on another caller's lookup stops waiting when ctx is done
+func cached[T any](ctx context.Context, r *CachingResolver, key string, fn func(ctx context.Context) (T, bool, error)) (T, error) {
+	r.mu.Lock()
+	now := r.clock()
+	if entry, ok := r.entries[key]; ok {
+		if entry.expires.IsZero() || now.Before(entry.expires) {
+			r.mu.Unlock()
+			r.hits.Add(1)
+			return entry.value.(T), entry.err
+		}
+		delete(r.entries, key)
+	}
+	if c, ok := r.calls[key]; ok {
+		r.mu.Unlock()
+		r.shared.Add(1)
+		select {
+		case <-c.done:
+			value, _ := c.value.(T)
+			return value, c.err
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+	c := &cacheCall{done: make(chan struct{}), err: fmt.Errorf("lookup (%s) did not complete", key)}
+	if r.calls == nil {
+		r.calls = map[string]*cacheCall{}
+	}
+	r.calls[key] = c
+	r.mu.Unlock()
+	r.misses.Add(1)
+	// Release waiting callers even if fn panics
+	defer func() {
+		r.mu.Lock()
+		delete(r.calls, key)
+		r.mu.Unlock()
+		close(c.done)
+	}()
+
+	value, notFound, err := fn(context.WithoutCancel(ctx))
+	c.value, c.err = value, err
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ttl, ok := r.ttl(notFound, err); ok {
+		entry := cacheEntry{value: value, err: err}
+		if ttl > 0 {
+			entry.expires = r.clock().Add(ttl)
+		}
+		if r.entries == nil {
+			r.entries = map[string]cacheEntry{}
+		}
+		r.entries[key] = entry
+	}
+	return value, err
+}
+
+// ttl returns how long to cache a result and whether it should be cached at all
+func (r *CachingResolver) ttl(notFound bool, err error) (time.Duration, bool) {
+	if err != nil {
+		isNotFound := r.Options.IsNotFound
+		if isNotFound == nil {
+			isNotFound = IsMissingResource
+		}
+		return r.Options.NegativeTTL, isNotFound(err) && r.Options.NegativeTTL > 0
+	}
+	if notFound {
+		return r.Options.NegativeTTL, r.Options.NegativeTTL > 0
+	}
+	return r.Options.TTL, true
+}
+
+func (r *CachingResolver) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+func connectionTargetKey(ct types.ConnectionTarget) string {
+	envId := "-"
+	if ct.EnvId != nil {
+		envId = fmt.Sprintf("%d", *ct.EnvId)
+	}
+	return fmt.Sprintf("%d/%s/%d/%s/%s/%s", ct.StackId, ct.StackName, ct.BlockId, ct.BlockName, envId, ct.EnvName)
+}
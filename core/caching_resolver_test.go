@@ -0,0 +1,300 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/nullstone-io/go-api-client.v0/artifacts"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+var errBlockNotFound = errors.New("block not found")
+
+type countingResolver struct {
+	mu      sync.Mutex
+	calls   map[string]int
+	modules map[string]*types.Module
+	// release blocks ResolveModule until closed
+	release chan struct{}
+	// panics makes ResolveModule panic once it is released
+	panics bool
+}
+
+func (r *countingResolver) count(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.calls == nil {
+		r.calls = map[string]int{}
+	}
+	r.calls[key]++
+}
+
+func (r *countingResolver) Calls(key string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[key]
+}
+
+func (r *countingResolver) ResolveModule(ctx context.Context, source artifacts.ModuleSource) (*types.Module, error) {
+	r.count("module")
+	if r.release != nil {
+		select {
+		case <-r.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if r.panics {
+		panic("resolver failed")
+	}
+	return r.modules[source.ModuleName], nil
+}
+
+func (r *countingResolver) ResolveModuleVersion(ctx context.Context, source artifacts.ModuleSource, version string) (*types.Module, *types.ModuleVersion, error) {
+	r.count("module-version")
+	m := r.modules[source.ModuleName]
+	if m == nil {
+		return nil, nil, nil
+	}
+	return m, m.LatestVersion, nil
+}
+
+func (r *countingResolver) ResolveBlock(ctx context.Context, ct types.ConnectionTarget) (types.Block, error) {
+	r.count("block")
+	if ct.BlockName == "missing" {
+		return types.Block{}, errBlockNotFound
+	}
+	if ct.BlockName == "deleted" {
+		return types.Block{}, MissingSnapshotResourceError{Kind: "block", Name: ct.BlockName}
+	}
+	if ct.BlockName == "flaky" {
+		return types.Block{}, errors.New("timeout")
+	}
+	return types.Block{Name: ct.BlockName}, nil
+}
+
+func (r *countingResolver) ResolveConnection(ctx context.Context, ct types.ConnectionTarget) (types.ConnectionTarget, error) {
+	r.count("connection")
+	ct.StackId = 1
+	return ct, nil
+}
+
+func (r *countingResolver) ResolveWorkspaceModuleConfig(ctx context.Context, ct types.ConnectionTarget) (WorkspaceModuleConfig, error) {
+	r.count("workspace-module-config")
+	return WorkspaceModuleConfig{}, nil
+}
+
+func (r *countingResolver) ListChannels(ctx context.Context, tool string) ([]map[string]any, error) {
+	r.count("channels")
+	return nil, nil
+}
+
+func (r *countingResolver) ReserveNullstoneSubdomain(ctx context.Context, blockName string, requested string) (*types.SubdomainReservation, error) {
+	r.count("subdomain")
+	return &types.SubdomainReservation{}, nil
+}
+
+func (r *countingResolver) ResolveSecretReference(ctx context.Context, ref SecretReference) (string, error) {
+	r.count("secret")
+	return "", nil
+}
+
+func TestCachingResolver(t *testing.T) {
+	ctx := context.Background()
+	fargate := &types.Module{Name: "aws-fargate-service", LatestVersion: &types.ModuleVersion{Version: "0.13.0"}}
+	source := artifacts.ModuleSource{OrgName: "nullstone", ModuleName: "aws-fargate-service"}
+	missingSource := artifacts.ModuleSource{OrgName: "nullstone", ModuleName: "missing"}
+
+	t.Run("caches results and reports stats", func(t *testing.T) {
+		inner := &countingResolver{modules: map[string]*types.Module{"aws-fargate-service": fargate}}
+		r := NewCachingResolver(inner, CacheOptions{})
+		for i := 0; i < 3; i++ {
+			m, mv, err := r.ResolveModuleVersion(ctx, source, "latest")
+			require.NoError(t, err)
+			assert.Equal(t, fargate, m)
+			assert.Equal(t, "0.13.0", mv.Version)
+			ct, err := r.ResolveConnection(ctx, types.ConnectionTarget{BlockName: "network"})
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), ct.StackId)
+		}
+		_, _, err := r.ResolveModuleVersion(ctx, source, "0.12.0")
+		require.NoError(t, err)
+		_, err = r.ReserveNullstoneSubdomain(ctx, "api", "api")
+		require.NoError(t, err)
+		_, err = r.ReserveNullstoneSubdomain(ctx, "api", "api")
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, inner.Calls("module-version"))
+		assert.Equal(t, 1, inner.Calls("connection"))
+		assert.Equal(t, 2, inner.Calls("subdomain"))
+		assert.Equal(t, CacheStats{Hits: 4, Misses: 3}, r.Stats())
+
+		r.Reset()
+		_, _, err = r.ResolveModuleVersion(ctx, source, "latest")
+		require.NoError(t, err)
+		assert.Equal(t, 3, inner.Calls("module-version"))
+	})
+
+	t.Run("coalesces in-flight lookups", func(t *testing.T) {
+		inner := &countingResolver{
+			modules: map[string]*types.Module{"aws-fargate-service": fargate},
+			release: make(chan struct{}),
+		}
+		r := NewCachingResolver(inner, CacheOptions{})
+		var wg sync.WaitGroup
+		results := make([]*types.Module, 5)
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], _ = r.ResolveModule(ctx, source)
+			}()
+		}
+		require.Eventually(t, func() bool {
+			stats := r.Stats()
+			return stats.Misses+stats.Shared == int64(len(results))
+		}, time.Second, time.Millisecond)
+		close(inner.release)
+		wg.Wait()
+
+		assert.Equal(t, 1, inner.Calls("module"))
+		assert.Equal(t, CacheStats{Misses: 1, Shared: 4}, r.Stats())
+		for _, m := range results {
+			assert.Equal(t, fargate, m)
+		}
+	})
+
+	t.Run("releases waiters when the lookup panics", func(t *testing.T) {
+		inner := &countingResolver{release: make(chan struct{}), panics: true}
+		r := NewCachingResolver(inner, CacheOptions{})
+		go func() {
+			defer func() { _ = recover() }()
+			_, _ = r.ResolveModule(ctx, source)
+		}()
+		require.Eventually(t, func() bool { return r.Stats().Misses == 1 }, time.Second, time.Millisecond)
+		waiter := make(chan error)
+		go func() {
+			_, err := r.ResolveModule(ctx, source)
+			waiter <- err
+		}()
+		require.Eventually(t, func() bool { return r.Stats().Shared == 1 }, time.Second, time.Millisecond)
+		close(inner.release)
+
+		select {
+		case err := <-waiter:
+			assert.Error(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("waiter is still blocked after the lookup panicked")
+		}
+		// The failed lookup is not cached
+		assert.Panics(t, func() { _, _ = r.ResolveModule(ctx, source) })
+		assert.Equal(t, 2, inner.Calls("module"))
+	})
+
+	t.Run("waiters stop when their context is done", func(t *testing.T) {
+		inner := &countingResolver{modules: map[string]*types.Module{"aws-fargate-service": fargate}, release: make(chan struct{})}
+		r := NewCachingResolver(inner, CacheOptions{})
+		leader := make(chan *types.Module)
+		go func() {
+			m, _ := r.ResolveModule(ctx, source)
+			leader <- m
+		}()
+		require.Eventually(t, func() bool { return r.Stats().Misses == 1 }, time.Second, time.Millisecond)
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		m, err := r.ResolveModule(cancelled, source)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, m)
+
+		close(inner.release)
+		assert.Equal(t, fargate, <-leader)
+		assert.Equal(t, 1, inner.Calls("module"))
+	})
+
+	t.Run("waiters receive the result when the leader's context is done", func(t *testing.T) {
+		inner := &countingResolver{modules: map[string]*types.Module{"aws-fargate-service": fargate}, release: make(chan struct{})}
+		r := NewCachingResolver(inner, CacheOptions{})
+		leaderCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() { _, _ = r.ResolveModule(leaderCtx, source) }()
+		require.Eventually(t, func() bool { return r.Stats().Misses == 1 }, time.Second, time.Millisecond)
+		waiter := make(chan *types.Module)
+		go func() {
+			m, err := r.ResolveModule(ctx, source)
+			assert.NoError(t, err)
+			waiter <- m
+		}()
+		require.Eventually(t, func() bool { return r.Stats().Shared == 1 }, time.Second, time.Millisecond)
+
+		cancel()
+		close(inner.release)
+		assert.Equal(t, fargate, <-waiter)
+		assert.Equal(t, 1, inner.Calls("module"))
+	})
+
+	t.Run("expires results after the ttl", func(t *testing.T) {
+		inner := &countingResolver{}
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		r := NewCachingResolver(inner, CacheOptions{TTL: time.Minute})
+		r.now = func() time.Time { return now }
+		ct := types.ConnectionTarget{BlockName: "network"}
+
+		_, _ = r.ResolveBlock(ctx, ct)
+		now = now.Add(30 * time.Second)
+		_, _ = r.ResolveBlock(ctx, ct)
+		assert.Equal(t, 1, inner.Calls("block"))
+		now = now.Add(time.Minute)
+		_, _ = r.ResolveBlock(ctx, ct)
+		assert.Equal(t, 2, inner.Calls("block"))
+	})
+
+	t.Run("negative caching", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			negativeTTL time.Duration
+			wantCalls   int
+		}{
+			{name: "disabled", negativeTTL: 0, wantCalls: 3},
+			{name: "enabled", negativeTTL: time.Minute, wantCalls: 1},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				inner := &countingResolver{}
+				r := NewCachingResolver(inner, CacheOptions{
+					NegativeTTL: test.negativeTTL,
+					IsNotFound:  func(err error) bool { return errors.Is(err, errBlockNotFound) },
+				})
+				for i := 0; i < 3; i++ {
+					m, _, err := r.ResolveModuleVersion(ctx, missingSource, "latest")
+					assert.NoError(t, err)
+					assert.Nil(t, m)
+					_, err = r.ResolveBlock(ctx, types.ConnectionTarget{BlockName: "missing"})
+					assert.ErrorIs(t, err, errBlockNotFound)
+					_, err = r.ResolveBlock(ctx, types.ConnectionTarget{BlockName: "flaky"})
+					assert.Error(t, err)
+				}
+				assert.Equal(t, test.wantCalls, inner.Calls("module-version"))
+				// 3 lookups of the flaky block are never cached
+				assert.Equal(t, test.wantCalls+3, inner.Calls("block"))
+			})
+		}
+
+		t.Run("missing resources by default", func(t *testing.T) {
+			inner := &countingResolver{}
+			r := NewCachingResolver(inner, CacheOptions{NegativeTTL: time.Minute})
+			for i := 0; i < 3; i++ {
+				_, err := r.ResolveBlock(ctx, types.ConnectionTarget{BlockName: "deleted"})
+				assert.True(t, IsMissingResource(err))
+				_, err = r.ResolveBlock(ctx, types.ConnectionTarget{BlockName: "flaky"})
+				assert.Error(t, err)
+			}
+			assert.Equal(t, 1+3, inner.Calls("block"))
+		})
+	})
+}
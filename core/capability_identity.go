@@ -1,6 +1,11 @@
 package core
 
-import "gopkg.in/nullstone-io/go-api-client.v0/types"
+import (
+	"fmt"
+	"path"
+
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
 
 type CapabilityIdentities []CapabilityIdentity
 
@@ -48,3 +53,30 @@ func (i CapabilityIdentity) Match(other CapabilityIdentity) bool {
 	}
 	return true
 }
+
+// AssignCapabilityNames returns the names of a list of capabilities, generating a name for each capability without one
+// Generated names are deterministic so that name-less capabilities in IaC and in Nullstone receive the same names
+// A capability is named after its module (e.g. nullstone/aws-s3-cdn => aws-s3-cdn)
+// If that name is already taken, a suffix is added based on declaration order (e.g. aws-s3-cdn-2)
+// Capabilities without a module source are left without a name
+func AssignCapabilityNames(names []string, moduleSources []string) []string {
+	result := make([]string, len(names))
+	taken := map[string]bool{}
+	for _, name := range names {
+		if name != "" {
+			taken[name] = true
+		}
+	}
+	for i, name := range names {
+		if name == "" && moduleSources[i] != "" {
+			base := path.Base(moduleSources[i])
+			name = base
+			for n := 2; taken[name]; n++ {
+				name = fmt.Sprintf("%s-%d", base, n)
+			}
+			taken[name] = true
+		}
+		result[i] = name
+	}
+	return result
+}
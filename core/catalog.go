@@ -0,0 +1,196 @@
+package core
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+	"gopkg.in/yaml.v3"
+)
+
+// CatalogVersion is the version of the catalog snapshot format
+const CatalogVersion = 1
+
+// Catalog is a snapshot of the Nullstone objects needed to process IaC files without access to the Nullstone API
+// See SnapshotResolver
+type Catalog struct {
+	Version int `json:"version"`
+	// Modules contains each module with its versions; each version must include its manifest
+	Modules []types.Module      `json:"modules,omitempty"`
+	Stacks  []types.Stack       `json:"stacks,omitempty"`
+	Envs    []types.Environment `json:"envs,omitempty"`
+	Blocks  []types.Block       `json:"blocks,omitempty"`
+	// WorkspaceModuleConfigs contains the module of existing workspaces
+	WorkspaceModuleConfigs []CatalogWorkspaceModuleConfig `json:"workspaceModuleConfigs,omitempty"`
+	// Channels contains the integration channels keyed by tool (e.g. slack)
+	Channels map[string][]map[string]any `json:"channels,omitempty"`
+}
+
+type CatalogWorkspaceModuleConfig struct {
+	StackId          int64  `json:"stackId"`
+	BlockId          int64  `json:"blockId"`
+	EnvId            int64  `json:"envId"`
+	Module           string `json:"module"`
+	ModuleConstraint string `json:"moduleConstraint"`
+}
+
+func NewCatalog() *Catalog {
+	return &Catalog{Version: CatalogVersion}
+}
+
+// ParseCatalog reads a catalog snapshot in JSON or YAML format
+// YAML keys are the same as the JSON keys (e.g. `orgName`)
+func ParseCatalog(r io.Reader) (*Catalog, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML, convert to JSON so that the api types are decoded with their json tags
+	var doc any
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("invalid catalog: %w", err)
+	}
+	converted, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid catalog: %w", err)
+	}
+	c := &Catalog{}
+	if err := json.Unmarshal(converted, c); err != nil {
+		return nil, fmt.Errorf("invalid catalog: %w", err)
+	}
+	if c.Version > CatalogVersion {
+		return nil, fmt.Errorf("unsupported catalog version %d, upgrade to read this catalog", c.Version)
+	}
+	return c, nil
+}
+
+func ReadCatalogFile(filename string) (*Catalog, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseCatalog(f)
+}
+
+// Write renders the catalog as indented JSON
+func (c *Catalog) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
+
+func (c *Catalog) WriteFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := c.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Sort orders the catalog so that snapshots of the same objects are identical
+func (c *Catalog) Sort() {
+	slices.SortFunc(c.Modules, func(a, b types.Module) int {
+		return cmp.Or(cmp.Compare(a.OrgName, b.OrgName), cmp.Compare(a.Name, b.Name))
+	})
+	for _, m := range c.Modules {
+		slices.SortFunc(m.Versions, func(a, b types.ModuleVersion) int {
+			va, aErr := ParseVersion(a.Version)
+			vb, bErr := ParseVersion(b.Version)
+			if aErr != nil || bErr != nil {
+				return cmp.Compare(a.Version, b.Version)
+			}
+			return va.Compare(vb)
+		})
+	}
+	slices.SortFunc(c.Stacks, func(a, b types.Stack) int { return cmp.Compare(a.Id, b.Id) })
+	slices.SortFunc(c.Envs, func(a, b types.Environment) int { return cmp.Compare(a.Id, b.Id) })
+	slices.SortFunc(c.Blocks, func(a, b types.Block) int { return cmp.Compare(a.Id, b.Id) })
+	slices.SortFunc(c.WorkspaceModuleConfigs, func(a, b CatalogWorkspaceModuleConfig) int {
+		return cmp.Or(cmp.Compare(a.StackId, b.StackId), cmp.Compare(a.BlockId, b.BlockId), cmp.Compare(a.EnvId, b.EnvId))
+	})
+}
+
+func (c *Catalog) FindModule(orgName, moduleName string) *types.Module {
+	for i, cur := range c.Modules {
+		if cur.OrgName == orgName && cur.Name == moduleName {
+			return &c.Modules[i]
+		}
+	}
+	return nil
+}
+
+// AddModule adds a module to the catalog
+// If the module already exists, versions that are not in the catalog yet are added
+func (c *Catalog) AddModule(m types.Module) {
+	existing := c.FindModule(m.OrgName, m.Name)
+	if existing == nil {
+		// Versions are replaced by AddModuleVersion, copy them to avoid modifying the caller's module
+		m.Versions = slices.Clone(m.Versions)
+		c.Modules = append(c.Modules, m)
+		return
+	}
+	for _, mv := range m.Versions {
+		c.AddModuleVersion(m.OrgName, m.Name, mv)
+	}
+}
+
+// AddModuleVersion adds or replaces a version of a module that is in the catalog
+// A version returned from the API by itself includes its manifest, so it replaces the version listed with the module
+func (c *Catalog) AddModuleVersion(orgName, moduleName string, mv types.ModuleVersion) {
+	m := c.FindModule(orgName, moduleName)
+	if m == nil {
+		return
+	}
+	for i, cur := range m.Versions {
+		if cur.Version == mv.Version {
+			m.Versions[i] = mv
+			return
+		}
+	}
+	m.Versions = append(m.Versions, mv)
+}
+
+func (c *Catalog) AddStack(stack types.Stack) {
+	for _, cur := range c.Stacks {
+		if cur.Id == stack.Id {
+			return
+		}
+	}
+	c.Stacks = append(c.Stacks, stack)
+}
+
+func (c *Catalog) AddEnv(env types.Environment) {
+	for _, cur := range c.Envs {
+		if cur.Id == env.Id {
+			return
+		}
+	}
+	c.Envs = append(c.Envs, env)
+}
+
+func (c *Catalog) AddBlock(block types.Block) {
+	for _, cur := range c.Blocks {
+		if cur.Id == block.Id {
+			return
+		}
+	}
+	c.Blocks = append(c.Blocks, block)
+}
+
+func (c *Catalog) AddWorkspaceModuleConfig(wmc CatalogWorkspaceModuleConfig) {
+	for _, cur := range c.WorkspaceModuleConfigs {
+		if cur.StackId == wmc.StackId && cur.BlockId == wmc.BlockId && cur.EnvId == wmc.EnvId {
+			return
+		}
+	}
+	c.WorkspaceModuleConfigs = append(c.WorkspaceModuleConfigs, wmc)
+}
@@ -9,14 +9,19 @@ type ChangeApplier interface {
 }
 
 type WorkspaceConfigUpdater interface {
-	UpdateSchema(moduleSource, moduleConstraint string, moduleVersion *types.ModuleVersion)
-	UpdateVariableValue(name string, value any)
+	// UpdateSchema syncs variables and connections to the module version's manifest
+	// renamedVariables carries values from variables the module removed to the variables that replace them (old => new)
+	// This returns a finding for each value that was dropped or renamed because the module no longer has the variable or connection
+	UpdateSchema(moduleSource, moduleConstraint string, moduleVersion *types.ModuleVersion, renamedVariables map[string]string) SchemaFindings
+	// UpdateVariableValue and AddOrUpdateEnvVariable return an error if a template in the value fails to render
+	UpdateVariableValue(name string, value any) error
 	UpdateConnectionTarget(name string, desired, effective types.ConnectionTarget)
-	AddOrUpdateEnvVariable(name string, value string, sensitive bool)
+	AddOrUpdateEnvVariable(name string, value string, sensitive bool) error
 	RemoveEnvVariablesNotIn(envVariables map[string]string)
 	GetCapabilityUpdater(identity CapabilityIdentity) CapabilityConfigUpdater
 	AddCapability(id int64, name string) CapabilityConfigUpdater
 	RemoveCapabilitiesNotIn(identities CapabilityIdentities)
+	RemoveCapability(identity CapabilityIdentity)
 	UpdateDomainName(domainNameTemplate *string)
 	UpdateSubdomainName(domainNameTemplate, subdomainNameTemplate *string, reservation *types.SubdomainReservation)
 	// UpdateDataClassification sets the workspace's data-classification level
@@ -24,6 +29,12 @@ type WorkspaceConfigUpdater interface {
 	UpdateDataClassification(level types.ClassificationLevel)
 }
 
+// TemplateContextUpdater is implemented by a WorkspaceConfigUpdater that renders templates in vars and environment values
+// ApplyChangesTo uses this to provide the block, env, and repository to templates
+type TemplateContextUpdater interface {
+	WithTemplateContext(block types.Block, env types.Environment, repoName, repoUrl string) WorkspaceConfigUpdater
+}
+
 type ExtraWorkspaceConfigInput struct {
 	DnsName    *string
 	DomainName *string
@@ -31,8 +42,11 @@ type ExtraWorkspaceConfigInput struct {
 }
 
 type CapabilityConfigUpdater interface {
-	UpdateSchema(moduleSource, moduleConstraint string, moduleVersion *types.ModuleVersion)
-	UpdateVariableValue(name string, value any)
+	// UpdateSchema syncs variables and connections to the module version's manifest
+	// renamedVariables carries values from variables the module removed to the variables that replace them (old => new)
+	// This returns a finding for each value that was dropped or renamed because the module no longer has the variable or connection
+	UpdateSchema(moduleSource, moduleConstraint string, moduleVersion *types.ModuleVersion, renamedVariables map[string]string) SchemaFindings
+	UpdateVariableValue(name string, value any) error
 	UpdateConnectionTarget(name string, desired, effective types.ConnectionTarget)
 	UpdateNamespace(namespace *string)
 }
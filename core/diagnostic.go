@@ -0,0 +1,137 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/BSick7/go-api/errors"
+)
+
+var (
+	_ error = Diagnostic{}
+)
+
+// DocsBaseUrl is prepended to a diagnostic's DocsSlug to build a link to its documentation
+var DocsBaseUrl = "https://docs.nullstone.io/reference/diagnostics/"
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Stage is the step of processing IaC files that reported a diagnostic
+type Stage string
+
+const (
+	StageInitialize Stage = "initialize"
+	StageNormalize  Stage = "normalize"
+	StageResolve    Stage = "resolve"
+	StageValidate   Stage = "validate"
+)
+
+// Diagnostic is a problem found in an IaC file
+// Each stage reports its own error type (e.g. ValidateError); all of them convert to a Diagnostic
+type Diagnostic struct {
+	// Code is a stable identifier for the kind of problem (e.g. NS-CONN-003)
+	// This is used to filter and suppress diagnostics
+	Code              string            `json:"code"`
+	Severity          Severity          `json:"severity"`
+	Stage             Stage             `json:"stage"`
+	IacContext        IacContext        `json:"iacContext"`
+	ObjectPathContext ObjectPathContext `json:"objectPathContext"`
+	Message           string            `json:"message"`
+	// Hint describes how to fix the problem
+	Hint string `json:"hint,omitempty"`
+	// DocsSlug refers to the documentation page for Code
+	DocsSlug string `json:"docsSlug,omitempty"`
+	// Suggestion is a yaml snippet to add to or replace the configuration at ObjectPathContext
+	Suggestion string `json:"suggestion,omitempty"`
+
+	SourcePosition
+}
+
+func newDiagnostic(code string, stage Stage, ic IacContext, pc ObjectPathContext, message string, pos SourcePosition) Diagnostic {
+	info := lookupDiagnosticCode(code)
+	return Diagnostic{
+		Code:              code,
+		Severity:          info.Severity,
+		Stage:             stage,
+		IacContext:        ic,
+		ObjectPathContext: pc,
+		Message:           message,
+		Hint:              info.Hint,
+		DocsSlug:          info.DocsSlug,
+		SourcePosition:    pos,
+	}
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s => %s", d.IacContext.Context(d.ObjectPathContext), d.Message)
+}
+
+func (d Diagnostic) ToValidationError() errors.ValidationError {
+	return errors.ValidationError{
+		Context: d.IacContext.Context(d.ObjectPathContext),
+		Message: d.Message,
+	}
+}
+
+// DocsUrl is the link to the documentation for the diagnostic's code
+func (d Diagnostic) DocsUrl() string {
+	if d.DocsSlug == "" {
+		return ""
+	}
+	return DocsBaseUrl + d.DocsSlug
+}
+
+type Diagnostics []Diagnostic
+
+func (s Diagnostics) ToValidationErrors() errors.ValidationErrors {
+	if len(s) == 0 {
+		return nil
+	}
+	ve := errors.ValidationErrors{}
+	for _, d := range s {
+		ve = append(ve, d.ToValidationError())
+	}
+	return ve
+}
+
+// HasErrors returns true if any diagnostic has error severity
+func (s Diagnostics) HasErrors() bool {
+	return slices.ContainsFunc(s, func(d Diagnostic) bool { return d.Severity == SeverityError })
+}
+
+// Filter returns the diagnostics that match fn
+func (s Diagnostics) Filter(fn func(d Diagnostic) bool) Diagnostics {
+	var result Diagnostics
+	for _, d := range s {
+		if fn(d) {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// Suppress removes diagnostics with any of the input codes
+func (s Diagnostics) Suppress(codes ...string) Diagnostics {
+	return s.Filter(func(d Diagnostic) bool { return !slices.Contains(codes, d.Code) })
+}
+
+type diagnosable interface {
+	ToDiagnostic() Diagnostic
+}
+
+func toDiagnostics[T diagnosable](errs []T) Diagnostics {
+	if len(errs) == 0 {
+		return nil
+	}
+	result := make(Diagnostics, 0, len(errs))
+	for _, err := range errs {
+		result = append(result, err.ToDiagnostic())
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+package core
+
+import "strings"
+
+// Diagnostic codes are stable; never renumber or reuse a code
+const (
+	CodeRequiredModule                 = "NS-MOD-001"
+	CodeInvalidModuleFormat            = "NS-MOD-002"
+	CodeModuleVersionLookupFailed      = "NS-MOD-003"
+	CodeMissingModule                  = "NS-MOD-004"
+	CodeInvalidModuleContract          = "NS-MOD-005"
+	CodeMissingModuleVersion           = "NS-MOD-006"
+	CodeInheritModuleLookupFailed      = "NS-MOD-007"
+	CodeModuleLookupFailed             = "NS-MOD-008"
+	CodeDefaultModuleVersion           = "NS-MOD-009"
+	CodeInvalidModuleVersionConstraint = "NS-MOD-010"
+	CodeNoMatchingModuleVersion        = "NS-MOD-011"
+	CodeMissingRequiredConnection      = "NS-CONN-001"
+	CodeConnectionDoesNotExist         = "NS-CONN-002"
+	CodeMismatchedConnectionContract   = "NS-CONN-003"
+	CodeMissingConnectionTarget        = "NS-CONN-004"
+	CodeLookupConnectionTarget         = "NS-CONN-005"
+	CodeLookupWorkspaceModule          = "NS-CONN-006"
+	CodeResolvedBlockMissingModule     = "NS-CONN-007"
+	CodeMissingConnectionBlock         = "NS-CONN-008"
+	CodeInvalidConnectionContract      = "NS-CONN-009"
+	CodeDependencyCycle                = "NS-CONN-010"
+	CodeInvalidConnection              = "NS-CONN-011"
+	CodeVariableDoesNotExist           = "NS-VAR-001"
+	CodeVariableIncompatibleType       = "NS-VAR-002"
+	CodeMissingRequiredVariable        = "NS-VAR-003"
+	CodeSensitiveVariablePlaintext     = "NS-VAR-004"
+	CodeVariableMissingAttribute       = "NS-VAR-005"
+	CodeVariableTupleLength            = "NS-VAR-006"
+	CodeInvalidTemplate                = "NS-TMPL-001"
+	CodeMissingCapabilityName          = "NS-CAP-001"
+	CodeUnsupportedAppCategory         = "NS-CAP-002"
+	CodeUnnamedCapability              = "NS-CAP-003"
+	CodeEnvVariableKeyStartsWithNumber = "NS-ENV-001"
+	CodeEnvVariableKeyInvalidChars     = "NS-ENV-002"
+	CodeEnvVariableValueAndSecret      = "NS-ENV-003"
+	CodeInvalidSecretReference         = "NS-SECRET-001"
+	CodeSecretReferenceResolveFailed   = "NS-SECRET-002"
+	CodeMissingSubdomainTemplate       = "NS-DNS-001"
+	CodeInvalidRandomSubdomainTemplate = "NS-DNS-002"
+	CodeFailedSubdomainReservation     = "NS-DNS-003"
+	CodeDeprecatedDnsName              = "NS-DNS-004"
+	CodeModuleLockMismatch             = "NS-LOCK-001"
+	CodeModuleDigestMismatch           = "NS-LOCK-002"
+	CodeInvalidDataClassification      = "NS-META-001"
+	CodeMissingDataClassification      = "NS-META-002"
+	CodeInvalidEventAction             = "NS-EVENT-001"
+	CodeInvalidEventStatus             = "NS-EVENT-002"
+	CodeInvalidEventTarget             = "NS-EVENT-003"
+	CodeToolChannelLookupFailed        = "NS-EVENT-004"
+	CodeSlackChannelNotFound           = "NS-EVENT-005"
+	CodeEventBlockNotFound             = "NS-EVENT-006"
+	CodeInvalidWebhookUrl              = "NS-EVENT-007"
+)
+
+type diagnosticCodeInfo struct {
+	Severity Severity
+	Hint     string
+	DocsSlug string
+}
+
+var diagnosticCodes = map[string]diagnosticCodeInfo{
+	CodeRequiredModule:                 {Hint: "Add `module: <org>/<module>` to the block"},
+	CodeInvalidModuleFormat:            {Hint: "Use the format `<module-org>/<module-name>` (e.g. nullstone/aws-fargate-service)"},
+	CodeModuleVersionLookupFailed:      {Hint: "Retry; if this continues, check that the module registry is reachable"},
+	CodeMissingModule:                  {Hint: "Check the module name for typos and that your organization has access to it"},
+	CodeInvalidModuleContract:          {Hint: "Use a module that matches the block type (e.g. an app module under `apps`)"},
+	CodeMissingModuleVersion:           {Hint: "Use `latest` or a version that has been published for the module"},
+	CodeInheritModuleLookupFailed:      {Hint: "Add `module` to the block in the overrides file or retry"},
+	CodeModuleLookupFailed:             {Hint: "Retry; if this continues, check that the module registry is reachable"},
+	CodeDefaultModuleVersion:           {Severity: SeverityWarning, Hint: "Pin `module_version` so that new module versions are adopted deliberately"},
+	CodeInvalidModuleVersionConstraint: {Hint: "Use `latest`, an exact version, or a constraint such as `~> 0.12` or `>= 1.2, < 2.0`"},
+	CodeNoMatchingModuleVersion:        {Hint: "Loosen the constraint or publish a version of the module that satisfies it"},
+	CodeMissingRequiredConnection:      {Hint: "Add the connection under `connections` or use a module version where it is optional"},
+	CodeConnectionDoesNotExist:         {Hint: "Remove the connection or check its name against the module's connections"},
+	CodeMismatchedConnectionContract:   {Hint: "Connect to a block whose module satisfies the connection's contract"},
+	CodeMissingConnectionTarget:        {Hint: "Check the block name for typos or declare the block in IaC"},
+	CodeLookupConnectionTarget:         {Hint: "Retry; if this continues, check that the target stack and environment exist"},
+	CodeLookupWorkspaceModule:          {Hint: "Retry; if this continues, check that the connected block has been launched"},
+	CodeResolvedBlockMissingModule:     {Hint: "Configure a module on the connected block"},
+	CodeMissingConnectionBlock:         {Hint: "Set `block_name` on the connection"},
+	CodeInvalidConnectionContract:      {Hint: "The module declares an invalid contract; contact the module author"},
+	CodeDependencyCycle:                {Hint: "Remove one of the connections in the cycle"},
+	CodeInvalidConnection:              {Hint: "Use the format [[stack.]env.]block or a mapping with `block_name`"},
+	CodeVariableDoesNotExist:           {Hint: "Remove the variable or check its name against the module's variables"},
+	CodeVariableIncompatibleType:       {Hint: "Change the value to match the variable's type"},
+	CodeMissingRequiredVariable:        {Hint: "Add a value for the variable under `vars`"},
+	CodeSensitiveVariablePlaintext:     {Hint: "Remove the value from the IaC file and set it through Nullstone"},
+	CodeVariableMissingAttribute:       {Hint: "Add the attribute to the value"},
+	CodeVariableTupleLength:            {Hint: "Change the number of elements to match the variable's type"},
+	CodeInvalidTemplate:                {Hint: "Use one of the supported template variables (e.g. {{ NULLSTONE_ENV }}) and functions"},
+	CodeMissingCapabilityName:          {Hint: "Add `name` to the capability"},
+	CodeUnsupportedAppCategory:         {Hint: "Use a capability module that supports the app's category"},
+	CodeUnnamedCapability:              {Severity: SeverityWarning, Hint: "Add `name` to the capability so that it keeps its identity when its connections change"},
+	CodeEnvVariableKeyStartsWithNumber: {Hint: "Rename the environment variable so that it starts with a letter or underscore"},
+	CodeEnvVariableKeyInvalidChars:     {Hint: "Rename the environment variable using only letters, numbers, and underscores"},
+	CodeEnvVariableValueAndSecret:      {Hint: "Remove either `value` or `secret`"},
+	CodeInvalidSecretReference:         {Hint: "Use the format <scheme>://<path>[#<key>] (e.g. aws-sm://prod/db#password)"},
+	CodeSecretReferenceResolveFailed:   {Hint: "Check that the secret exists and that Nullstone has access to it"},
+	CodeMissingSubdomainTemplate:       {Hint: "Add `dns.template` to the subdomain"},
+	CodeInvalidRandomSubdomainTemplate: {Hint: "Use `{{ random() }}` as the entire template"},
+	CodeFailedSubdomainReservation:     {Hint: "Choose a different subdomain or retry"},
+	CodeDeprecatedDnsName:              {Severity: SeverityWarning, Hint: "Replace `dns_name` with `dns.template`"},
+	CodeModuleLockMismatch:             {Hint: "Regenerate the lock file or upgrade the block in the lock file"},
+	CodeModuleDigestMismatch:           {Hint: "Check that the module version was not republished, then regenerate the lock file"},
+	CodeInvalidDataClassification:      {Hint: "Use one of the listed data classification levels"},
+	CodeMissingDataClassification:      {Severity: SeverityWarning, Hint: "Add `metadata.dataclassification` to the datastore"},
+	CodeInvalidEventAction:             {Hint: "Use one of the supported event actions"},
+	CodeInvalidEventStatus:             {Hint: "Use one of the supported event statuses"},
+	CodeInvalidEventTarget:             {Hint: "Use one of the supported event targets (e.g. slack or webhook)"},
+	CodeToolChannelLookupFailed:        {Hint: "Check that the integration is connected to your organization"},
+	CodeSlackChannelNotFound:           {Hint: "Check the channel name or invite the Nullstone app to the channel"},
+	CodeEventBlockNotFound:             {Hint: "Check the block name for typos or create the block first"},
+	CodeInvalidWebhookUrl:              {Hint: "Set `url` to an absolute URL (e.g. https://example.com/hook)"},
+}
+
+// lookupDiagnosticCode returns the severity, hint, and docs slug for a code
+// Unknown codes are errors without a hint
+func lookupDiagnosticCode(code string) diagnosticCodeInfo {
+	info := diagnosticCodes[code]
+	if info.Severity == "" {
+		info.Severity = SeverityError
+	}
+	if info.DocsSlug == "" && code != "" {
+		info.DocsSlug = strings.ToLower(code)
+	}
+	return info
+}
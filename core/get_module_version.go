@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"slices"
 	"strings"
 
 	"gopkg.in/nullstone-io/go-api-client.v0/artifacts"
@@ -19,6 +20,12 @@ func GetModuleVersion(ctx context.Context, resolver ModuleVersionResolver, pc Ob
 	if err != nil {
 		return nil, nil, InvalidResolveModuleFormatError(pc, source)
 	}
+	isConstraint := IsVersionConstraint(version)
+	if isConstraint {
+		if _, err := ParseVersionConstraint(version); err != nil {
+			return nil, nil, InvalidModuleVersionConstraintError(pc, version, err)
+		}
+	}
 	m, mv, err := resolver.ResolveModuleVersion(ctx, *ms, version)
 	if err != nil {
 		return nil, nil, ModuleVersionLookupFailedError(pc, source, version, err)
@@ -39,8 +46,27 @@ func GetModuleVersion(ctx context.Context, resolver ModuleVersionResolver, pc Ob
 	}
 
 	if mv == nil {
+		if isConstraint {
+			return nil, nil, NoMatchingModuleVersionError(pc, ms.String(), version, moduleVersionNames(m))
+		}
 		return nil, nil, MissingModuleVersionError(pc, ms.String(), version)
 	}
 
 	return m, mv, nil
 }
+
+// moduleVersionNames returns the versions of a module from highest to lowest
+func moduleVersionNames(m *types.Module) []string {
+	versions := make([]Version, 0, len(m.Versions))
+	for _, cur := range m.Versions {
+		if v, err := ParseVersion(cur.Version); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	slices.SortFunc(versions, func(a, b Version) int { return b.Compare(a) })
+	result := make([]string, 0, len(versions))
+	for _, v := range versions {
+		result = append(result, v.String())
+	}
+	return result
+}
@@ -11,7 +11,10 @@ type IacContext struct {
 	RepoName    string `json:"repoName"`
 	Filename    string `json:"filename"`
 	IsOverrides bool   `json:"isOverrides"`
-	Version     string `json:"version"`
+	// Version is the IaC version that the file was written against (0.1 if the file omits it)
+	Version string `json:"version"`
+	// Migrated is true if the file was upgraded from Version to the current IaC version when it was parsed
+	Migrated bool `json:"migrated"`
 }
 
 func (c IacContext) Context(sub ObjectPathContext) string {
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"context"
+
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+var (
+	_ NormalizeResolver = IacNormalizeResolver{}
+)
+
+// IacNormalizeResolver resolves connections to blocks that are declared in IaC, but have not been synced yet
+// If the target block does not exist and Finder finds it in IaC, the connection target is used as written
+// Finder only matches targets that name their stack (see MultiIacFinder)
+// Unsynced blocks in the current stack are backfilled into the resolver instead (e.g. find.ResourceResolver.BackfillMissingBlocks)
+type IacNormalizeResolver struct {
+	NormalizeResolver
+	Finder IacFinder
+}
+
+func (r IacNormalizeResolver) ResolveConnection(ctx context.Context, ct types.ConnectionTarget) (types.ConnectionTarget, error) {
+	result, err := r.NormalizeResolver.ResolveConnection(ctx, ct)
+	if err == nil || !IsMissingResource(err) || r.Finder == nil {
+		return result, err
+	}
+	if r.Finder.FindBlockModuleInIac(ctx, ct) == nil {
+		return result, err
+	}
+	return ct, nil
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/BSick7/go-api/errors"
 	"gopkg.in/nullstone-io/go-api-client.v0/types"
@@ -15,17 +16,23 @@ type InitializeError struct {
 	IacContext        IacContext        `json:"iacContext"`
 	ObjectPathContext ObjectPathContext `json:"objectPathContext"`
 	ErrorMessage      string            `json:"errorMessage"`
+	// Code is the stable diagnostic code for the error (e.g. NS-CONN-003)
+	Code string `json:"code,omitempty"`
+
+	// SourcePosition is populated from the IaC file once IacContext is attached to the error
+	SourcePosition
 }
 
 func (e InitializeError) Error() string {
-	return fmt.Sprintf("%s => %s", e.IacContext.Context(e.ObjectPathContext), e.ErrorMessage)
+	return e.ToDiagnostic().Error()
 }
 
 func (e InitializeError) ToValidationError() errors.ValidationError {
-	return errors.ValidationError{
-		Context: e.IacContext.Context(e.ObjectPathContext),
-		Message: e.ErrorMessage,
-	}
+	return e.ToDiagnostic().ToValidationError()
+}
+
+func (e InitializeError) ToDiagnostic() Diagnostic {
+	return newDiagnostic(e.Code, StageInitialize, e.IacContext, e.ObjectPathContext, e.ErrorMessage, e.SourcePosition)
 }
 
 type InitializeErrors []InitializeError
@@ -41,8 +48,13 @@ func (s InitializeErrors) ToValidationErrors() errors.ValidationErrors {
 	return ve
 }
 
+func (s InitializeErrors) ToDiagnostics() Diagnostics {
+	return toDiagnostics(s)
+}
+
 func RequiredModuleError(pc ObjectPathContext) *InitializeError {
 	return &InitializeError{
+		Code:              CodeRequiredModule,
 		ObjectPathContext: pc,
 		ErrorMessage:      "Module is required",
 	}
@@ -50,6 +62,7 @@ func RequiredModuleError(pc ObjectPathContext) *InitializeError {
 
 func InvalidResolveModuleFormatError(pc ObjectPathContext, moduleSource string) *InitializeError {
 	return &InitializeError{
+		Code:              CodeInvalidModuleFormat,
 		ObjectPathContext: pc.SubField("module"),
 		ErrorMessage:      fmt.Sprintf("Invalid module format (%s) - must be in the format \"<module-org>/<module-name>\"", moduleSource),
 	}
@@ -57,6 +70,7 @@ func InvalidResolveModuleFormatError(pc ObjectPathContext, moduleSource string)
 
 func ModuleVersionLookupFailedError(pc ObjectPathContext, source, version string, err error) *InitializeError {
 	return &InitializeError{
+		Code:              CodeModuleVersionLookupFailed,
 		ObjectPathContext: pc.SubField("module_version"),
 		ErrorMessage:      fmt.Sprintf("Module version (%s@%s) lookup failed: %s", source, version, err),
 	}
@@ -64,6 +78,7 @@ func ModuleVersionLookupFailedError(pc ObjectPathContext, source, version string
 
 func MissingModuleError(pc ObjectPathContext, moduleSource string) *InitializeError {
 	return &InitializeError{
+		Code:              CodeMissingModule,
 		ObjectPathContext: pc.SubField("module"),
 		ErrorMessage:      fmt.Sprintf("Module (%s) does not exist", moduleSource),
 	}
@@ -71,6 +86,7 @@ func MissingModuleError(pc ObjectPathContext, moduleSource string) *InitializeEr
 
 func InvalidModuleContractError(pc ObjectPathContext, moduleSource string, want, got types.ModuleContractName) *InitializeError {
 	return &InitializeError{
+		Code:              CodeInvalidModuleContract,
 		ObjectPathContext: pc.SubField("module"),
 		ErrorMessage:      fmt.Sprintf("Module (%s) must be %s module and match the contract (%s), it is defined as %s", moduleSource, want.Category, want, got),
 	}
@@ -78,13 +94,59 @@ func InvalidModuleContractError(pc ObjectPathContext, moduleSource string, want,
 
 func MissingModuleVersionError(pc ObjectPathContext, source, version string) *InitializeError {
 	return &InitializeError{
+		Code:              CodeMissingModuleVersion,
 		ObjectPathContext: pc.SubField("module_version"),
 		ErrorMessage:      fmt.Sprintf("Module version (%s@%s) does not exist", source, version),
 	}
 }
 
+func InvalidModuleVersionConstraintError(pc ObjectPathContext, constraint string, err error) *InitializeError {
+	return &InitializeError{
+		Code:              CodeInvalidModuleVersionConstraint,
+		ObjectPathContext: pc.SubField("module_version"),
+		ErrorMessage:      fmt.Sprintf("Module version constraint (%s) is invalid: %s", constraint, err),
+	}
+}
+
+func NoMatchingModuleVersionError(pc ObjectPathContext, source, constraint string, available []string) *InitializeError {
+	versions := "none"
+	if len(available) > 0 {
+		versions = strings.Join(available, ", ")
+	}
+	return &InitializeError{
+		Code:              CodeNoMatchingModuleVersion,
+		ObjectPathContext: pc.SubField("module_version"),
+		ErrorMessage:      fmt.Sprintf("Module (%s) has no version that satisfies %q, available versions: %s", source, constraint, versions),
+	}
+}
+
+func ModuleLockMismatchError(pc ObjectPathContext, source, constraint, lockedSource, lockedConstraint string) *InitializeError {
+	return &InitializeError{
+		Code:              CodeModuleLockMismatch,
+		ObjectPathContext: pc.SubField("module_version"),
+		ErrorMessage:      fmt.Sprintf("Module (%s@%s) does not match the lock file (%s@%s), the lock file must be updated", source, constraint, lockedSource, lockedConstraint),
+	}
+}
+
+func ModuleDigestMismatchError(pc ObjectPathContext, source, version string) *InitializeError {
+	return &InitializeError{
+		Code:              CodeModuleDigestMismatch,
+		ObjectPathContext: pc.SubField("module_version"),
+		ErrorMessage:      fmt.Sprintf("Module version (%s@%s) does not match the digest in the lock file", source, version),
+	}
+}
+
+func InheritModuleLookupFailedError(pc ObjectPathContext, err error) *InitializeError {
+	return &InitializeError{
+		Code:              CodeInheritModuleLookupFailed,
+		ObjectPathContext: pc,
+		ErrorMessage:      fmt.Sprintf("Failed to lookup the module from the existing workspace: %s", err),
+	}
+}
+
 func MissingRequiredConnectionError(pc ObjectPathContext, connName string) InitializeError {
 	return InitializeError{
+		Code:              CodeMissingRequiredConnection,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Connection (%s) is required", connName),
 	}
@@ -13,17 +13,23 @@ type NormalizeError struct {
 	IacContext        IacContext        `json:"iacContext"`
 	ObjectPathContext ObjectPathContext `json:"objectPathContext"`
 	ErrorMessage      string            `json:"errorMessage"`
+	// Code is the stable diagnostic code for the error (e.g. NS-CONN-003)
+	Code string `json:"code,omitempty"`
+
+	// SourcePosition is populated from the IaC file once IacContext is attached to the error
+	SourcePosition
 }
 
 func (e NormalizeError) Error() string {
-	return fmt.Sprintf("%s => %s", e.IacContext.Context(e.ObjectPathContext), e.ErrorMessage)
+	return e.ToDiagnostic().Error()
 }
 
 func (e NormalizeError) ToValidationError() errors.ValidationError {
-	return errors.ValidationError{
-		Context: e.IacContext.Context(e.ObjectPathContext),
-		Message: e.ErrorMessage,
-	}
+	return e.ToDiagnostic().ToValidationError()
+}
+
+func (e NormalizeError) ToDiagnostic() Diagnostic {
+	return newDiagnostic(e.Code, StageNormalize, e.IacContext, e.ObjectPathContext, e.ErrorMessage, e.SourcePosition)
 }
 
 type NormalizeErrors []NormalizeError
@@ -38,3 +44,23 @@ func (s NormalizeErrors) ToValidationErrors() errors.ValidationErrors {
 	}
 	return ve
 }
+
+func (s NormalizeErrors) ToDiagnostics() Diagnostics {
+	return toDiagnostics(s)
+}
+
+func InvalidConnectionError(pc ObjectPathContext, err error) *NormalizeError {
+	return &NormalizeError{
+		Code:              CodeInvalidConnection,
+		ObjectPathContext: pc,
+		ErrorMessage:      fmt.Sprintf("Connection is invalid, %s", err),
+	}
+}
+
+func EventBlockNotFoundError(pc ObjectPathContext, err error) NormalizeError {
+	return NormalizeError{
+		Code:              CodeEventBlockNotFound,
+		ObjectPathContext: pc,
+		ErrorMessage:      fmt.Sprintf("Block must exist to subscribe to event, but it failed to resolve: %s", err),
+	}
+}
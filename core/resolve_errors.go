@@ -14,17 +14,23 @@ type ResolveError struct {
 	IacContext        IacContext        `json:"iacContext"`
 	ObjectPathContext ObjectPathContext `json:"objectPathContext"`
 	ErrorMessage      string            `json:"errorMessage"`
+	// Code is the stable diagnostic code for the error (e.g. NS-CONN-003)
+	Code string `json:"code,omitempty"`
+
+	// SourcePosition is populated from the IaC file once IacContext is attached to the error
+	SourcePosition
 }
 
 func (e ResolveError) Error() string {
-	return fmt.Sprintf("%s => %s", e.IacContext.Context(e.ObjectPathContext), e.ErrorMessage)
+	return e.ToDiagnostic().Error()
 }
 
 func (e ResolveError) ToValidationError() errors.ValidationError {
-	return errors.ValidationError{
-		Context: e.IacContext.Context(e.ObjectPathContext),
-		Message: e.ErrorMessage,
-	}
+	return e.ToDiagnostic().ToValidationError()
+}
+
+func (e ResolveError) ToDiagnostic() Diagnostic {
+	return newDiagnostic(e.Code, StageResolve, e.IacContext, e.ObjectPathContext, e.ErrorMessage, e.SourcePosition)
 }
 
 type ResolveErrors []ResolveError
@@ -40,8 +46,13 @@ func (s ResolveErrors) ToValidationErrors() errors.ValidationErrors {
 	return ve
 }
 
+func (s ResolveErrors) ToDiagnostics() Diagnostics {
+	return toDiagnostics(s)
+}
+
 func MissingConnectionTargetError(pc ObjectPathContext, err error) *ResolveError {
 	return &ResolveError{
+		Code:              CodeMissingConnectionTarget,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Connection is invalid, %s", err),
 	}
@@ -49,6 +60,7 @@ func MissingConnectionTargetError(pc ObjectPathContext, err error) *ResolveError
 
 func LookupConnectionTargetFailedError(pc ObjectPathContext, err error) *ResolveError {
 	return &ResolveError{
+		Code:              CodeLookupConnectionTarget,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Failed to validate connection, error when looking up connection target: %s", err),
 	}
@@ -56,6 +68,7 @@ func LookupConnectionTargetFailedError(pc ObjectPathContext, err error) *Resolve
 
 func LookupWorkspaceModuleConfigError(pc ObjectPathContext, err error) *ResolveError {
 	return &ResolveError{
+		Code:              CodeLookupWorkspaceModule,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Failed to lookup workspace module config: %s", err),
 	}
@@ -63,6 +76,7 @@ func LookupWorkspaceModuleConfigError(pc ObjectPathContext, err error) *ResolveE
 
 func ResolvedBlockMissingModuleError(pc ObjectPathContext, stackName, blockName string) *ResolveError {
 	return &ResolveError{
+		Code:              CodeResolvedBlockMissingModule,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Module is required on block (%s/%s) that was resolved by a connection.", stackName, blockName),
 	}
@@ -70,6 +84,7 @@ func ResolvedBlockMissingModuleError(pc ObjectPathContext, stackName, blockName
 
 func InvalidModuleFormatError(pc ObjectPathContext, moduleSource string) *ResolveError {
 	return &ResolveError{
+		Code:              CodeInvalidModuleFormat,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Invalid module format (%s) - must be in the format \"<module-org>/<module-name>\"", moduleSource),
 	}
@@ -77,13 +92,23 @@ func InvalidModuleFormatError(pc ObjectPathContext, moduleSource string) *Resolv
 
 func ModuleLookupFailedError(pc ObjectPathContext, moduleSource string, err error) *ResolveError {
 	return &ResolveError{
+		Code:              CodeModuleLookupFailed,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Module (%s) lookup failed: %s", moduleSource, err),
 	}
 }
 
+func SecretReferenceResolveFailedError(pc ObjectPathContext, ref SecretReference, err error) ResolveError {
+	return ResolveError{
+		Code:              CodeSecretReferenceResolveFailed,
+		ObjectPathContext: pc,
+		ErrorMessage:      fmt.Sprintf("Unable to resolve secret reference (%s): %s", ref, err),
+	}
+}
+
 func ToolChannelLookupFailedError(pc ObjectPathContext, tool string, err error) ResolveError {
 	return ResolveError{
+		Code:              CodeToolChannelLookupFailed,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Failed to look up %s channels: %s", tool, err),
 	}
@@ -91,6 +116,7 @@ func ToolChannelLookupFailedError(pc ObjectPathContext, tool string, err error)
 
 func InvalidRandomSubdomainTemplateError(pc ObjectPathContext, template string) ResolveError {
 	return ResolveError{
+		Code:              CodeInvalidRandomSubdomainTemplate,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Invalid subdomain template %q: cannot have specify additional text when using `{{ random() }}`.", template),
 	}
@@ -98,7 +124,16 @@ func InvalidRandomSubdomainTemplateError(pc ObjectPathContext, template string)
 
 func FailedSubdomainReservationError(pc ObjectPathContext, requested string, err error) ResolveError {
 	return ResolveError{
+		Code:              CodeFailedSubdomainReservation,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Failed to reserve subdomain %q: %s", requested, err),
 	}
 }
+
+func SlackChannelNotFoundError(pc ObjectPathContext, channelName string) ResolveError {
+	return ResolveError{
+		Code:              CodeSlackChannelNotFound,
+		ObjectPathContext: pc,
+		ErrorMessage:      fmt.Sprintf("Slack channel %q could not be found in the connected workspace", channelName),
+	}
+}
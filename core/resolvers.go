@@ -18,6 +18,7 @@ type ResolveResolver interface {
 	ModuleVersionResolver
 	EventChannelResolver
 	SubdomainReserver
+	SecretReferenceResolver
 }
 
 type NormalizeResolver interface {
@@ -70,3 +71,9 @@ type ModuleVersionResolver interface {
 type EventChannelResolver interface {
 	ListChannels(ctx context.Context, tool string) ([]map[string]any, error)
 }
+
+type SecretReferenceResolver interface {
+	// ResolveSecretReference returns the value to store in a sensitive env variable for the secret reference
+	// Implementations may verify the secret exists or restrict the supported schemes
+	ResolveSecretReference(ctx context.Context, ref SecretReference) (string, error)
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+type SchemaFindingAction string
+
+const (
+	// SchemaFindingDropped means the value was discarded because the module no longer has the variable or connection
+	SchemaFindingDropped SchemaFindingAction = "dropped"
+	// SchemaFindingRenamed means the value was carried over to the variable that replaces it
+	SchemaFindingRenamed SchemaFindingAction = "renamed"
+)
+
+// SchemaFinding records a value set on a variable or connection that the module's manifest no longer contains
+type SchemaFinding struct {
+	ChangeType types.ChangeType `json:"changeType"`
+	// CapabilityName is empty for the variables and connections of a block
+	CapabilityName string              `json:"capabilityName,omitempty"`
+	Name           string              `json:"name"`
+	Action         SchemaFindingAction `json:"action"`
+	// RenamedTo is the name of the variable that received the value
+	RenamedTo string `json:"renamedTo,omitempty"`
+	// Value is the variable value or the desired connection target
+	// This is omitted for sensitive variables
+	Value any `json:"value,omitempty"`
+}
+
+type SchemaFindings []SchemaFinding
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	_ SecretReferenceResolver = LiteralSecretReferenceResolver{}
+
+	secretSchemeRegexp = regexp.MustCompile(`^[a-z][a-z0-9\-]*$`)
+)
+
+// SecretReference points at a secret stored outside the IaC repository
+// The format is <scheme>://<path>[#<key>] (e.g. aws-sm://prod/db#password)
+type SecretReference struct {
+	Scheme string `json:"scheme"`
+	Path   string `json:"path"`
+	Key    string `json:"key,omitempty"`
+}
+
+func ParseSecretReference(s string) (SecretReference, error) {
+	scheme, rest, ok := strings.Cut(s, "://")
+	if !ok {
+		return SecretReference{}, fmt.Errorf("secret reference must be in the form <scheme>://<path>[#<key>]")
+	}
+	if !secretSchemeRegexp.MatchString(scheme) {
+		return SecretReference{}, fmt.Errorf("secret reference scheme %q must contain only lowercase letters, numbers, and dashes", scheme)
+	}
+	path, key, hasKey := strings.Cut(rest, "#")
+	if path == "" {
+		return SecretReference{}, fmt.Errorf("secret reference is missing a path")
+	}
+	if hasKey && key == "" {
+		return SecretReference{}, fmt.Errorf("secret reference has an empty key after '#'")
+	}
+	return SecretReference{Scheme: scheme, Path: path, Key: key}, nil
+}
+
+func (r SecretReference) String() string {
+	s := r.Scheme + "://" + r.Path
+	if r.Key != "" {
+		s += "#" + r.Key
+	}
+	return s
+}
+
+// LiteralSecretReferenceResolver stores the reference itself as the sensitive value
+// The runner is responsible for fetching the secret at deploy time
+type LiteralSecretReferenceResolver struct{}
+
+func (LiteralSecretReferenceResolver) ResolveSecretReference(ctx context.Context, ref SecretReference) (string, error) {
+	return ref.String(), nil
+}
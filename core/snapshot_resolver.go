@@ -0,0 +1,239 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"gopkg.in/nullstone-io/go-api-client.v0/artifacts"
+	"gopkg.in/nullstone-io/go-api-client.v0/find"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+var (
+	_ InitializeResolver = &SnapshotResolver{}
+	_ NormalizeResolver  = &SnapshotResolver{}
+	_ ResolveResolver    = &SnapshotResolver{}
+	_ ConnectionResolver = &SnapshotResolver{}
+	_ error              = MissingSnapshotResourceError{}
+)
+
+// SnapshotResolver resolves modules, blocks, and connections from a Catalog instead of the Nullstone API
+// This allows IaC files to be validated offline (e.g. in a CI job without Nullstone credentials)
+type SnapshotResolver struct {
+	Catalog *Catalog
+	// CurStackId and CurEnvId refer to the stack and env that the IaC files are processed for
+	// A connection without a stack or env resolves to them
+	CurStackId              int64
+	CurEnvId                int64
+	SecretReferenceResolver SecretReferenceResolver
+
+	backfilled []types.Block
+}
+
+func NewSnapshotResolver(catalog *Catalog, stackId, envId int64) *SnapshotResolver {
+	return &SnapshotResolver{
+		Catalog:                 catalog,
+		CurStackId:              stackId,
+		CurEnvId:                envId,
+		SecretReferenceResolver: LiteralSecretReferenceResolver{},
+	}
+}
+
+// MissingSnapshotResourceError is returned when a stack, block, or env does not exist in the catalog
+type MissingSnapshotResourceError struct {
+	Kind string
+	Name string
+}
+
+func (e MissingSnapshotResourceError) Error() string {
+	return fmt.Sprintf("%s %s does not exist in the catalog snapshot", e.Kind, e.Name)
+}
+
+// IsMissingResource returns true if err means a stack, block, or env does not exist
+// This recognizes errors from the Nullstone API as well as from a SnapshotResolver
+func IsMissingResource(err error) bool {
+	return find.IsMissingResource(err) || errors.As(err, &MissingSnapshotResourceError{})
+}
+
+// BackfillMissingBlocks adds blocks that are declared in IaC files, but not in the catalog yet
+// This mirrors find.ResourceResolver so that connections to new blocks resolve before the blocks are created
+// The catalog is not modified
+func (s *SnapshotResolver) BackfillMissingBlocks(ctx context.Context, blocks []types.Block) {
+	s.backfilled = append(s.backfilled, blocks...)
+}
+
+func (s *SnapshotResolver) ResolveModule(ctx context.Context, source artifacts.ModuleSource) (*types.Module, error) {
+	m := s.Catalog.FindModule(source.OrgName, source.ModuleName)
+	if m == nil {
+		return nil, nil
+	}
+	result := *m
+	return &result, nil
+}
+
+func (s *SnapshotResolver) ResolveModuleVersion(ctx context.Context, source artifacts.ModuleSource, version string) (*types.Module, *types.ModuleVersion, error) {
+	m, _ := s.ResolveModule(ctx, source)
+	if m == nil {
+		return nil, nil, nil
+	}
+
+	if version == "latest" {
+		if m.LatestVersion != nil {
+			return m, m.LatestVersion, nil
+		}
+		// A hand-written catalog may omit latestVersion
+		version = ">= 0.0.0"
+	}
+	if IsVersionConstraint(version) {
+		constraint, err := ParseVersionConstraint(version)
+		if err != nil {
+			return m, nil, err
+		}
+		return m, constraint.HighestMatch(m.Versions), nil
+	}
+	for i, cur := range m.Versions {
+		if cur.Version == version {
+			return m, &m.Versions[i], nil
+		}
+	}
+	return m, nil, nil
+}
+
+func (s *SnapshotResolver) ResolveConnection(ctx context.Context, ct types.ConnectionTarget) (types.ConnectionTarget, error) {
+	stack, err := s.findStack(ct)
+	if err != nil {
+		return ct, err
+	}
+	block, err := s.findBlock(stack, ct)
+	if err != nil {
+		return ct, err
+	}
+	env, err := s.findEnv(stack, ct)
+	if err != nil {
+		return ct, err
+	}
+	return types.ConnectionTarget{
+		StackId:   stack.Id,
+		StackName: stack.Name,
+		BlockId:   block.Id,
+		BlockName: block.Name,
+		EnvId:     &env.Id,
+		EnvName:   env.Name,
+	}, nil
+}
+
+func (s *SnapshotResolver) ResolveBlock(ctx context.Context, ct types.ConnectionTarget) (types.Block, error) {
+	stack, err := s.findStack(ct)
+	if err != nil {
+		return types.Block{}, err
+	}
+	return s.findBlock(stack, ct)
+}
+
+func (s *SnapshotResolver) ResolveWorkspaceModuleConfig(ctx context.Context, ct types.ConnectionTarget) (WorkspaceModuleConfig, error) {
+	effective, err := s.ResolveConnection(ctx, ct)
+	if err != nil {
+		return WorkspaceModuleConfig{}, err
+	}
+	for _, cur := range s.Catalog.WorkspaceModuleConfigs {
+		if cur.StackId == effective.StackId && cur.BlockId == effective.BlockId && cur.EnvId == *effective.EnvId {
+			return WorkspaceModuleConfig{Module: cur.Module, ModuleConstraint: cur.ModuleConstraint}, nil
+		}
+	}
+	return WorkspaceModuleConfig{}, nil
+}
+
+func (s *SnapshotResolver) ListChannels(ctx context.Context, tool string) ([]map[string]any, error) {
+	return StaticEventChannelResolver{ChannelsByTool: s.Catalog.Channels}.ListChannels(ctx, tool)
+}
+
+// ReserveNullstoneSubdomain does not reserve anything since the catalog is read-only
+// The reservation echoes the requested subdomain (or the block name if none was requested) without a domain name
+func (s *SnapshotResolver) ReserveNullstoneSubdomain(ctx context.Context, blockName string, requested string) (*types.SubdomainReservation, error) {
+	if _, err := s.ResolveBlock(ctx, types.ConnectionTarget{BlockName: blockName}); err != nil {
+		return nil, err
+	}
+	if requested == "" {
+		return &types.SubdomainReservation{IsRandom: true, SubdomainName: blockName}, nil
+	}
+	return &types.SubdomainReservation{SubdomainName: requested}, nil
+}
+
+func (s *SnapshotResolver) ResolveSecretReference(ctx context.Context, ref SecretReference) (string, error) {
+	if s.SecretReferenceResolver == nil {
+		return LiteralSecretReferenceResolver{}.ResolveSecretReference(ctx, ref)
+	}
+	return s.SecretReferenceResolver.ResolveSecretReference(ctx, ref)
+}
+
+func (s *SnapshotResolver) findStack(ct types.ConnectionTarget) (types.Stack, error) {
+	for _, cur := range s.Catalog.Stacks {
+		switch {
+		case ct.StackId != 0:
+			if cur.Id == ct.StackId {
+				return cur, nil
+			}
+		case ct.StackName != "":
+			if cur.Name == ct.StackName {
+				return cur, nil
+			}
+		case cur.Id == s.CurStackId:
+			return cur, nil
+		}
+	}
+	switch {
+	case ct.StackId != 0:
+		return types.Stack{}, MissingSnapshotResourceError{Kind: "stack", Name: fmt.Sprintf("%d", ct.StackId)}
+	case ct.StackName != "":
+		return types.Stack{}, MissingSnapshotResourceError{Kind: "stack", Name: ct.StackName}
+	}
+	return types.Stack{}, MissingSnapshotResourceError{Kind: "stack", Name: fmt.Sprintf("%d", s.CurStackId)}
+}
+
+func (s *SnapshotResolver) findBlock(stack types.Stack, ct types.ConnectionTarget) (types.Block, error) {
+	for _, cur := range slices.Concat(s.Catalog.Blocks, s.backfilled) {
+		if cur.StackId != stack.Id {
+			continue
+		}
+		if (ct.BlockId != 0 && cur.Id == ct.BlockId) || (ct.BlockId == 0 && cur.Name == ct.BlockName) {
+			return cur, nil
+		}
+	}
+	name := ct.BlockName
+	if ct.BlockId != 0 {
+		name = fmt.Sprintf("%d", ct.BlockId)
+	}
+	return types.Block{}, MissingSnapshotResourceError{Kind: "block", Name: fmt.Sprintf("%s/%s", stack.Name, name)}
+}
+
+// findEnv finds the env in stack for a connection target
+// If the target omits the env, this uses the current env or the env in stack with the same name as the current env
+func (s *SnapshotResolver) findEnv(stack types.Stack, ct types.ConnectionTarget) (types.Environment, error) {
+	envId, envName := ct.EnvId, ct.EnvName
+	if envId == nil && envName == "" {
+		if stack.Id == s.CurStackId {
+			envId = &s.CurEnvId
+		} else {
+			for _, cur := range s.Catalog.Envs {
+				if cur.Id == s.CurEnvId {
+					envName = cur.Name
+				}
+			}
+		}
+	}
+	for _, cur := range s.Catalog.Envs {
+		if cur.StackId != stack.Id {
+			continue
+		}
+		if (envId != nil && cur.Id == *envId) || (envId == nil && cur.Name == envName) {
+			return cur, nil
+		}
+	}
+	name := envName
+	if envId != nil {
+		name = fmt.Sprintf("%d", *envId)
+	}
+	return types.Environment{}, MissingSnapshotResourceError{Kind: "env", Name: fmt.Sprintf("%s/%s", stack.Name, name)}
+}
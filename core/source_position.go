@@ -0,0 +1,58 @@
+package core
+
+import "strings"
+
+// SourcePosition identifies where an object is declared in an IaC file
+// Line and Column refer to the start of the object (its key, if it has one)
+// EndLine refers to the last line of the object's value
+type SourcePosition struct {
+	Line    int `json:"line,omitempty"`
+	Column  int `json:"column,omitempty"`
+	EndLine int `json:"endLine,omitempty"`
+}
+
+func (p SourcePosition) IsEmpty() bool {
+	return p.Line == 0
+}
+
+// SourceMap maps an object path (see ObjectPathContext.Context) to its position in an IaC file
+type SourceMap map[string]SourcePosition
+
+// Find looks up the position of the object identified by pc
+// If the object isn't in the source map (e.g. a missing required field), the position of the nearest parent object is returned
+func (m SourceMap) Find(pc ObjectPathContext) SourcePosition {
+	if len(m) == 0 {
+		return SourcePosition{}
+	}
+	for path := pc.Context(); path != ""; path = parentObjectPath(path) {
+		if pos, ok := m[path]; ok {
+			return pos
+		}
+	}
+	return SourcePosition{}
+}
+
+// Has returns true if the object identified by pc is written in the IaC file
+// Unlike Find, this does not fall back to a parent object
+func (m SourceMap) Has(pc ObjectPathContext) bool {
+	_, ok := m[pc.Context()]
+	return ok
+}
+
+// parentObjectPath strips the last field, key, or index from an object path
+// Examples:
+//
+//	"apps.api.vars.cpu"        → "apps.api.vars"
+//	"apps.api.capabilities[0]" → "apps.api.capabilities"
+//	"apps"                     → ""
+func parentObjectPath(path string) string {
+	if strings.HasSuffix(path, "]") {
+		if idx := strings.LastIndex(path, "["); idx != -1 {
+			return path[:idx]
+		}
+	}
+	if idx := strings.LastIndex(path, "."); idx != -1 {
+		return path[:idx]
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+var (
+	// TemplateVariables are the variables available in {{ }} templates within vars and environment values
+	TemplateVariables = []string{
+		"NULLSTONE_ORG",
+		"NULLSTONE_STACK",
+		"NULLSTONE_BLOCK",
+		"NULLSTONE_ENV",
+		"NULLSTONE_ENV_TYPE",
+		"NULLSTONE_ENV_IS_PROD",
+		"NULLSTONE_REPO",
+		"NULLSTONE_REPO_URL",
+	}
+	// TemplateFunctions are the functions available in {{ }} templates within vars and environment values
+	TemplateFunctions = []string{"lower", "default", "replace"}
+
+	undefinedFunctionRegexp = regexp.MustCompile(`function "([^"]+)" not defined`)
+	// nullstoneTemplateRegexp matches a {{ }} action that refers to a Nullstone template variable
+	// Other {{ }} text (e.g. Helm, mustache, or Jinja templates) is passed through to the module as-is
+	nullstoneTemplateRegexp = regexp.MustCompile(`\{\{[^}]*\bNULLSTONE_`)
+)
+
+// TemplateContext contains the values for TemplateVariables
+type TemplateContext struct {
+	OrgName   string
+	StackName string
+	BlockName string
+	EnvName   string
+	EnvType   string
+	EnvIsProd bool
+	RepoName  string
+	RepoUrl   string
+}
+
+// IsTemplate returns true if the input contains a {{ }} template that refers to a Nullstone template variable
+func IsTemplate(input string) bool {
+	return nullstoneTemplateRegexp.MatchString(input)
+}
+
+// ValidateTemplate parses and executes the template against an empty TemplateContext
+// This reports unknown variables, unknown functions, and invalid arguments
+func ValidateTemplate(input string) error {
+	if !IsTemplate(input) {
+		return nil
+	}
+	_, err := TemplateContext{}.Render(input)
+	return err
+}
+
+// Render executes a template (e.g. "{{ if NULLSTONE_ENV_IS_PROD }}warn{{ else }}debug{{ end }}")
+func (c TemplateContext) Render(input string) (string, error) {
+	if !IsTemplate(input) {
+		return input, nil
+	}
+	tmpl, err := template.New("value").Funcs(c.funcs()).Parse(input)
+	if err != nil {
+		if m := undefinedFunctionRegexp.FindStringSubmatch(err.Error()); m != nil {
+			return "", fmt.Errorf("unknown template variable or function %q", m[1])
+		}
+		return "", errors.New(strings.TrimPrefix(err.Error(), "template: "))
+	}
+	if err := checkTemplateNode(tmpl.Root); err != nil {
+		return "", err
+	}
+	sb := strings.Builder{}
+	if err := tmpl.Execute(&sb, nil); err != nil {
+		return "", errors.New(strings.TrimPrefix(err.Error(), "template: "))
+	}
+	return sb.String(), nil
+}
+
+// RenderValue renders every template string within a variable value (including nested lists and maps)
+func (c TemplateContext) RenderValue(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return c.Render(v)
+	case []any:
+		result := make([]any, 0, len(v))
+		for _, item := range v {
+			rendered, err := c.RenderValue(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, rendered)
+		}
+		return result, nil
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			rendered, err := c.RenderValue(item)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = rendered
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+// checkTemplateNode limits templates to TemplateVariables, TemplateFunctions, literals, and if/else
+// text/template also allows builtins (e.g. call, printf, index), fields, and variables which are not supported
+func checkTemplateNode(node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkTemplateNode(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parse.TextNode, *parse.StringNode, *parse.BoolNode, *parse.NumberNode:
+		return nil
+	case *parse.ActionNode:
+		return checkTemplateNode(n.Pipe)
+	case *parse.IfNode:
+		for _, child := range []parse.Node{n.Pipe, n.List, n.ElseList} {
+			if err := checkTemplateNode(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parse.PipeNode:
+		if len(n.Decl) > 0 {
+			break
+		}
+		for _, cmd := range n.Cmds {
+			if err := checkTemplateNode(cmd); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if err := checkTemplateNode(arg); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parse.IdentifierNode:
+		if slices.Contains(TemplateVariables, n.Ident) || slices.Contains(TemplateFunctions, n.Ident) {
+			return nil
+		}
+		return fmt.Errorf("unknown template variable or function %q", n.Ident)
+	}
+	return fmt.Errorf("unsupported template syntax %q", node.String())
+}
+
+func (c TemplateContext) funcs() template.FuncMap {
+	str := func(s string) func() string { return func() string { return s } }
+	return template.FuncMap{
+		"NULLSTONE_ORG":         str(c.OrgName),
+		"NULLSTONE_STACK":       str(c.StackName),
+		"NULLSTONE_BLOCK":       str(c.BlockName),
+		"NULLSTONE_ENV":         str(c.EnvName),
+		"NULLSTONE_ENV_TYPE":    str(c.EnvType),
+		"NULLSTONE_ENV_IS_PROD": func() bool { return c.EnvIsProd },
+		"NULLSTONE_REPO":        str(c.RepoName),
+		"NULLSTONE_REPO_URL":    str(c.RepoUrl),
+		"lower":                 strings.ToLower,
+		// default returns fallback if value is empty (e.g. {{ default "main" NULLSTONE_ENV }})
+		"default": func(fallback, value string) string {
+			if value == "" {
+				return fallback
+			}
+			return value
+		},
+		// replace is ordered for pipelines (e.g. {{ NULLSTONE_ENV | replace "-" "_" }})
+		"replace": func(old, new, s string) string {
+			return strings.ReplaceAll(s, old, new)
+		},
+	}
+}
@@ -15,17 +15,23 @@ type ValidateError struct {
 	IacContext        IacContext        `json:"iacContext"`
 	ObjectPathContext ObjectPathContext `json:"objectPathContext"`
 	ErrorMessage      string            `json:"errorMessage"`
+	// Code is the stable diagnostic code for the error (e.g. NS-CONN-003)
+	Code string `json:"code,omitempty"`
+
+	// SourcePosition is populated from the IaC file once IacContext is attached to the error
+	SourcePosition
 }
 
 func (e ValidateError) Error() string {
-	return fmt.Sprintf("%s => %s", e.IacContext.Context(e.ObjectPathContext), e.ErrorMessage)
+	return e.ToDiagnostic().Error()
 }
 
 func (e ValidateError) ToValidationError() errors.ValidationError {
-	return errors.ValidationError{
-		Context: e.IacContext.Context(e.ObjectPathContext),
-		Message: e.ErrorMessage,
-	}
+	return e.ToDiagnostic().ToValidationError()
+}
+
+func (e ValidateError) ToDiagnostic() Diagnostic {
+	return newDiagnostic(e.Code, StageValidate, e.IacContext, e.ObjectPathContext, e.ErrorMessage, e.SourcePosition)
 }
 
 type ValidateErrors []ValidateError
@@ -41,8 +47,13 @@ func (s ValidateErrors) ToValidationErrors() errors.ValidationErrors {
 	return ve
 }
 
+func (s ValidateErrors) ToDiagnostics() Diagnostics {
+	return toDiagnostics(s)
+}
+
 func VariableDoesNotExistError(pc ObjectPathContext, moduleName string) *ValidateError {
 	return &ValidateError{
+		Code:              CodeVariableDoesNotExist,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Variable does not exist on the module (%s)", moduleName),
 	}
@@ -50,13 +61,55 @@ func VariableDoesNotExistError(pc ObjectPathContext, moduleName string) *Validat
 
 func VariableIncompatibleTypeError(pc ObjectPathContext, varType string, value any) *ValidateError {
 	return &ValidateError{
+		Code:              CodeVariableIncompatibleType,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Specified variable value (%T) is incompatible with expected variable type (%s)", value, varType),
 	}
 }
 
+func MissingRequiredVariableError(pc ObjectPathContext, varName string) *ValidateError {
+	return &ValidateError{
+		Code:              CodeMissingRequiredVariable,
+		ObjectPathContext: pc,
+		ErrorMessage:      fmt.Sprintf("Variable %q is required by the module and has no default", varName),
+	}
+}
+
+func SensitiveVariablePlaintextError(pc ObjectPathContext) *ValidateError {
+	return &ValidateError{
+		Code:              CodeSensitiveVariablePlaintext,
+		ObjectPathContext: pc,
+		ErrorMessage:      "Variable is sensitive and must not be committed as a plaintext value",
+	}
+}
+
+func InvalidTemplateError(pc ObjectPathContext, err error) ValidateError {
+	return ValidateError{
+		Code:              CodeInvalidTemplate,
+		ObjectPathContext: pc,
+		ErrorMessage:      fmt.Sprintf("Invalid template: %s", err),
+	}
+}
+
+func VariableMissingAttributeError(pc ObjectPathContext, attribute string) *ValidateError {
+	return &ValidateError{
+		Code:              CodeVariableMissingAttribute,
+		ObjectPathContext: pc,
+		ErrorMessage:      fmt.Sprintf("Specified variable value is missing required attribute (%s)", attribute),
+	}
+}
+
+func VariableTupleLengthError(pc ObjectPathContext, expected, actual int) *ValidateError {
+	return &ValidateError{
+		Code:              CodeVariableTupleLength,
+		ObjectPathContext: pc,
+		ErrorMessage:      fmt.Sprintf("Specified variable value has %d elements, expected %d", actual, expected),
+	}
+}
+
 func ConnectionDoesNotExistError(pc ObjectPathContext, moduleName string) *ValidateError {
 	return &ValidateError{
+		Code:              CodeConnectionDoesNotExist,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Connection does not exist on the module (%s)", moduleName),
 	}
@@ -64,6 +117,7 @@ func ConnectionDoesNotExistError(pc ObjectPathContext, moduleName string) *Valid
 
 func MissingConnectionBlockError(pc ObjectPathContext) *ValidateError {
 	return &ValidateError{
+		Code:              CodeMissingConnectionBlock,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Connection must have a block_name to identify which block it is connected to"),
 	}
@@ -71,6 +125,7 @@ func MissingConnectionBlockError(pc ObjectPathContext) *ValidateError {
 
 func InvalidConnectionContractError(pc ObjectPathContext, contract, moduleName string) *ValidateError {
 	return &ValidateError{
+		Code:              CodeInvalidConnectionContract,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Connection contract (contract=%s) in module (%s) is invalid", contract, moduleName),
 	}
@@ -78,13 +133,23 @@ func InvalidConnectionContractError(pc ObjectPathContext, contract, moduleName s
 
 func MismatchedConnectionContractError(pc ObjectPathContext, blockName, connectionContract string) *ValidateError {
 	return &ValidateError{
+		Code:              CodeMismatchedConnectionContract,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Block (%s) does not match the required contract (%s) for the capability connection", blockName, connectionContract),
 	}
 }
 
+func DependencyCycleError(pc ObjectPathContext, cycle []string) ValidateError {
+	return ValidateError{
+		Code:              CodeDependencyCycle,
+		ObjectPathContext: pc,
+		ErrorMessage:      fmt.Sprintf("Connection creates a dependency cycle (%s)", strings.Join(cycle, " -> ")),
+	}
+}
+
 func MissingCapabilityNameError(pc ObjectPathContext) *ValidateError {
 	return &ValidateError{
+		Code:              CodeMissingCapabilityName,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Capability requires a name"),
 	}
@@ -92,6 +157,7 @@ func MissingCapabilityNameError(pc ObjectPathContext) *ValidateError {
 
 func EnvVariableKeyStartsWithNumberError(pc ObjectPathContext) ValidateError {
 	return ValidateError{
+		Code:              CodeEnvVariableKeyStartsWithNumber,
 		ObjectPathContext: pc,
 		ErrorMessage:      "Invalid environment variable, key must not start with a number",
 	}
@@ -99,13 +165,31 @@ func EnvVariableKeyStartsWithNumberError(pc ObjectPathContext) ValidateError {
 
 func EnvVariableKeyInvalidCharsError(pc ObjectPathContext) ValidateError {
 	return ValidateError{
+		Code:              CodeEnvVariableKeyInvalidChars,
 		ObjectPathContext: pc,
 		ErrorMessage:      "Invalid environment variable, key must contain only letters, numbers, and underscores",
 	}
 }
 
+func InvalidSecretReferenceError(pc ObjectPathContext, err error) ValidateError {
+	return ValidateError{
+		Code:              CodeInvalidSecretReference,
+		ObjectPathContext: pc,
+		ErrorMessage:      fmt.Sprintf("Invalid secret reference: %s", err),
+	}
+}
+
+func EnvVariableValueAndSecretError(pc ObjectPathContext) ValidateError {
+	return ValidateError{
+		Code:              CodeEnvVariableValueAndSecret,
+		ObjectPathContext: pc,
+		ErrorMessage:      "Invalid environment variable, specify either value or secret, not both",
+	}
+}
+
 func MissingSubdomainTemplateError(pc ObjectPathContext) ValidateError {
 	return ValidateError{
+		Code:              CodeMissingSubdomainTemplate,
 		ObjectPathContext: pc,
 		ErrorMessage:      "Subdomain template is required",
 	}
@@ -113,6 +197,7 @@ func MissingSubdomainTemplateError(pc ObjectPathContext) ValidateError {
 
 func InvalidDataClassificationError(pc ObjectPathContext, value string, allowed []string) *ValidateError {
 	return &ValidateError{
+		Code:              CodeInvalidDataClassification,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Invalid data classification value (%s), must be one of: %s", value, strings.Join(allowed, ", ")),
 	}
@@ -120,6 +205,7 @@ func InvalidDataClassificationError(pc ObjectPathContext, value string, allowed
 
 func UnsupportedAppCategoryError(pc ObjectPathContext, moduleSource, subcategory string) ValidateError {
 	return ValidateError{
+		Code:              CodeUnsupportedAppCategory,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Module (%s) does not support application category (%s)", moduleSource, subcategory),
 	}
@@ -131,11 +217,13 @@ func InvalidEventActionError(pc ObjectPathContext, actions []string) *ValidateEr
 	}
 	if len(actions) == 1 {
 		return &ValidateError{
+			Code:              CodeInvalidEventAction,
 			ObjectPathContext: pc,
 			ErrorMessage:      fmt.Sprintf("Event Action (%s) is not a valid event action", actions[0]),
 		}
 	}
 	return &ValidateError{
+		Code:              CodeInvalidEventAction,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Event Actions (%s) are not valid event actions", strings.Join(actions, ",")),
 	}
@@ -147,11 +235,13 @@ func InvalidEventStatusError(pc ObjectPathContext, actions []string) *ValidateEr
 	}
 	if len(actions) == 1 {
 		return &ValidateError{
+			Code:              CodeInvalidEventStatus,
 			ObjectPathContext: pc,
 			ErrorMessage:      fmt.Sprintf("Event Status (%s) is not a valid event status", actions[0]),
 		}
 	}
 	return &ValidateError{
+		Code:              CodeInvalidEventStatus,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Event Statuses (%s) are not valid event statuses", strings.Join(actions, ",")),
 	}
@@ -159,7 +249,28 @@ func InvalidEventStatusError(pc ObjectPathContext, actions []string) *ValidateEr
 
 func InvalidEventTargetError(pc ObjectPathContext, target string) ValidateError {
 	return ValidateError{
+		Code:              CodeInvalidEventTarget,
 		ObjectPathContext: pc,
 		ErrorMessage:      fmt.Sprintf("Event Target (%s) is not a valid event target", target),
 	}
 }
+
+func MissingWebhookUrlError(pc ObjectPathContext) ValidateError {
+	return ValidateError{
+		Code:              CodeInvalidWebhookUrl,
+		ObjectPathContext: pc,
+		ErrorMessage:      "When specifying `webhook`, `url` is required",
+	}
+}
+
+func InvalidWebhookUrlError(pc ObjectPathContext, err error) ValidateError {
+	msg := "Invalid webhook URL"
+	if err != nil {
+		msg = fmt.Sprintf("Invalid webhook URL: %s", err)
+	}
+	return ValidateError{
+		Code:              CodeInvalidWebhookUrl,
+		ObjectPathContext: pc,
+		ErrorMessage:      msg,
+	}
+}
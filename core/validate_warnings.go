@@ -0,0 +1,42 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Warnings are diagnostics for configuration that is valid, but deprecated or risky
+// They are reported to the user without failing a sync
+
+func newWarning(code string, pc ObjectPathContext, message, suggestion string) Diagnostic {
+	d := newDiagnostic(code, StageValidate, IacContext{}, pc, message, SourcePosition{})
+	d.Suggestion = suggestion
+	return d
+}
+
+func DeprecatedDnsNameWarning(pc ObjectPathContext, template string) Diagnostic {
+	return newWarning(CodeDeprecatedDnsName, pc,
+		"`dns_name` is deprecated, use `dns.template` instead",
+		fmt.Sprintf("dns:\n  template: %q", template))
+}
+
+func UnnamedCapabilityWarning(pc ObjectPathContext, name string) Diagnostic {
+	return newWarning(CodeUnnamedCapability, pc,
+		"Capability does not have a name, it is identified by its module and connections",
+		fmt.Sprintf("name: %s", name))
+}
+
+func DefaultModuleVersionWarning(pc ObjectPathContext, moduleSource, version string) Diagnostic {
+	if version == "" {
+		version = "<version>"
+	}
+	return newWarning(CodeDefaultModuleVersion, pc,
+		"`module_version` is not specified, the latest version of the module is used",
+		fmt.Sprintf("module: %s\nmodule_version: %q", moduleSource, version))
+}
+
+func MissingDataClassificationWarning(pc ObjectPathContext, allowed []string) Diagnostic {
+	return newWarning(CodeMissingDataClassification, pc,
+		fmt.Sprintf("Datastore is unclassified, specify one of: %s", strings.Join(allowed, ", ")),
+		"metadata:\n  dataclassification: <level>")
+}
@@ -0,0 +1,287 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+// Version is a parsed semantic version (e.g. 1.2.3 or v1.2.3-beta.1)
+// Build metadata is ignored
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// ParseVersion parses a full semantic version
+func ParseVersion(s string) (Version, error) {
+	v, parts, err := parsePartialVersion(s)
+	if err != nil {
+		return Version{}, err
+	}
+	if parts != 3 {
+		return Version{}, fmt.Errorf("invalid version %q, must be in the format <major>.<minor>.<patch>", s)
+	}
+	return v, nil
+}
+
+// parsePartialVersion parses a version that may omit minor and patch (e.g. 1 or 1.2)
+// This returns the number of version parts that were specified
+func parsePartialVersion(s string) (Version, int, error) {
+	raw := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.Index(raw, "+"); i != -1 {
+		raw = raw[:i]
+	}
+	v := Version{}
+	if i := strings.Index(raw, "-"); i != -1 {
+		v.Prerelease = raw[i+1:]
+		raw = raw[:i]
+		if v.Prerelease == "" {
+			return Version{}, 0, fmt.Errorf("invalid version %q, prerelease is empty", s)
+		}
+	}
+	tokens := strings.Split(raw, ".")
+	if len(tokens) > 3 {
+		return Version{}, 0, fmt.Errorf("invalid version %q, too many version parts", s)
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, token := range tokens {
+		n, err := strconv.Atoi(token)
+		if err != nil || n < 0 {
+			return Version{}, 0, fmt.Errorf("invalid version %q", s)
+		}
+		*nums[i] = n
+	}
+	if v.Prerelease != "" && len(tokens) != 3 {
+		return Version{}, 0, fmt.Errorf("invalid version %q, a prerelease requires <major>.<minor>.<patch>", s)
+	}
+	return v, len(tokens), nil
+}
+
+// Compare returns -1, 0, or 1 if v is less than, equal to, or greater than other
+// A prerelease is less than its release (e.g. 1.0.0-beta < 1.0.0)
+func (v Version) Compare(other Version) int {
+	if c := compareInt(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func comparePrerelease(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareInt(an, bn); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			// Numeric identifiers have lower precedence than alphanumeric identifiers
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInt(len(as), len(bs))
+}
+
+// IsVersionConstraint returns true if a module_version is a constraint rather than `latest` or an exact version
+func IsVersionConstraint(s string) bool {
+	return strings.ContainsAny(s, "<>=!~^,")
+}
+
+// VersionConstraint is a comma-separated list of version requirements that must all be satisfied
+// Supported operators:
+//
+//	=, !=, >, >=, <, <=   compare against a version (e.g. ">= 1.2, < 2.0")
+//	~> 0.12               allows the rightmost version part to increase (>= 0.12.0, < 1.0.0)
+//	~> 0.12.3             (>= 0.12.3, < 0.13.0)
+//	~1.2.3                allows patch updates (>= 1.2.3, < 1.3.0)
+//	^1.2.3                allows updates that don't change the leftmost non-zero part (>= 1.2.3, < 2.0.0)
+//
+// Prerelease versions are only matched if the constraint mentions a prerelease
+type VersionConstraint struct {
+	raw               string
+	terms             []versionTerm
+	includePrerelease bool
+}
+
+type versionTerm struct {
+	op      string
+	version Version
+}
+
+var versionOperators = []string{"~>", ">=", "<=", "!=", ">", "<", "=", "~", "^"}
+
+// ParseVersionConstraint parses a version constraint (e.g. "~> 0.12" or ">= 1.2, < 2.0")
+func ParseVersionConstraint(s string) (VersionConstraint, error) {
+	c := VersionConstraint{raw: s}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return VersionConstraint{}, fmt.Errorf("invalid version constraint %q, empty requirement", s)
+		}
+		op := "="
+		for _, cur := range versionOperators {
+			if strings.HasPrefix(part, cur) {
+				op = cur
+				part = strings.TrimSpace(strings.TrimPrefix(part, cur))
+				break
+			}
+		}
+		v, parts, err := parsePartialVersion(part)
+		if err != nil {
+			return VersionConstraint{}, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		if v.Prerelease != "" {
+			c.includePrerelease = true
+		}
+		terms, err := expandVersionTerm(op, v, parts)
+		if err != nil {
+			return VersionConstraint{}, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		c.terms = append(c.terms, terms...)
+	}
+	return c, nil
+}
+
+// expandVersionTerm converts a requirement into simple comparisons
+func expandVersionTerm(op string, v Version, parts int) ([]versionTerm, error) {
+	switch op {
+	case "~>":
+		upper := Version{Major: v.Major + 1}
+		if parts == 3 {
+			upper = Version{Major: v.Major, Minor: v.Minor + 1}
+		}
+		return []versionTerm{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	case "~":
+		upper := Version{Major: v.Major, Minor: v.Minor + 1}
+		if parts == 1 {
+			upper = Version{Major: v.Major + 1}
+		}
+		return []versionTerm{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	case "^":
+		var upper Version
+		switch {
+		case v.Major > 0 || parts == 1:
+			upper = Version{Major: v.Major + 1}
+		case v.Minor > 0 || parts == 2:
+			upper = Version{Minor: v.Minor + 1}
+		default:
+			upper = Version{Patch: v.Patch + 1}
+		}
+		return []versionTerm{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	case "=", "!=":
+		if parts == 3 {
+			return []versionTerm{{op: op, version: v}}, nil
+		}
+		if op == "!=" {
+			return nil, fmt.Errorf("%s requires a full version", op)
+		}
+		// A partial version matches any version with the same prefix (e.g. =1.2 matches 1.2.x)
+		upper := Version{Major: v.Major + 1}
+		if parts == 2 {
+			upper = Version{Major: v.Major, Minor: v.Minor + 1}
+		}
+		return []versionTerm{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	default:
+		return []versionTerm{{op: op, version: v}}, nil
+	}
+}
+
+func (c VersionConstraint) String() string {
+	return c.raw
+}
+
+// Check returns true if version satisfies every requirement in the constraint
+func (c VersionConstraint) Check(version string) bool {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return false
+	}
+	if v.Prerelease != "" && !c.includePrerelease {
+		return false
+	}
+	for _, t := range c.terms {
+		cmp := v.Compare(t.version)
+		var ok bool
+		switch t.op {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// HighestMatch returns the highest version that satisfies the constraint
+// This returns nil if no version satisfies the constraint
+func (c VersionConstraint) HighestMatch(versions []types.ModuleVersion) *types.ModuleVersion {
+	var best *types.ModuleVersion
+	var bestVersion Version
+	for i, cur := range versions {
+		if !c.Check(cur.Version) {
+			continue
+		}
+		v, _ := ParseVersion(cur.Version)
+		if best == nil || v.Compare(bestVersion) > 0 {
+			best, bestVersion = &versions[i], v
+		}
+	}
+	return best
+}
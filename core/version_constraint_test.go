@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/nullstone-io/go-api-client.v0/artifacts"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+func TestVersionConstraint_Check(t *testing.T) {
+	tests := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+	}{
+		{constraint: "~> 0.12", match: []string{"0.12.0", "0.12.7", "0.13.1"}, noMatch: []string{"0.11.9", "1.0.0"}},
+		{constraint: "~> 0.12.3", match: []string{"0.12.3", "0.12.9"}, noMatch: []string{"0.12.2", "0.13.0"}},
+		{constraint: ">= 1.2, < 2.0", match: []string{"1.2.0", "1.9.9"}, noMatch: []string{"1.1.9", "2.0.0", "2.0.0-beta.1"}},
+		{constraint: "^1.2.3", match: []string{"1.2.3", "1.9.0"}, noMatch: []string{"1.2.2", "2.0.0"}},
+		{constraint: "^0.2.3", match: []string{"0.2.3", "0.2.9"}, noMatch: []string{"0.3.0"}},
+		{constraint: "~1.2.3", match: []string{"1.2.3", "1.2.9"}, noMatch: []string{"1.3.0"}},
+		{constraint: "=1.2", match: []string{"1.2.0", "1.2.5"}, noMatch: []string{"1.3.0"}},
+		{constraint: ">= 1.0.0, != 1.1.0", match: []string{"1.0.0", "1.2.0"}, noMatch: []string{"1.1.0"}},
+		{constraint: ">= 1.0.0-beta.1", match: []string{"1.0.0-beta.2", "1.0.0"}, noMatch: []string{"1.0.0-alpha"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.constraint, func(t *testing.T) {
+			c, err := ParseVersionConstraint(test.constraint)
+			require.NoError(t, err)
+			for _, v := range test.match {
+				assert.True(t, c.Check(v), "expected %s to match", v)
+			}
+			for _, v := range test.noMatch {
+				assert.False(t, c.Check(v), "expected %s not to match", v)
+			}
+		})
+	}
+}
+
+func TestParseVersionConstraint_invalid(t *testing.T) {
+	for _, input := range []string{">= ", "~> 1.x", ">= 1.0,", "!= 1.2", ">= 1.2.3.4"} {
+		_, err := ParseVersionConstraint(input)
+		assert.Error(t, err, input)
+	}
+}
+
+type constraintResolver struct {
+	module *types.Module
+}
+
+func (r constraintResolver) ResolveModuleVersion(ctx context.Context, source artifacts.ModuleSource, version string) (*types.Module, *types.ModuleVersion, error) {
+	constraint, err := ParseVersionConstraint(version)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.module, constraint.HighestMatch(r.module.Versions), nil
+}
+
+func TestGetModuleVersion_constraint(t *testing.T) {
+	resolver := constraintResolver{
+		module: &types.Module{
+			OrgName:  "nullstone",
+			Name:     "aws-fargate-service",
+			Category: types.CategoryApp,
+			Versions: []types.ModuleVersion{{Version: "0.12.1"}, {Version: "1.0.0"}, {Version: "0.12.4"}, {Version: "0.11.0"}},
+		},
+	}
+	pc := NewObjectPathContextKey("apps", "api")
+	contract := types.ModuleContractName{Category: string(types.CategoryApp), Provider: "*", Platform: "*"}
+
+	_, mv, err := GetModuleVersion(context.Background(), resolver, pc, "nullstone/aws-fargate-service", "~> 0.12.0", contract)
+	require.Nil(t, err)
+	assert.Equal(t, "0.12.4", mv.Version)
+
+	_, _, err = GetModuleVersion(context.Background(), resolver, pc, "nullstone/aws-fargate-service", ">= 2.0", contract)
+	assert.Equal(t, NoMatchingModuleVersionError(pc, "nullstone/aws-fargate-service", ">= 2.0", []string{"1.0.0", "0.12.4", "0.12.1", "0.11.0"}), err)
+	assert.Equal(t, `Module (nullstone/aws-fargate-service) has no version that satisfies ">= 2.0", available versions: 1.0.0, 0.12.4, 0.12.1, 0.11.0`, err.ErrorMessage)
+
+	_, _, err = GetModuleVersion(context.Background(), resolver, pc, "nullstone/aws-fargate-service", ">= banana", contract)
+	require.NotNil(t, err)
+	assert.Equal(t, CodeInvalidModuleVersionConstraint, err.Code)
+}
@@ -0,0 +1,363 @@
+package graph
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nullstone-io/iac"
+	"github.com/nullstone-io/iac/config"
+	"github.com/nullstone-io/iac/core"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+// Node is a block in the dependency graph
+type Node struct {
+	StackName string `json:"stackName"`
+	// EnvName is only set for a block in a different env than the graph (e.g. a connection to `prod.network` from dev)
+	EnvName   string `json:"envName,omitempty"`
+	BlockName string `json:"blockName"`
+	// External is true if the block is not declared in the IaC files used to build the graph (e.g. a block in another stack)
+	External bool `json:"external"`
+}
+
+func (n Node) String() string {
+	if n.EnvName != "" {
+		return n.StackName + "." + n.EnvName + "." + n.BlockName
+	}
+	return n.StackName + "." + n.BlockName
+}
+
+// Edge is a connection from a block to the block it depends on
+type Edge struct {
+	From              Node                   `json:"from"`
+	To                Node                   `json:"to"`
+	IacContext        core.IacContext        `json:"iacContext"`
+	ObjectPathContext core.ObjectPathContext `json:"objectPathContext"`
+	core.SourcePosition
+}
+
+// Graph is the dependency graph of blocks formed by block and capability connections
+type Graph struct {
+	nodes      map[string]Node
+	edges      map[string][]Edge
+	dependents map[string][]Edge
+}
+
+// Build creates the dependency graph for env from the connections in config.yml and the overrides file for env
+// A connection in the overrides file replaces the connection with the same name in config.yml
+// The connections should be normalized so that targets in other stacks are fully identified
+// stackName is the stack that contains the IaC files
+func Build(input iac.ConfigFiles, env types.Environment, stackName string) *Graph {
+	g := &Graph{
+		nodes:      map[string]Node{},
+		edges:      map[string][]Edge{},
+		dependents: map[string][]Edge{},
+	}
+
+	files := make([]*config.EnvConfiguration, 0)
+	if input.Config != nil {
+		files = append(files, input.Config)
+	}
+	if cur := input.GetOverrides(env); cur != nil {
+		files = append(files, cur)
+	}
+
+	for _, file := range files {
+		for blockName := range file.BlockNames() {
+			g.addNode(Node{StackName: stackName, BlockName: blockName})
+		}
+	}
+	type candidate struct {
+		fileIndex int
+		edge      Edge
+	}
+	candidates := map[string]candidate{}
+	for i, file := range files {
+		capabilityPaths := namedCapabilityPaths(file)
+		file.VisitConnections(func(blockName string, pc core.ObjectPathContext, conn *config.ConnectionConfiguration) {
+			key := pc.Context()
+			if path, ok := capabilityPaths[pc.Path]; ok {
+				key = core.ObjectPathContext{Path: path, Field: pc.Field, Key: pc.Key}.Context()
+			}
+			target := conn.EffectiveTarget
+			if target.BlockName == "" {
+				target = conn.DesiredTarget
+			}
+			if target.BlockName == "" {
+				return
+			}
+			to := Node{StackName: target.StackName, BlockName: target.BlockName}
+			if to.StackName == "" {
+				to.StackName = stackName
+			}
+			to.EnvName = targetEnvName(target, env, to.StackName == stackName)
+			candidates[key] = candidate{fileIndex: i, edge: Edge{
+				From:              Node{StackName: stackName, BlockName: blockName},
+				To:                to,
+				IacContext:        file.IacContext,
+				ObjectPathContext: pc,
+				SourcePosition:    file.SourceMap.Find(pc),
+			}}
+		})
+	}
+	// Connections are visited in map order, sort them so the edge kept for each pair of blocks is deterministic
+	sorted := slices.SortedFunc(maps.Values(candidates), func(a, b candidate) int {
+		if a.fileIndex != b.fileIndex {
+			return a.fileIndex - b.fileIndex
+		}
+		return strings.Compare(a.edge.ObjectPathContext.Context(), b.edge.ObjectPathContext.Context())
+	})
+	for _, c := range sorted {
+		g.addEdge(c.edge)
+	}
+
+	for key := range g.edges {
+		slices.SortFunc(g.edges[key], compareEdges)
+	}
+	for key := range g.dependents {
+		slices.SortFunc(g.dependents[key], compareEdges)
+	}
+	return g
+}
+
+// targetEnvName returns the env of a connection target that is in a different env than env
+// Envs in other stacks have different ids, so only the env name is compared for a target in another stack
+func targetEnvName(target types.ConnectionTarget, env types.Environment, isLocalStack bool) string {
+	if target.EnvName != "" {
+		if target.EnvName == env.Name {
+			return ""
+		}
+		return target.EnvName
+	}
+	if isLocalStack && target.EnvId != nil && env.Id != 0 && *target.EnvId != env.Id {
+		return strconv.FormatInt(*target.EnvId, 10)
+	}
+	return ""
+}
+
+// namedCapabilityPaths maps the path of each named capability to a path that uses its name instead of its position
+// This matches a capability in an overrides file with the same capability in config.yml
+func namedCapabilityPaths(file *config.EnvConfiguration) map[string]string {
+	result := map[string]string{}
+	for _, app := range file.Applications {
+		pc := core.NewObjectPathContextKey("apps", app.Name)
+		for i, capability := range app.Capabilities {
+			if capability.Name != "" {
+				result[pc.SubIndex("capabilities", i).Context()] = pc.SubKey("capabilities", capability.Name).Context()
+			}
+		}
+	}
+	return result
+}
+
+func (g *Graph) addNode(n Node) {
+	if _, ok := g.nodes[n.String()]; !ok {
+		g.nodes[n.String()] = n
+	}
+}
+
+// addEdge ignores an edge between two blocks that are already connected
+// A block that is not declared in the IaC files is added as an external block
+func (g *Graph) addEdge(e Edge) {
+	if _, ok := g.nodes[e.To.String()]; !ok {
+		e.To.External = true
+		g.addNode(e.To)
+	}
+	e.From, e.To = g.nodes[e.From.String()], g.nodes[e.To.String()]
+	from, to := e.From.String(), e.To.String()
+	for _, cur := range g.edges[from] {
+		if cur.To.String() == to {
+			return
+		}
+	}
+	g.edges[from] = append(g.edges[from], e)
+	g.dependents[to] = append(g.dependents[to], e)
+}
+
+func compareEdges(a, b Edge) int {
+	if c := strings.Compare(a.From.String(), b.From.String()); c != 0 {
+		return c
+	}
+	return strings.Compare(a.To.String(), b.To.String())
+}
+
+// Nodes returns every block in the graph sorted by stack and block name
+func (g *Graph) Nodes() []Node {
+	result := make([]Node, 0, len(g.nodes))
+	for _, key := range slices.Sorted(maps.Keys(g.nodes)) {
+		result = append(result, g.nodes[key])
+	}
+	return result
+}
+
+// Edges returns every connection in the graph sorted by the connecting block
+func (g *Graph) Edges() []Edge {
+	result := make([]Edge, 0)
+	for _, key := range slices.Sorted(maps.Keys(g.edges)) {
+		result = append(result, g.edges[key]...)
+	}
+	return result
+}
+
+// Dependencies returns the blocks that n connects to
+func (g *Graph) Dependencies(n Node) []Node {
+	result := make([]Node, 0)
+	for _, e := range g.edges[n.String()] {
+		result = append(result, e.To)
+	}
+	return result
+}
+
+// Dependents returns the blocks that connect to n
+func (g *Graph) Dependents(n Node) []Node {
+	result := make([]Node, 0)
+	for _, e := range g.dependents[n.String()] {
+		result = append(result, e.From)
+	}
+	return result
+}
+
+// AllDependents returns every block that directly or indirectly connects to n, sorted by stack and block name
+// This is the set of blocks affected by a change to n
+func (g *Graph) AllDependents(n Node) []Node {
+	visited := map[string]Node{}
+	queue := []Node{n}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, dep := range g.Dependents(cur) {
+			if _, ok := visited[dep.String()]; ok || dep.String() == n.String() {
+				continue
+			}
+			visited[dep.String()] = dep
+			queue = append(queue, dep)
+		}
+	}
+	result := make([]Node, 0, len(visited))
+	for _, key := range slices.Sorted(maps.Keys(visited)) {
+		result = append(result, visited[key])
+	}
+	return result
+}
+
+// Validate reports each dependency cycle as an error on the connection that starts the cycle
+func (g *Graph) Validate() core.ValidateErrors {
+	var errs core.ValidateErrors
+	for _, cycle := range g.Cycles() {
+		path := make([]string, 0, len(cycle)+1)
+		for _, e := range cycle {
+			path = append(path, e.From.String())
+		}
+		path = append(path, cycle[0].From.String())
+		err := core.DependencyCycleError(cycle[0].ObjectPathContext, path)
+		err.IacContext = cycle[0].IacContext
+		err.SourcePosition = cycle[0].SourcePosition
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Cycles returns the connections that form each dependency cycle
+// Each cycle starts at its block with the lowest name so that results are deterministic
+func (g *Graph) Cycles() [][]Edge {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	seen := map[string]bool{}
+	var stack []Edge
+	var cycles [][]Edge
+
+	var visit func(key string)
+	visit = func(key string) {
+		state[key] = visiting
+		for _, e := range g.edges[key] {
+			to := e.To.String()
+			switch state[to] {
+			case visiting:
+				// The cycle is the part of the current path that starts at the target
+				start := slices.IndexFunc(stack, func(cur Edge) bool { return cur.From.String() == to })
+				if start < 0 {
+					// The block connects to itself
+					start = len(stack)
+				}
+				cycle := canonicalCycle(append(slices.Clone(stack[start:]), e))
+				if id := cycleId(cycle); !seen[id] {
+					seen[id] = true
+					cycles = append(cycles, cycle)
+				}
+			case unvisited:
+				stack = append(stack, e)
+				visit(to)
+				stack = stack[:len(stack)-1]
+			}
+		}
+		state[key] = done
+	}
+	for _, key := range slices.Sorted(maps.Keys(g.nodes)) {
+		if state[key] == unvisited {
+			visit(key)
+		}
+	}
+	return cycles
+}
+
+// canonicalCycle rotates the cycle to start at the block with the lowest name
+func canonicalCycle(cycle []Edge) []Edge {
+	lowest := 0
+	for i, e := range cycle {
+		if e.From.String() < cycle[lowest].From.String() {
+			lowest = i
+		}
+	}
+	return append(slices.Clone(cycle[lowest:]), cycle[:lowest]...)
+}
+
+func cycleId(cycle []Edge) string {
+	sb := strings.Builder{}
+	for _, e := range cycle {
+		sb.WriteString(e.From.String())
+		sb.WriteString(">")
+	}
+	return sb.String()
+}
+
+// TopologicalOrder returns blocks ordered so that every block comes after the blocks it connects to
+// Blocks that are ready at the same time are sorted by stack and block name
+// If the graph contains a cycle, no order is returned and the cycles are reported as errors
+func (g *Graph) TopologicalOrder() ([]Node, core.ValidateErrors) {
+	if errs := g.Validate(); len(errs) > 0 {
+		return nil, errs
+	}
+
+	remaining := map[string]int{}
+	for key := range g.nodes {
+		remaining[key] = len(g.edges[key])
+	}
+	var ready []string
+	for key, count := range remaining {
+		if count == 0 {
+			ready = append(ready, key)
+		}
+	}
+
+	result := make([]Node, 0, len(g.nodes))
+	for len(ready) > 0 {
+		slices.Sort(ready)
+		key := ready[0]
+		ready = ready[1:]
+		result = append(result, g.nodes[key])
+		for _, e := range g.dependents[key] {
+			from := e.From.String()
+			remaining[from]--
+			if remaining[from] == 0 {
+				ready = append(ready, from)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,199 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/nullstone-io/iac"
+	"github.com/nullstone-io/iac/config"
+	"github.com/nullstone-io/iac/core"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+func connections(targets map[string]types.ConnectionTarget) config.ConnectionConfigurations {
+	result := config.ConnectionConfigurations{}
+	for name, target := range targets {
+		result[name] = &config.ConnectionConfiguration{DesiredTarget: target, EffectiveTarget: target}
+	}
+	return result
+}
+
+func TestGraph(t *testing.T) {
+	input := iac.ConfigFiles{
+		Config: &config.EnvConfiguration{
+			IacContext: core.IacContext{Filename: ".nullstone/config.yml"},
+			Applications: map[string]*config.AppConfiguration{
+				"api": {
+					BlockConfiguration: config.BlockConfiguration{
+						Name: "api",
+						Connections: connections(map[string]types.ConnectionTarget{
+							"postgres": {StackName: "core", BlockName: "db"},
+							"cluster":  {StackName: "shared", BlockName: "cluster"},
+						}),
+					},
+					Capabilities: config.CapabilityConfigurations{
+						{Connections: connections(map[string]types.ConnectionTarget{"subdomain": {BlockName: "api-subdomain"}})},
+					},
+				},
+			},
+			Datastores: map[string]*config.DatastoreConfiguration{
+				"db": {BlockConfiguration: config.BlockConfiguration{Name: "db"}},
+			},
+			Subdomains: map[string]*config.SubdomainConfiguration{
+				"api-subdomain": {BlockConfiguration: config.BlockConfiguration{Name: "api-subdomain"}},
+			},
+		},
+		Overrides: map[string]*config.EnvConfiguration{
+			"prod": {
+				IacContext: core.IacContext{Filename: ".nullstone/prod.yml", IsOverrides: true},
+				Blocks: map[string]*config.BlockConfiguration{
+					"replica": {
+						Name:        "replica",
+						Connections: connections(map[string]types.ConnectionTarget{"primary": {BlockName: "db"}}),
+					},
+				},
+			},
+		},
+	}
+	g := Build(input, types.Environment{Name: "prod"}, "core")
+
+	api := Node{StackName: "core", BlockName: "api"}
+	db := Node{StackName: "core", BlockName: "db"}
+	cluster := Node{StackName: "shared", BlockName: "cluster", External: true}
+	subdomain := Node{StackName: "core", BlockName: "api-subdomain"}
+	replica := Node{StackName: "core", BlockName: "replica"}
+
+	assert.Equal(t, []Node{api, subdomain, db, replica, cluster}, g.Nodes())
+	assert.Equal(t, []Node{subdomain, db, cluster}, g.Dependencies(api))
+	assert.Equal(t, []Node{api, replica}, g.Dependents(db))
+	assert.Equal(t, []Node{api}, g.AllDependents(cluster))
+	assert.Empty(t, g.Validate())
+
+	order, errs := g.TopologicalOrder()
+	assert.Empty(t, errs)
+	assert.Equal(t, []Node{subdomain, db, replica, cluster, api}, order)
+}
+
+func TestGraph_overriddenConnection(t *testing.T) {
+	input := iac.ConfigFiles{
+		Config: &config.EnvConfiguration{
+			IacContext: core.IacContext{Filename: ".nullstone/config.yml"},
+			Applications: map[string]*config.AppConfiguration{
+				"api": {
+					BlockConfiguration: config.BlockConfiguration{
+						Name:        "api",
+						Connections: connections(map[string]types.ConnectionTarget{"postgres": {BlockName: "db"}}),
+					},
+					Capabilities: config.CapabilityConfigurations{
+						{Name: "cdn", Connections: connections(map[string]types.ConnectionTarget{"subdomain": {BlockName: "api-subdomain"}})},
+					},
+				},
+			},
+			Datastores: map[string]*config.DatastoreConfiguration{
+				"db": {BlockConfiguration: config.BlockConfiguration{Name: "db"}},
+			},
+			Subdomains: map[string]*config.SubdomainConfiguration{
+				"api-subdomain": {BlockConfiguration: config.BlockConfiguration{Name: "api-subdomain"}},
+			},
+		},
+		Overrides: map[string]*config.EnvConfiguration{
+			"prod": {
+				IacContext: core.IacContext{Filename: ".nullstone/prod.yml", IsOverrides: true},
+				Applications: map[string]*config.AppConfiguration{
+					"api": {
+						BlockConfiguration: config.BlockConfiguration{
+							Name:        "api",
+							Connections: connections(map[string]types.ConnectionTarget{"postgres": {BlockName: "prod-db"}}),
+						},
+						Capabilities: config.CapabilityConfigurations{
+							{Name: "debug-sidecar"},
+							{Name: "cdn", Connections: connections(map[string]types.ConnectionTarget{"subdomain": {BlockName: "prod-subdomain"}})},
+						},
+					},
+				},
+				Datastores: map[string]*config.DatastoreConfiguration{
+					"prod-db": {BlockConfiguration: config.BlockConfiguration{Name: "prod-db"}},
+				},
+				Subdomains: map[string]*config.SubdomainConfiguration{
+					"prod-subdomain": {BlockConfiguration: config.BlockConfiguration{Name: "prod-subdomain"}},
+				},
+			},
+			"staging": {
+				IacContext: core.IacContext{Filename: ".nullstone/staging.yml", IsOverrides: true},
+				Blocks: map[string]*config.BlockConfiguration{
+					"worker": {Name: "worker", Connections: connections(map[string]types.ConnectionTarget{"api": {BlockName: "api"}})},
+				},
+			},
+		},
+	}
+	api := Node{StackName: "core", BlockName: "api"}
+	db := Node{StackName: "core", BlockName: "db"}
+	prodDb := Node{StackName: "core", BlockName: "prod-db"}
+	subdomain := Node{StackName: "core", BlockName: "api-subdomain"}
+	prodSubdomain := Node{StackName: "core", BlockName: "prod-subdomain"}
+
+	dev := Build(input, types.Environment{Name: "dev"}, "core")
+	assert.Equal(t, []Node{subdomain, db}, dev.Dependencies(api))
+	assert.Empty(t, dev.Dependents(api), "connections in another env's overrides file are not included")
+
+	prod := Build(input, types.Environment{Name: "prod"}, "core")
+	assert.Equal(t, []Node{prodDb, prodSubdomain}, prod.Dependencies(api))
+	assert.Empty(t, prod.Dependents(db))
+	assert.Empty(t, prod.Dependents(api))
+}
+
+// A connection to the same block in another env must not collapse onto the local block
+func TestGraph_crossEnvConnection(t *testing.T) {
+	prodId := int64(11)
+	input := iac.ConfigFiles{
+		Config: &config.EnvConfiguration{
+			IacContext: core.IacContext{Filename: ".nullstone/config.yml"},
+			Blocks: map[string]*config.BlockConfiguration{
+				"a": {Name: "a", Connections: connections(map[string]types.ConnectionTarget{"b": {BlockName: "b"}})},
+				"b": {Name: "b", Connections: connections(map[string]types.ConnectionTarget{
+					"prod_a": {EnvName: "prod", BlockName: "a"},
+					"prod_b": {StackName: "core", EnvId: &prodId, BlockName: "b"},
+				})},
+				"c": {Name: "c", Connections: connections(map[string]types.ConnectionTarget{"a": {EnvName: "dev", BlockName: "a"}})},
+			},
+		},
+	}
+	g := Build(input, types.Environment{IdModel: types.IdModel{Id: 10}, Name: "dev"}, "core")
+
+	assert.Empty(t, g.Validate())
+	assert.Equal(t, []Node{
+		{StackName: "core", EnvName: "11", BlockName: "b", External: true},
+		{StackName: "core", BlockName: "a"},
+		{StackName: "core", BlockName: "b"},
+		{StackName: "core", BlockName: "c"},
+		{StackName: "core", EnvName: "prod", BlockName: "a", External: true},
+	}, g.Nodes())
+	assert.Equal(t, []Node{{StackName: "core", BlockName: "c"}}, g.Dependents(Node{StackName: "core", BlockName: "a"}))
+}
+
+func TestGraph_cycles(t *testing.T) {
+	input := iac.ConfigFiles{
+		Config: &config.EnvConfiguration{
+			IacContext: core.IacContext{Filename: ".nullstone/config.yml"},
+			Blocks: map[string]*config.BlockConfiguration{
+				"a": {Name: "a", Connections: connections(map[string]types.ConnectionTarget{"b": {BlockName: "b"}})},
+				"b": {Name: "b", Connections: connections(map[string]types.ConnectionTarget{"c": {BlockName: "c"}})},
+				"c": {Name: "c", Connections: connections(map[string]types.ConnectionTarget{"a": {BlockName: "a"}})},
+				"d": {Name: "d", Connections: connections(map[string]types.ConnectionTarget{"self": {BlockName: "d"}})},
+				"e": {Name: "e", Connections: connections(map[string]types.ConnectionTarget{"a": {BlockName: "a"}})},
+			},
+		},
+	}
+	g := Build(input, types.Environment{Name: "dev"}, "core")
+
+	cycle1 := core.DependencyCycleError(core.ObjectPathContext{Path: "blocks.a", Field: "connections", Key: "b"}, []string{"core.a", "core.b", "core.c", "core.a"})
+	cycle1.IacContext = input.Config.IacContext
+	cycle2 := core.DependencyCycleError(core.ObjectPathContext{Path: "blocks.d", Field: "connections", Key: "self"}, []string{"core.d", "core.d"})
+	cycle2.IacContext = input.Config.IacContext
+	want := core.ValidateErrors{cycle1, cycle2}
+
+	assert.Equal(t, want, g.Validate())
+	order, errs := g.TopologicalOrder()
+	assert.Nil(t, order)
+	assert.Equal(t, want, errs)
+}
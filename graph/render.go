@@ -0,0 +1,177 @@
+package graph
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/nullstone-io/iac/config"
+	"github.com/nullstone-io/iac/core"
+)
+
+// blockTypeOrder is the order that groups of blocks are rendered in
+var blockTypeOrder = []config.BlockType{
+	config.BlockTypeApplication,
+	config.BlockTypeDatastore,
+	config.BlockTypeSubdomain,
+	config.BlockTypeDomain,
+	config.BlockTypeIngress,
+	config.BlockTypeCluster,
+	config.BlockTypeClusterNamespace,
+	config.BlockTypeNetwork,
+	config.BlockTypeBlock,
+}
+
+const externalGroup = "External"
+
+// diagram contains the blocks and connections declared in an IaC file
+// Blocks that are connected to but not declared in the file (e.g. blocks in another stack) are in the External group
+type diagram struct {
+	stackName string
+	// groups contains the node ids of each group sorted by name
+	groups map[string][]string
+	labels map[string]string
+	edges  []diagramEdge
+}
+
+type diagramEdge struct {
+	from, to, label string
+}
+
+func newDiagram(ec *config.EnvConfiguration, stackName string) diagram {
+	d := diagram{stackName: stackName, groups: map[string][]string{}, labels: map[string]string{}}
+	for _, block := range ec.ToBlocks("", 0) {
+		d.groups[block.Type] = append(d.groups[block.Type], block.Name)
+		d.labels[block.Name] = block.Name
+	}
+
+	external := map[string]bool{}
+	ec.VisitConnections(func(blockName string, pc core.ObjectPathContext, conn *config.ConnectionConfiguration) {
+		target := conn.EffectiveTarget
+		if target.BlockName == "" {
+			target = conn.DesiredTarget
+		}
+		if target.BlockName == "" {
+			return
+		}
+		to := target.BlockName
+		if target.StackName != "" && target.StackName != stackName {
+			to = target.StackName + "." + target.BlockName
+		}
+		if _, ok := d.labels[to]; !ok {
+			external[to] = true
+		}
+		d.edges = append(d.edges, diagramEdge{from: blockName, to: to, label: pc.Key})
+	})
+	for id := range external {
+		d.groups[externalGroup] = append(d.groups[externalGroup], id)
+		d.labels[id] = id
+	}
+
+	for _, ids := range d.groups {
+		slices.Sort(ids)
+	}
+	slices.SortFunc(d.edges, func(a, b diagramEdge) int {
+		return strings.Compare(a.from+"\x00"+a.to+"\x00"+a.label, b.from+"\x00"+b.to+"\x00"+b.label)
+	})
+	return d
+}
+
+// groupNames returns the groups in blockTypeOrder followed by the External group
+func (d diagram) groupNames() []string {
+	result := make([]string, 0, len(d.groups))
+	for _, bt := range blockTypeOrder {
+		if _, ok := d.groups[string(bt)]; ok {
+			result = append(result, string(bt))
+		}
+	}
+	// Include any block type that isn't in blockTypeOrder
+	for _, name := range slices.Sorted(maps.Keys(d.groups)) {
+		if name != externalGroup && !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+	if _, ok := d.groups[externalGroup]; ok {
+		result = append(result, externalGroup)
+	}
+	return result
+}
+
+// RenderDOT renders the blocks and connections in an IaC file as a Graphviz digraph
+// Blocks are grouped by block type and connections are labeled with the connection name
+func RenderDOT(ec *config.EnvConfiguration, stackName string) string {
+	d := newDiagram(ec, stackName)
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "digraph %q {\n", stackName)
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box];\n")
+	for _, group := range d.groupNames() {
+		fmt.Fprintf(&sb, "  subgraph %q {\n", "cluster_"+group)
+		fmt.Fprintf(&sb, "    label=%q;\n", group)
+		if group == externalGroup {
+			sb.WriteString("    style=dashed;\n")
+		}
+		for _, id := range d.groups[group] {
+			if group == externalGroup {
+				fmt.Fprintf(&sb, "    %q [style=dashed];\n", id)
+			} else {
+				fmt.Fprintf(&sb, "    %q;\n", id)
+			}
+		}
+		sb.WriteString("  }\n")
+	}
+	for _, e := range d.edges {
+		fmt.Fprintf(&sb, "  %q -> %q [label=%q];\n", e.from, e.to, e.label)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+var mermaidIdRegexp = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// mermaidIds returns a unique Mermaid node id for each block in the diagram
+// Ids are prefixed so that a block name can't be mistaken for a Mermaid keyword (e.g. end)
+// Block names that only differ by punctuation (e.g. api-db and api_db) receive a numeric suffix in name order
+func mermaidIds(d diagram) map[string]string {
+	result := map[string]string{}
+	used := map[string]bool{}
+	for _, key := range slices.Sorted(maps.Keys(d.labels)) {
+		base := "b_" + mermaidIdRegexp.ReplaceAllString(key, "_")
+		id := base
+		for i := 2; used[id]; i++ {
+			id = fmt.Sprintf("%s_%d", base, i)
+		}
+		used[id] = true
+		result[key] = id
+	}
+	return result
+}
+
+// RenderMermaid renders the blocks and connections in an IaC file as a Mermaid flowchart
+// Blocks are grouped by block type and connections are labeled with the connection name
+func RenderMermaid(ec *config.EnvConfiguration, stackName string) string {
+	d := newDiagram(ec, stackName)
+	ids := mermaidIds(d)
+	sb := strings.Builder{}
+	sb.WriteString("flowchart LR\n")
+	for _, group := range d.groupNames() {
+		fmt.Fprintf(&sb, "  subgraph %s\n", group)
+		for _, id := range d.groups[group] {
+			fmt.Fprintf(&sb, "    %s[%q]", ids[id], d.labels[id])
+			if group == externalGroup {
+				sb.WriteString(":::external")
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("  end\n")
+	}
+	for _, e := range d.edges {
+		fmt.Fprintf(&sb, "  %s -->|%s| %s\n", ids[e.from], e.label, ids[e.to])
+	}
+	if _, ok := d.groups[externalGroup]; ok {
+		sb.WriteString("  classDef external stroke-dasharray: 5 5\n")
+	}
+	return sb.String()
+}
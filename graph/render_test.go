@@ -0,0 +1,131 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/nullstone-io/iac/config"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+func renderInput() *config.EnvConfiguration {
+	return &config.EnvConfiguration{
+		Applications: map[string]*config.AppConfiguration{
+			"api": {
+				BlockConfiguration: config.BlockConfiguration{
+					Type: config.BlockTypeApplication,
+					Name: "api",
+					Connections: connections(map[string]types.ConnectionTarget{
+						"postgres": {StackName: "core", BlockName: "db"},
+						"cluster":  {StackName: "shared", BlockName: "cluster"},
+					}),
+				},
+				Capabilities: config.CapabilityConfigurations{
+					{Connections: connections(map[string]types.ConnectionTarget{"subdomain": {BlockName: "api-subdomain"}})},
+				},
+			},
+		},
+		Datastores: map[string]*config.DatastoreConfiguration{
+			"db": {BlockConfiguration: config.BlockConfiguration{Type: config.BlockTypeDatastore, Name: "db"}},
+		},
+		Subdomains: map[string]*config.SubdomainConfiguration{
+			"api-subdomain": {BlockConfiguration: config.BlockConfiguration{Type: config.BlockTypeSubdomain, Name: "api-subdomain"}},
+		},
+	}
+}
+
+func TestRenderDOT(t *testing.T) {
+	want := `digraph "core" {
+  rankdir=LR;
+  node [shape=box];
+  subgraph "cluster_Application" {
+    label="Application";
+    "api";
+  }
+  subgraph "cluster_Datastore" {
+    label="Datastore";
+    "db";
+  }
+  subgraph "cluster_Subdomain" {
+    label="Subdomain";
+    "api-subdomain";
+  }
+  subgraph "cluster_External" {
+    label="External";
+    style=dashed;
+    "shared.cluster" [style=dashed];
+  }
+  "api" -> "api-subdomain" [label="subdomain"];
+  "api" -> "db" [label="postgres"];
+  "api" -> "shared.cluster" [label="cluster"];
+}
+`
+	assert.Equal(t, want, RenderDOT(renderInput(), "core"))
+}
+
+func TestRenderMermaid(t *testing.T) {
+	want := `flowchart LR
+  subgraph Application
+    b_api["api"]
+  end
+  subgraph Datastore
+    b_db["db"]
+  end
+  subgraph Subdomain
+    b_api_subdomain["api-subdomain"]
+  end
+  subgraph External
+    b_shared_cluster["shared.cluster"]:::external
+  end
+  b_api -->|subdomain| b_api_subdomain
+  b_api -->|postgres| b_db
+  b_api -->|cluster| b_shared_cluster
+  classDef external stroke-dasharray: 5 5
+`
+	assert.Equal(t, want, RenderMermaid(renderInput(), "core"))
+}
+
+func TestRenderMermaid_uniqueIds(t *testing.T) {
+	ec := &config.EnvConfiguration{
+		Applications: map[string]*config.AppConfiguration{
+			"api": {
+				BlockConfiguration: config.BlockConfiguration{
+					Type: config.BlockTypeApplication,
+					Name: "api",
+					Connections: connections(map[string]types.ConnectionTarget{
+						"db":      {BlockName: "api-db"},
+						"replica": {BlockName: "api_db"},
+						"cluster": {StackName: "shared", BlockName: "cluster"},
+						"end":     {BlockName: "end"},
+					}),
+				},
+			},
+		},
+		Blocks: map[string]*config.BlockConfiguration{
+			"api-db":         {Type: config.BlockTypeBlock, Name: "api-db"},
+			"api_db":         {Type: config.BlockTypeBlock, Name: "api_db"},
+			"shared_cluster": {Type: config.BlockTypeBlock, Name: "shared_cluster"},
+			"end":            {Type: config.BlockTypeBlock, Name: "end"},
+		},
+	}
+	want := `flowchart LR
+  subgraph Application
+    b_api["api"]
+  end
+  subgraph Block
+    b_api_db["api-db"]
+    b_api_db_2["api_db"]
+    b_end["end"]
+    b_shared_cluster_2["shared_cluster"]
+  end
+  subgraph External
+    b_shared_cluster["shared.cluster"]:::external
+  end
+  b_api -->|db| b_api_db
+  b_api -->|replica| b_api_db_2
+  b_api -->|end| b_end
+  b_api -->|cluster| b_shared_cluster
+  classDef external stroke-dasharray: 5 5
+`
+	assert.Equal(t, want, RenderMermaid(ec, "core"))
+}
@@ -0,0 +1,79 @@
+package iac
+
+import (
+	"context"
+
+	"github.com/nullstone-io/iac/config"
+	"github.com/nullstone-io/iac/core"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+var (
+	_ core.IacFinder = MultiIacFinder{}
+)
+
+// StackConfigFiles are the IaC files from the repository that manages a stack
+type StackConfigFiles struct {
+	StackId   int64  `json:"stackId"`
+	StackName string `json:"stackName"`
+	ConfigFiles
+
+	// Envs are the stack's environments
+	// These are used to select the overrides file that applies to a connection target
+	Envs []types.Environment `json:"envs"`
+}
+
+func (s StackConfigFiles) matchStack(ct types.ConnectionTarget) bool {
+	if ct.StackId != 0 && s.StackId != 0 {
+		return ct.StackId == s.StackId
+	}
+	return ct.StackName != "" && ct.StackName == s.StackName
+}
+
+func (s StackConfigFiles) findEnv(ct types.ConnectionTarget) *types.Environment {
+	for _, env := range s.Envs {
+		if ct.EnvId != nil && *ct.EnvId == env.Id {
+			return &env
+		}
+		if ct.EnvId == nil && ct.EnvName != "" && ct.EnvName == env.Name {
+			return &env
+		}
+	}
+	return nil
+}
+
+func NewMultiIacFinder(stacks ...StackConfigFiles) *MultiIacFinder {
+	return &MultiIacFinder{Stacks: stacks}
+}
+
+// MultiIacFinder finds block modules across the IaC files of several stacks
+// This allows connections to other stacks and environments to be validated against IaC that has not been synced yet
+// If the block is not found, the caller falls back to the API
+type MultiIacFinder struct {
+	Stacks []StackConfigFiles
+}
+
+// FindBlockModuleInIac looks for a BlockConfiguration in the iac configuration files of the stack that matches the connection target
+// If the connection target refers to an environment that is known, that environment's overrides are also searched
+func (f MultiIacFinder) FindBlockModuleInIac(ctx context.Context, ct types.ConnectionTarget) *types.Module {
+	if ct.BlockName == "" {
+		return nil
+	}
+	for _, stack := range f.Stacks {
+		if !stack.matchStack(ct) {
+			continue
+		}
+		var overrides *config.EnvConfiguration
+		if env := stack.findEnv(ct); env != nil {
+			overrides = stack.GetOverrides(*env)
+		}
+		finder := config.IacFinder{Config: stack.Config, Overrides: overrides, StackId: ct.StackId}
+		if ct.EnvId != nil {
+			finder.EnvId = *ct.EnvId
+		}
+		if module := finder.FindBlockModuleInIac(ctx, ct); module != nil {
+			return module
+		}
+	}
+	return nil
+}
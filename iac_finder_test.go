@@ -0,0 +1,173 @@
+package iac
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/nullstone-io/iac/config"
+	"github.com/nullstone-io/iac/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+func TestMultiIacFinder_FindBlockModuleInIac(t *testing.T) {
+	postgres := &types.Module{Name: "aws-rds-postgres"}
+	aurora := &types.Module{Name: "aws-aurora-postgres"}
+	redis := &types.Module{Name: "aws-redis"}
+	prodId, previewId := int64(10), int64(11)
+
+	finder := NewMultiIacFinder(
+		StackConfigFiles{
+			StackId:   1,
+			StackName: "core",
+			ConfigFiles: ConfigFiles{
+				Config: &config.EnvConfiguration{
+					Blocks: map[string]*config.BlockConfiguration{
+						"db": {Name: "db", Module: postgres},
+					},
+				},
+				Overrides: map[string]*config.EnvConfiguration{
+					"previews": {
+						Blocks: map[string]*config.BlockConfiguration{
+							"cache": {Name: "cache", Module: redis},
+						},
+					},
+				},
+			},
+			Envs: []types.Environment{
+				{IdModel: types.IdModel{Id: prodId}, Name: "prod", Type: types.EnvTypePipeline},
+				{IdModel: types.IdModel{Id: previewId}, Name: "f-123", Type: types.EnvTypePreview},
+			},
+		},
+		StackConfigFiles{
+			StackId:   2,
+			StackName: "other-stack",
+			ConfigFiles: ConfigFiles{
+				Config: &config.EnvConfiguration{
+					Blocks: map[string]*config.BlockConfiguration{
+						"db": {Name: "db", Module: aurora},
+					},
+				},
+			},
+		},
+	)
+
+	tests := map[string]struct {
+		ct   types.ConnectionTarget
+		want *types.Module
+	}{
+		"same block name in different stacks": {
+			ct:   types.ConnectionTarget{StackId: 2, StackName: "other-stack", BlockName: "db", EnvId: &prodId, EnvName: "prod"},
+			want: aurora,
+		},
+		"matches stack by name": {
+			ct:   types.ConnectionTarget{StackName: "core", BlockName: "db", EnvName: "prod"},
+			want: postgres,
+		},
+		"uses overrides for a known env": {
+			ct:   types.ConnectionTarget{StackId: 1, BlockName: "cache", EnvId: &previewId},
+			want: redis,
+		},
+		"ignores overrides for another env": {
+			ct:   types.ConnectionTarget{StackId: 1, BlockName: "cache", EnvId: &prodId},
+			want: nil,
+		},
+		"unknown stack": {
+			ct:   types.ConnectionTarget{StackId: 3, StackName: "unknown", BlockName: "db"},
+			want: nil,
+		},
+		"missing block": {
+			ct:   types.ConnectionTarget{StackId: 2, BlockName: "cache"},
+			want: nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := finder.FindBlockModuleInIac(context.Background(), test.ct)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestNormalize_unsyncedCrossStackTarget(t *testing.T) {
+	// The shared stack exists, but its network block has not been synced yet
+	catalogYml := `version: 1
+modules:
+  - orgName: nullstone
+    name: aws-fargate-service
+    category: app
+    versions:
+      - version: 0.1.0
+        manifest:
+          connections:
+            network:
+              contract: network/aws/vpc
+  - orgName: nullstone
+    name: aws-network
+    category: network
+    providerTypes: [aws]
+    platform: vpc
+    versions:
+      - version: 1.0.0
+        manifest: {}
+stacks:
+  - {id: 1, orgName: acme, name: core}
+  - {id: 2, orgName: acme, name: shared}
+envs:
+  - {id: 10, orgName: acme, name: dev, stackId: 1}
+  - {id: 20, orgName: acme, name: dev, stackId: 2}
+blocks:
+  - {id: 100, orgName: acme, stackId: 1, name: api}
+`
+	apiYml := `version: "0.2"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+    module_version: "0.1.0"
+    connections:
+      network:
+        stack_name: shared
+        block_name: network
+`
+	sharedYml := `version: "0.2"
+networks:
+  network:
+    module: nullstone/aws-network
+    module_version: "1.0.0"
+`
+	ctx := context.Background()
+	catalog, err := core.ParseCatalog(bytes.NewBufferString(catalogYml))
+	require.NoError(t, err)
+	resolver := core.NewSnapshotResolver(catalog, 1, 10)
+	parse := func(t *testing.T, repoName, configYml string) ConfigFiles {
+		input, err := ParseMap("", repoName, map[string]string{".nullstone/config.yml": configYml})
+		require.NoError(t, err)
+		require.Empty(t, Initialize(ctx, input, resolver))
+		return input
+	}
+
+	shared := parse(t, "acme/shared", sharedYml)
+	finder := NewMultiIacFinder(StackConfigFiles{StackId: 2, StackName: "shared", ConfigFiles: shared})
+
+	t.Run("fails without the IaC of the other stack", func(t *testing.T) {
+		input := parse(t, "acme/api", apiYml)
+		errs := Normalize(ctx, input, resolver)
+		require.Len(t, errs, 1)
+		assert.Equal(t, core.CodeInvalidConnection, errs[0].Code)
+	})
+
+	t.Run("resolves the module from the IaC of the other stack", func(t *testing.T) {
+		input := parse(t, "acme/api", apiYml)
+		require.Empty(t, NormalizeWithFinder(ctx, input, resolver, finder))
+		require.Empty(t, Resolve(ctx, input, resolver, finder))
+		require.Empty(t, Validate(input))
+
+		conn := input.Config.Applications["api"].Connections["network"]
+		assert.Equal(t, types.ConnectionTarget{StackName: "shared", BlockName: "network"}, conn.EffectiveTarget)
+		assert.Equal(t, shared.Config.Networks["network"].Module, conn.Module)
+		assert.Nil(t, conn.Block)
+	})
+}
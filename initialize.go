@@ -3,24 +3,31 @@ package iac
 import (
 	"context"
 
+	"github.com/nullstone-io/iac/config"
 	"github.com/nullstone-io/iac/core"
+	"github.com/nullstone-io/iac/lockfile"
 )
 
 // Initialize performs initialization on all blocks and capabilities:
 // - Module schema (variables + connections)
 // - Capability module schema (variables + connections)
 // Initialize is useful as a separate step from Resolve because we need to initialize all Block information before resolving connections
+// If input.Lock is set, module versions are pinned to the versions in the lock file
 func Initialize(ctx context.Context, input ConfigFiles, resolver core.InitializeResolver) core.InitializeErrors {
 	errs := core.InitializeErrors{}
 	if input.Config != nil {
-		for _, err := range input.Config.Initialize(ctx, resolver) {
+		for _, err := range initializeLocked(ctx, input.Config, input.Lock.GetBlocks(""), resolver) {
 			err.IacContext = input.Config.IacContext
+			err.SourcePosition = input.Config.SourceMap.Find(err.ObjectPathContext)
 			errs = append(errs, err)
 		}
 	}
-	for _, cur := range input.Overrides {
-		for _, err := range cur.Initialize(ctx, resolver) {
+	for name, cur := range input.Overrides {
+		// Blocks that omit the module are validated against the module in the primary config file
+		cur.InheritModules(input.Config)
+		for _, err := range initializeLocked(ctx, cur, input.Lock.GetBlocks(name), resolver) {
 			err.IacContext = cur.IacContext
+			err.SourcePosition = cur.SourceMap.Find(err.ObjectPathContext)
 			errs = append(errs, err)
 		}
 	}
@@ -29,3 +36,9 @@ func Initialize(ctx context.Context, input ConfigFiles, resolver core.Initialize
 	}
 	return nil
 }
+
+func initializeLocked(ctx context.Context, ec *config.EnvConfiguration, locked lockfile.LockedBlocks, resolver core.InitializeResolver) core.InitializeErrors {
+	errs := ec.ApplyLock(locked)
+	errs = append(errs, ec.Initialize(ctx, resolver)...)
+	return append(errs, ec.VerifyLock(locked)...)
+}
@@ -0,0 +1,38 @@
+package iac
+
+import (
+	"context"
+
+	"github.com/nullstone-io/iac/core"
+	"github.com/nullstone-io/iac/lockfile"
+)
+
+// GenerateLock records the module version resolved for each block and capability in the IaC files
+// This must be called after Initialize
+func GenerateLock(input ConfigFiles) *lockfile.Lock {
+	lock := lockfile.New()
+	if input.Config != nil {
+		lock.Blocks = input.Config.LockedBlocks()
+	}
+	for name, cur := range input.Overrides {
+		if blocks := cur.LockedBlocks(); len(blocks) > 0 {
+			lock.Overrides[name] = blocks
+		}
+	}
+	return lock
+}
+
+// UpdateLock resolves module versions and returns a new lock file
+// If blockNames is empty, the lock file is regenerated and every module version is resolved again
+// Otherwise, only the modules for blockNames (including their capabilities) are upgraded; other blocks keep the versions in input.Lock
+func UpdateLock(ctx context.Context, input ConfigFiles, resolver core.InitializeResolver, blockNames ...string) (*lockfile.Lock, core.InitializeErrors) {
+	if len(blockNames) == 0 {
+		input.Lock = nil
+	} else {
+		input.Lock = input.Lock.Without(blockNames...)
+	}
+	if errs := Initialize(ctx, input, resolver); len(errs) > 0 {
+		return nil, errs
+	}
+	return GenerateLock(input), nil
+}
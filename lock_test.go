@@ -0,0 +1,159 @@
+package iac
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/nullstone-io/iac/core"
+	"github.com/nullstone-io/iac/lockfile"
+	"github.com/nullstone-io/module/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/nullstone-io/go-api-client.v0/artifacts"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+type lockResolver struct {
+	modules map[string]*types.Module
+}
+
+func (r lockResolver) ResolveModuleVersion(ctx context.Context, source artifacts.ModuleSource, version string) (*types.Module, *types.ModuleVersion, error) {
+	m := r.modules[source.OrgName+"/"+source.ModuleName]
+	if m == nil {
+		return nil, nil, nil
+	}
+	if version == "latest" {
+		return m, &m.Versions[len(m.Versions)-1], nil
+	}
+	if core.IsVersionConstraint(version) {
+		constraint, err := core.ParseVersionConstraint(version)
+		if err != nil {
+			return nil, nil, err
+		}
+		return m, constraint.HighestMatch(m.Versions), nil
+	}
+	for i, cur := range m.Versions {
+		if cur.Version == version {
+			return m, &m.Versions[i], nil
+		}
+	}
+	return m, nil, nil
+}
+
+func (r lockResolver) ResolveWorkspaceModuleConfig(ctx context.Context, ct types.ConnectionTarget) (core.WorkspaceModuleConfig, error) {
+	return core.WorkspaceModuleConfig{}, nil
+}
+
+func lockTestModule(orgName, name string, category types.CategoryName, versions ...string) *types.Module {
+	m := &types.Module{OrgName: orgName, Name: name, Category: category}
+	for _, v := range versions {
+		m.Versions = append(m.Versions, types.ModuleVersion{
+			Version: v,
+			Manifest: config.Manifest{
+				Variables:   map[string]config.Variable{},
+				Connections: map[string]config.Connection{},
+			},
+		})
+	}
+	return m
+}
+
+func TestUpdateLock(t *testing.T) {
+	resolver := lockResolver{
+		modules: map[string]*types.Module{
+			"nullstone/aws-fargate-service": lockTestModule("nullstone", "aws-fargate-service", types.CategoryApp, "0.12.1", "0.12.4", "0.13.0"),
+			"nullstone/aws-s3-cdn":          lockTestModule("nullstone", "aws-s3-cdn", types.CategoryCapability, "1.0.0", "1.1.0"),
+			"nullstone/aws-rds-postgres":    lockTestModule("nullstone", "aws-rds-postgres", types.CategoryDatastore, "0.3.0", "0.4.0"),
+		},
+	}
+	configYml := `version: "0.2"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+    module_version: "~> 0.12.0"
+    capabilities:
+      - module: nullstone/aws-s3-cdn
+datastores:
+  db:
+    module: nullstone/aws-rds-postgres
+`
+	parse := func(t *testing.T, lockYml string) ConfigFiles {
+		files := map[string]string{".nullstone/config.yml": configYml}
+		if lockYml != "" {
+			files[".nullstone/lock.yml"] = lockYml
+		}
+		input, err := ParseMap("", "acme/api", files)
+		require.NoError(t, err)
+		return input
+	}
+
+	// Generate a lock file, then pin versions that are older than the latest
+	lock, errs := UpdateLock(context.Background(), parse(t, ""), resolver)
+	require.Empty(t, errs)
+	assert.Equal(t, "0.12.4", lock.Blocks.Find("api", "").Resolved)
+	assert.Equal(t, "1.1.0", lock.Blocks.Find("api", "aws-s3-cdn").Resolved)
+	assert.Equal(t, "0.4.0", lock.Blocks.Find("db", "").Resolved)
+	lock.Blocks.Find("api", "").Resolved = "0.12.1"
+	lock.Blocks.Find("api", "aws-s3-cdn").Resolved = "1.0.0"
+	lock.Blocks.Find("db", "").Resolved = "0.3.0"
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, lock.Write(buf))
+	pinned := buf.String()
+
+	t.Run("initialize honors the lock file", func(t *testing.T) {
+		input := parse(t, pinned)
+		require.Empty(t, Initialize(context.Background(), input, resolver))
+		assert.Equal(t, "0.12.1", input.Config.Applications["api"].ModuleVersion.Version)
+		assert.Equal(t, "~> 0.12.0", input.Config.Applications["api"].ModuleConstraint)
+		assert.Equal(t, "1.0.0", input.Config.Applications["api"].Capabilities[0].ModuleVersion.Version)
+		assert.Equal(t, "0.3.0", input.Config.Datastores["db"].ModuleVersion.Version)
+	})
+
+	t.Run("constraint that disagrees with the lock file", func(t *testing.T) {
+		input := parse(t, pinned)
+		input.Config.Applications["api"].ModuleConstraint = "~> 0.13.0"
+		errs := Initialize(context.Background(), input, resolver)
+		require.Len(t, errs, 1)
+		assert.Equal(t, core.CodeModuleLockMismatch, errs[0].Code)
+		assert.Equal(t, "apps.api.module_version", errs[0].ObjectPathContext.Context())
+		assert.Equal(t, ".nullstone/config.yml", errs[0].IacContext.Filename)
+	})
+
+	t.Run("manifest that disagrees with the lock file", func(t *testing.T) {
+		input := parse(t, pinned)
+		input.Lock.Blocks.Find("db", "").Digest = "sha256:republished"
+		errs := Initialize(context.Background(), input, resolver)
+		require.Len(t, errs, 1)
+		assert.Equal(t, core.CodeModuleDigestMismatch, errs[0].Code)
+		assert.Equal(t, "datastores.db.module_version", errs[0].ObjectPathContext.Context())
+	})
+
+	t.Run("upgrade a single block", func(t *testing.T) {
+		upgraded, errs := UpdateLock(context.Background(), parse(t, pinned), resolver, "api")
+		require.Empty(t, errs)
+		assert.Equal(t, "0.12.4", upgraded.Blocks.Find("api", "").Resolved)
+		assert.Equal(t, "1.1.0", upgraded.Blocks.Find("api", "aws-s3-cdn").Resolved)
+		assert.Equal(t, "0.3.0", upgraded.Blocks.Find("db", "").Resolved)
+	})
+
+	t.Run("upgrade after initializing the same input", func(t *testing.T) {
+		input := parse(t, pinned)
+		require.Empty(t, Initialize(context.Background(), input, resolver))
+		upgraded, errs := UpdateLock(context.Background(), input, resolver, "api")
+		require.Empty(t, errs)
+		assert.Equal(t, "0.12.4", upgraded.Blocks.Find("api", "").Resolved)
+		assert.Equal(t, "1.1.0", upgraded.Blocks.Find("api", "aws-s3-cdn").Resolved)
+		assert.Equal(t, "0.3.0", upgraded.Blocks.Find("db", "").Resolved)
+
+		regenerated, errs := UpdateLock(context.Background(), input, resolver)
+		require.Empty(t, errs)
+		assert.Equal(t, "0.4.0", regenerated.Blocks.Find("db", "").Resolved)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		parsed, err := lockfile.Parse(bytes.NewBufferString(pinned))
+		require.NoError(t, err)
+		assert.Equal(t, lock, parsed)
+	})
+}
@@ -0,0 +1,174 @@
+// Package lockfile reads and writes .nullstone/lock.yml, which records the module version resolved for each block and capability
+package lockfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/nullstone-io/module/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Filename is the name of the lock file in the .nullstone directory
+	Filename = "lock.yml"
+	// CurrentVersion is the version of the lock file format
+	CurrentVersion = 1
+
+	header = "# This file is generated by Nullstone to pin module versions; do not edit it manually\n"
+)
+
+type Lock struct {
+	Version int `yaml:"version" json:"version"`
+	// Blocks contains the modules locked for .nullstone/config.yml
+	Blocks LockedBlocks `yaml:"blocks,omitempty" json:"blocks,omitempty"`
+	// Overrides contains the modules locked for each overrides file (e.g. .nullstone/prod.yml) keyed by env name (e.g. prod)
+	Overrides map[string]LockedBlocks `yaml:"overrides,omitempty" json:"overrides,omitempty"`
+}
+
+func New() *Lock {
+	return &Lock{Version: CurrentVersion, Blocks: LockedBlocks{}, Overrides: map[string]LockedBlocks{}}
+}
+
+// LockedBlocks is keyed by block name
+type LockedBlocks map[string]*LockedBlock
+
+type LockedBlock struct {
+	LockedModule `yaml:",inline"`
+	// Capabilities is keyed by capability name
+	Capabilities map[string]*LockedModule `yaml:"capabilities,omitempty" json:"capabilities,omitempty"`
+}
+
+// LockedModule records how a module version was resolved
+type LockedModule struct {
+	Module string `yaml:"module,omitempty" json:"module,omitempty"`
+	// ModuleVersion is the module_version in the IaC file (e.g. latest or ~> 0.12)
+	ModuleVersion string `yaml:"module_version,omitempty" json:"moduleVersion,omitempty"`
+	// Resolved is the exact version that was selected for ModuleVersion
+	Resolved string `yaml:"resolved,omitempty" json:"resolved,omitempty"`
+	// Digest identifies the manifest of the resolved version (see ManifestDigest)
+	Digest string `yaml:"digest,omitempty" json:"digest,omitempty"`
+}
+
+func (m LockedModule) IsEmpty() bool {
+	return m.Module == ""
+}
+
+// Parse reads a lock file
+func Parse(r io.Reader) (*Lock, error) {
+	lock := New()
+	if err := yaml.NewDecoder(r).Decode(lock); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("error parsing lock file: %w", err)
+	}
+	if lock.Version > CurrentVersion {
+		return nil, fmt.Errorf("unsupported lock file version %d, the latest supported version is %d", lock.Version, CurrentVersion)
+	}
+	return lock, nil
+}
+
+// Write encodes the lock file as yaml
+func (l *Lock) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(l); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// GetBlocks returns the locked blocks for a config file
+// envName is empty for .nullstone/config.yml
+func (l *Lock) GetBlocks(envName string) LockedBlocks {
+	if l == nil {
+		return nil
+	}
+	if envName == "" {
+		return l.Blocks
+	}
+	return l.Overrides[envName]
+}
+
+// Without returns a copy of the lock that excludes the input blocks in every config file
+func (l *Lock) Without(blockNames ...string) *Lock {
+	result := New()
+	if l == nil {
+		return result
+	}
+	result.Version = l.Version
+	result.Blocks = l.Blocks.without(blockNames)
+	for envName, blocks := range l.Overrides {
+		result.Overrides[envName] = blocks.without(blockNames)
+	}
+	return result
+}
+
+func (s LockedBlocks) without(blockNames []string) LockedBlocks {
+	result := LockedBlocks{}
+	for name, block := range s {
+		if !slices.Contains(blockNames, name) {
+			result[name] = block
+		}
+	}
+	return result
+}
+
+// ManifestDigest computes a digest of a module version's manifest
+// This detects a module version that was republished with a different manifest
+func ManifestDigest(manifest config.Manifest) string {
+	raw, _ := json.Marshal(manifest)
+	sum := sha256.Sum256(raw)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Find returns the locked module for a block, or for one of its capabilities if capabilityName is not empty
+func (s LockedBlocks) Find(blockName, capabilityName string) *LockedModule {
+	block, ok := s[blockName]
+	if !ok || block == nil {
+		return nil
+	}
+	if capabilityName == "" {
+		if block.IsEmpty() {
+			return nil
+		}
+		return &block.LockedModule
+	}
+	return block.Capabilities[capabilityName]
+}
+
+// Set records the locked module for a block, or for one of its capabilities if capabilityName is not empty
+func (s LockedBlocks) Set(blockName, capabilityName string, module LockedModule) {
+	block, ok := s[blockName]
+	if !ok || block == nil {
+		block = &LockedBlock{}
+		s[blockName] = block
+	}
+	if capabilityName == "" {
+		block.LockedModule = module
+		return
+	}
+	if block.Capabilities == nil {
+		block.Capabilities = map[string]*LockedModule{}
+	}
+	block.Capabilities[capabilityName] = &module
+}
+
+// WriteFile writes the lock file to filename (e.g. .nullstone/lock.yml)
+func (l *Lock) WriteFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := l.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
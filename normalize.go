@@ -14,6 +14,7 @@ func Normalize(ctx context.Context, input ConfigFiles, resolver core.NormalizeRe
 	if input.Config != nil {
 		for _, err := range input.Config.Normalize(ctx, resolver) {
 			err.IacContext = input.Config.IacContext
+			err.SourcePosition = input.Config.SourceMap.Find(err.ObjectPathContext)
 			errs = append(errs, err)
 		}
 	}
@@ -21,6 +22,7 @@ func Normalize(ctx context.Context, input ConfigFiles, resolver core.NormalizeRe
 	for _, cur := range input.Overrides {
 		for _, err := range cur.Normalize(ctx, resolver) {
 			err.IacContext = cur.IacContext
+			err.SourcePosition = cur.SourceMap.Find(err.ObjectPathContext)
 			errs = append(errs, err)
 		}
 	}
@@ -30,3 +32,10 @@ func Normalize(ctx context.Context, input ConfigFiles, resolver core.NormalizeRe
 	}
 	return nil
 }
+
+// NormalizeWithFinder performs Normalize, but keeps a connection to a block in another stack as written
+// if the block is declared in IaC (found by iacFinder), but not synced yet
+// Pass the same iacFinder to Resolve so that the connection is resolved with the module from IaC
+func NormalizeWithFinder(ctx context.Context, input ConfigFiles, resolver core.NormalizeResolver, iacFinder core.IacFinder) core.NormalizeErrors {
+	return Normalize(ctx, input, core.IacNormalizeResolver{NormalizeResolver: resolver, Finder: iacFinder})
+}
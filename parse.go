@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/nullstone-io/iac/config"
+	"github.com/nullstone-io/iac/lockfile"
 	yaml2 "github.com/nullstone-io/iac/yaml"
 	"gopkg.in/yaml.v3"
 	"io"
@@ -27,7 +28,36 @@ func (e InvalidYamlError) Unwrap() error {
 	return e.Err
 }
 
+// InvalidYamlErrors is returned when a YAML file has multiple problems (e.g. several unknown fields in strict mode)
+type InvalidYamlErrors []InvalidYamlError
+
+func (s InvalidYamlErrors) Error() string {
+	msgs := make([]string, 0, len(s))
+	for _, e := range s {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (s InvalidYamlErrors) Unwrap() []error {
+	errs := make([]error, 0, len(s))
+	for _, e := range s {
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+type ParseOptions struct {
+	// Strict rejects keys that do not map to a known field (e.g. a typo like `enviroment`)
+	// Each unknown key is reported as an InvalidYamlError wrapping a yaml.UnknownFieldError
+	Strict bool
+}
+
 func ParseMap(repoUrl, repoName string, files map[string]string) (ConfigFiles, error) {
+	return ParseMapWithOptions(repoUrl, repoName, files, ParseOptions{})
+}
+
+func ParseMapWithOptions(repoUrl, repoName string, files map[string]string, opts ParseOptions) (ConfigFiles, error) {
 	result := ConfigFiles{
 		RepoUrl:   repoUrl,
 		RepoName:  repoName,
@@ -36,8 +66,16 @@ func ParseMap(repoUrl, repoName string, files map[string]string) (ConfigFiles, e
 	}
 
 	for filename, raw := range files {
+		if isLockFile(filename) {
+			lock, err := lockfile.Parse(bytes.NewBufferString(raw))
+			if err != nil {
+				return result, InvalidYamlError{ParseContext: repoName, FileName: filename, Err: err}
+			}
+			result.Lock = lock
+			continue
+		}
 		desc, isOverrides := getConfigFileDescription(filename)
-		parsed, err := ParseConfig(repoUrl, repoName, filename, isOverrides, bytes.NewBufferString(raw))
+		parsed, err := ParseConfigWithOptions(repoUrl, repoName, filename, isOverrides, bytes.NewBufferString(raw), opts)
 		if err != nil {
 			return result, err
 		}
@@ -51,23 +89,59 @@ func ParseMap(repoUrl, repoName string, files map[string]string) (ConfigFiles, e
 }
 
 func ParseConfig(repoUrl, repoName, filename string, isOverrides bool, r io.Reader) (*config.EnvConfiguration, error) {
+	return ParseConfigWithOptions(repoUrl, repoName, filename, isOverrides, r, ParseOptions{})
+}
+
+func ParseConfigWithOptions(repoUrl, repoName, filename string, isOverrides bool, r io.Reader, opts ParseOptions) (*config.EnvConfiguration, error) {
 	decoder := yaml.NewDecoder(r)
+	var node yaml.Node
+	if err := decoder.Decode(&node); err != nil {
+		return nil, InvalidYamlError{ParseContext: repoName, FileName: filename, Err: err}
+	}
+	// Build the source map before migrating so that positions refer to the file as written
+	sourceMap := yaml2.BuildSourceMap(&node)
+	original, err := yaml2.Migrate(&node)
+	if err != nil {
+		return nil, InvalidYamlError{ParseContext: repoName, FileName: filename, Err: err}
+	}
+	if opts.Strict {
+		if unknown := yaml2.FindUnknownFields(&node); len(unknown) > 0 {
+			errs := make(InvalidYamlErrors, 0, len(unknown))
+			for _, uf := range unknown {
+				errs = append(errs, InvalidYamlError{ParseContext: repoName, FileName: filename, Err: uf})
+			}
+			return nil, errs
+		}
+	}
 	var obj yaml2.EnvConfiguration
-	if err := decoder.Decode(&obj); err != nil {
+	if err := node.Decode(&obj); err != nil {
 		return nil, InvalidYamlError{ParseContext: repoName, FileName: filename, Err: err}
 	}
-	return config.ConvertConfiguration(repoUrl, repoName, filename, isOverrides, obj), nil
+	ec := config.ConvertConfiguration(repoUrl, repoName, filename, isOverrides, obj)
+	ec.SourceMap = sourceMap
+	// Keep the version as written, the yaml was decoded after migrating to the current version
+	ec.IacContext.Version = original
+	ec.IacContext.Migrated = original != yaml2.CurrentVersion
+	return ec, nil
 }
 
 func ParseConfigFile(repoUrl, repoName, filename string, isOverrides bool) (*config.EnvConfiguration, error) {
+	return ParseConfigFileWithOptions(repoUrl, repoName, filename, isOverrides, ParseOptions{})
+}
+
+func ParseConfigFileWithOptions(repoUrl, repoName, filename string, isOverrides bool, opts ParseOptions) (*config.EnvConfiguration, error) {
 	raw, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	return ParseConfig(repoUrl, repoName, filename, isOverrides, bytes.NewReader(raw))
+	return ParseConfigWithOptions(repoUrl, repoName, filename, isOverrides, bytes.NewReader(raw), opts)
 }
 
 func ParseConfigDir(repoUrl, repoName, dir string) (*ConfigFiles, error) {
+	return ParseConfigDirWithOptions(repoUrl, repoName, dir, ParseOptions{})
+}
+
+func ParseConfigDirWithOptions(repoUrl, repoName, dir string, opts ParseOptions) (*ConfigFiles, error) {
 	pmr := &ConfigFiles{
 		RepoUrl:   repoUrl,
 		RepoName:  repoName,
@@ -85,8 +159,16 @@ func ParseConfigDir(repoUrl, repoName, dir string) (*ConfigFiles, error) {
 		if entry.IsDir() || !isYmlFile(filename) {
 			continue
 		}
+		if isLockFile(filename) {
+			lock, err := parseLockFile(filepath.Join(dir, filename))
+			if err != nil {
+				return nil, InvalidYamlError{ParseContext: repoName, FileName: filename, Err: err}
+			}
+			pmr.Lock = lock
+			continue
+		}
 		desc, isOverrides := getConfigFileDescription(filename)
-		ec, err := ParseConfigFile(repoUrl, repoName, filepath.Join(dir, filename), isOverrides)
+		ec, err := ParseConfigFileWithOptions(repoUrl, repoName, filepath.Join(dir, filename), isOverrides, opts)
 		if err != nil {
 			return nil, fmt.Errorf("cannot parse config file: %w", err)
 		}
@@ -114,3 +196,17 @@ func isYmlFile(filename string) bool {
 	}
 	return false
 }
+
+// isLockFile returns true for `.nullstone/lock.yml`, which is not an IaC file
+func isLockFile(filename string) bool {
+	return path.Base(filename) == lockfile.Filename
+}
+
+func parseLockFile(filename string) (*lockfile.Lock, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return lockfile.Parse(f)
+}
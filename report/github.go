@@ -0,0 +1,84 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/nullstone-io/iac/core"
+)
+
+// WriteGitHubAnnotations writes diagnostics as GitHub Actions workflow commands (e.g. `::error file=...,line=...::message`)
+// When run in a GitHub workflow, each diagnostic is shown inline on the IaC file in a pull request's diff
+func WriteGitHubAnnotations(w io.Writer, diags core.Diagnostics) error {
+	for _, d := range diags {
+		if _, err := io.WriteString(w, GitHubAnnotation(d)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GitHubAnnotation formats a single diagnostic as a GitHub Actions workflow command
+func GitHubAnnotation(d core.Diagnostic) string {
+	var props []string
+	if d.IacContext.Filename != "" {
+		props = append(props, "file="+escapeGitHubProperty(d.IacContext.Filename))
+		if !d.SourcePosition.IsEmpty() {
+			props = append(props, "line="+strconv.Itoa(d.Line))
+			if d.EndLine > 0 {
+				props = append(props, "endLine="+strconv.Itoa(d.EndLine))
+			}
+			if d.Column > 0 {
+				props = append(props, "col="+strconv.Itoa(d.Column))
+			}
+		}
+	}
+	if d.Code != "" {
+		props = append(props, "title="+escapeGitHubProperty(d.Code))
+	}
+
+	message := d.Message
+	if path := d.ObjectPathContext.Context(); path != "" {
+		message = fmt.Sprintf("%s: %s", path, message)
+	}
+	if d.Hint != "" {
+		message = fmt.Sprintf("%s\n%s", message, d.Hint)
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("::")
+	sb.WriteString(gitHubCommand(d.Severity))
+	if len(props) > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(strings.Join(props, ","))
+	}
+	sb.WriteString("::")
+	sb.WriteString(escapeGitHubData(message))
+	return sb.String()
+}
+
+func gitHubCommand(severity core.Severity) string {
+	switch severity {
+	case core.SeverityWarning:
+		return "warning"
+	case core.SeverityInfo:
+		return "notice"
+	default:
+		return "error"
+	}
+}
+
+var (
+	gitHubDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	gitHubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeGitHubData(s string) string {
+	return gitHubDataEscaper.Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return gitHubPropertyEscaper.Replace(s)
+}
@@ -0,0 +1,16 @@
+// Package report formats IaC diagnostics for CI systems (SARIF and GitHub Actions annotations)
+package report
+
+import (
+	"github.com/nullstone-io/iac/core"
+)
+
+// Collect combines the errors from each stage of processing IaC files into a single list of diagnostics
+func Collect(initErrs core.InitializeErrors, normErrs core.NormalizeErrors, resolveErrs core.ResolveErrors, validateErrs core.ValidateErrors) core.Diagnostics {
+	var result core.Diagnostics
+	result = append(result, initErrs.ToDiagnostics()...)
+	result = append(result, normErrs.ToDiagnostics()...)
+	result = append(result, resolveErrs.ToDiagnostics()...)
+	result = append(result, validateErrs.ToDiagnostics()...)
+	return result
+}
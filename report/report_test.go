@@ -0,0 +1,67 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nullstone-io/iac/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// testDiagnostics contains a diagnostic from each stage and a warning
+func testDiagnostics() core.Diagnostics {
+	ic := core.IacContext{RepoName: "acme/api", Filename: ".nullstone/config.yml"}
+	prodIc := core.IacContext{RepoName: "acme/api", Filename: ".nullstone/prod.yml", IsOverrides: true}
+	apiPc := core.NewObjectPathContextKey("apps", "api")
+
+	initErr := core.RequiredModuleError(apiPc)
+	initErr.IacContext = ic
+	initErr.SourcePosition = core.SourcePosition{Line: 3, Column: 3, EndLine: 8}
+
+	normErr := core.InvalidConnectionError(apiPc.SubKey("connections", "postgres"), errors.New("block core/db does not exist"))
+	normErr.IacContext = ic
+	normErr.SourcePosition = core.SourcePosition{Line: 6, Column: 7, EndLine: 6}
+
+	resolveErr := core.FailedSubdomainReservationError(core.NewObjectPathContextKey("subdomains", "api-subdomain"), "api", errors.New("subdomain is already in use"))
+	resolveErr.IacContext = prodIc
+
+	validateErr := core.MismatchedConnectionContractError(apiPc.SubKey("connections", "cluster"), "network", "cluster/aws/ecs:*")
+	validateErr.IacContext = ic
+	validateErr.SourcePosition = core.SourcePosition{Line: 7, Column: 7, EndLine: 7}
+
+	diags := Collect(core.InitializeErrors{*initErr}, core.NormalizeErrors{*normErr}, core.ResolveErrors{resolveErr}, core.ValidateErrors{*validateErr})
+
+	warning := core.DeprecatedDnsNameWarning(core.NewObjectPathContextKey("subdomains", "api-subdomain").SubField("dns_name"), "api.{{ NULLSTONE_ENV }}")
+	warning.IacContext = ic
+	warning.SourcePosition = core.SourcePosition{Line: 12, Column: 5, EndLine: 12}
+	return append(diags, warning)
+}
+
+func assertGolden(t *testing.T, name string, got []byte) {
+	filename := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(filename, got, 0644))
+	}
+	want, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestWriteSARIF(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, WriteSARIF(buf, testDiagnostics()))
+	assertGolden(t, "diagnostics.sarif.json", buf.Bytes())
+}
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, WriteGitHubAnnotations(buf, testDiagnostics()))
+	assertGolden(t, "diagnostics.github.txt", buf.Bytes())
+}
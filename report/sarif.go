@@ -0,0 +1,172 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+
+	"github.com/nullstone-io/iac/core"
+)
+
+const (
+	SarifVersion = "2.1.0"
+	SarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	toolName           = "nullstone-iac"
+	toolInformationUri = "https://docs.nullstone.io"
+)
+
+// WriteSARIF writes diagnostics as a SARIF 2.1.0 log with a single run
+// Each diagnostic code becomes a rule; results refer to the IaC file and object path of each diagnostic
+func WriteSARIF(w io.Writer, diags core.Diagnostics) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(toSarifLog(diags))
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationUri string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	Id                   string             `json:"id"`
+	HelpUri              string             `json:"helpUri,omitempty"`
+	Help                 *sarifMessage      `json:"help,omitempty"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleId     string          `json:"ruleId,omitempty"`
+	RuleIndex  *int            `json:"ruleIndex,omitempty"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations"`
+	Properties sarifProperties `json:"properties"`
+}
+
+type sarifProperties struct {
+	Stage      core.Stage `json:"stage"`
+	Suggestion string     `json:"suggestion,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	Uri string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+func toSarifLog(diags core.Diagnostics) sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{Name: toolName, InformationUri: toolInformationUri, Rules: []sarifRule{}},
+		},
+		Results: []sarifResult{},
+	}
+
+	var codes []string
+	for _, d := range diags {
+		if d.Code != "" && !slices.Contains(codes, d.Code) {
+			codes = append(codes, d.Code)
+		}
+	}
+	slices.Sort(codes)
+	for _, code := range codes {
+		d := diags[slices.IndexFunc(diags, func(d core.Diagnostic) bool { return d.Code == code })]
+		rule := sarifRule{Id: code, HelpUri: d.DocsUrl(), DefaultConfiguration: sarifConfiguration{Level: sarifLevel(d.Severity)}}
+		if d.Hint != "" {
+			rule.Help = &sarifMessage{Text: d.Hint}
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+	}
+
+	for _, d := range diags {
+		result := sarifResult{
+			RuleId:     d.Code,
+			Level:      sarifLevel(d.Severity),
+			Message:    sarifMessage{Text: d.Message},
+			Locations:  []sarifLocation{sarifLocationOf(d)},
+			Properties: sarifProperties{Stage: d.Stage, Suggestion: d.Suggestion},
+		}
+		if i := slices.Index(codes, d.Code); i != -1 {
+			result.RuleIndex = &i
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	return sarifLog{Schema: SarifSchema, Version: SarifVersion, Runs: []sarifRun{run}}
+}
+
+func sarifLocationOf(d core.Diagnostic) sarifLocation {
+	loc := sarifLocation{}
+	if d.IacContext.Filename != "" {
+		loc.PhysicalLocation = &sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{Uri: d.IacContext.Filename},
+		}
+		if !d.SourcePosition.IsEmpty() {
+			loc.PhysicalLocation.Region = &sarifRegion{
+				StartLine:   d.Line,
+				StartColumn: d.Column,
+				EndLine:     d.EndLine,
+			}
+		}
+	}
+	if path := d.ObjectPathContext.Context(); path != "" {
+		loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: path}}
+	}
+	return loc
+}
+
+func sarifLevel(severity core.Severity) string {
+	switch severity {
+	case core.SeverityWarning:
+		return "warning"
+	case core.SeverityInfo:
+		return "note"
+	default:
+		return "error"
+	}
+}
@@ -14,12 +14,14 @@ func Resolve(ctx context.Context, input ConfigFiles, resolver core.ResolveResolv
 	if input.Config != nil {
 		for _, err := range input.Config.Resolve(ctx, resolver, iacFinder) {
 			err.IacContext = input.Config.IacContext
+			err.SourcePosition = input.Config.SourceMap.Find(err.ObjectPathContext)
 			errs = append(errs, err)
 		}
 	}
 	for _, cur := range input.Overrides {
 		for _, err := range cur.Resolve(ctx, resolver, iacFinder) {
 			err.IacContext = cur.IacContext
+			err.SourcePosition = cur.SourceMap.Find(err.ObjectPathContext)
 			errs = append(errs, err)
 		}
 	}
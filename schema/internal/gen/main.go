@@ -0,0 +1,24 @@
+// gen writes the JSON Schema for the current config version into a directory
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/nullstone-io/iac/schema"
+)
+
+func main() {
+	dir := flag.String("dir", ".schema", "directory to write the schema file into")
+	flag.Parse()
+
+	raw, err := schema.GenerateJSON()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := os.WriteFile(filepath.Join(*dir, schema.Filename()), raw, 0644); err != nil {
+		log.Fatalln(err)
+	}
+}
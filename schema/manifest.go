@@ -0,0 +1,170 @@
+package schema
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/nullstone-io/iac"
+	"github.com/nullstone-io/iac/config"
+	"github.com/nullstone-io/iac/tftype"
+	moduleConfig "github.com/nullstone-io/module/config"
+)
+
+// GenerateForConfigFiles builds a schema for a repo's IaC files
+// Each block with a resolved module lists exactly the module's variables and connections
+// The config files must be initialized so that each block's ModuleVersion is populated
+func GenerateForConfigFiles(files iac.ConfigFiles) *Schema {
+	root := Generate()
+
+	envs := make([]*config.EnvConfiguration, 0, len(files.Overrides)+1)
+	if files.Config != nil {
+		envs = append(envs, files.Config)
+	}
+	for _, name := range slices.Sorted(maps.Keys(files.Overrides)) {
+		envs = append(envs, files.Overrides[name])
+	}
+
+	for _, env := range envs {
+		for _, cur := range namedBlocks(env) {
+			if cur.Block.ModuleVersion == nil {
+				continue
+			}
+			prop := root.Properties[cur.Key]
+			if prop.Properties == nil {
+				prop.Properties = map[string]*Schema{}
+			}
+			// The first file that resolves a block wins; overrides usually reuse the module from config.yml
+			if _, ok := prop.Properties[cur.Block.Name]; ok {
+				continue
+			}
+			prop.Properties[cur.Block.Name] = blockSchema(root.Defs[cur.Definition], cur.Block.ModuleVersion.Manifest)
+		}
+	}
+	return root
+}
+
+func blockSchema(def *Schema, manifest moduleConfig.Manifest) *Schema {
+	s := *def
+	s.Properties = maps.Clone(def.Properties)
+	s.Properties["vars"] = variablesSchema(def.Properties["vars"].Description, manifest.Variables)
+	s.Properties["connections"] = connectionsSchema(def.Properties["connections"].Description, manifest.Connections)
+	return &s
+}
+
+func variablesSchema(description string, variables map[string]moduleConfig.Variable) *Schema {
+	s := &Schema{
+		Description:          description,
+		Type:                 "object",
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: false,
+	}
+	for name, v := range variables {
+		prop := terraformTypeSchema(v.Type)
+		prop.Description = v.Description
+		prop.Default = v.Default
+		s.Properties[name] = prop
+	}
+	return s
+}
+
+func connectionsSchema(description string, connections map[string]moduleConfig.Connection) *Schema {
+	s := &Schema{
+		Description:          description,
+		Type:                 "object",
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: false,
+	}
+	for name, c := range connections {
+		desc := "Contract: " + c.Contract
+		if c.Optional {
+			desc += " (optional)"
+		}
+		s.Properties[name] = &Schema{Ref: defsRef + "connection_constraint", Description: desc}
+	}
+	return s
+}
+
+// terraformTypeSchema converts a Terraform type constraint (e.g. list(string)) into a schema
+// A type that cannot be parsed accepts any value
+func terraformTypeSchema(tfType string) *Schema {
+	t, err := tftype.Parse(tfType)
+	if err != nil {
+		return &Schema{}
+	}
+	return typeSchema(t)
+}
+
+func typeSchema(t *tftype.Type) *Schema {
+	switch t.Kind {
+	case tftype.KindString:
+		return &Schema{Type: "string"}
+	case tftype.KindNumber:
+		return &Schema{Type: "number"}
+	case tftype.KindBool:
+		return &Schema{Type: "boolean"}
+	case tftype.KindList, tftype.KindSet:
+		return &Schema{Type: "array", Items: typeSchema(t.Elem)}
+	case tftype.KindTuple:
+		s := &Schema{Type: "array", PrefixItems: []*Schema{}, Items: &Schema{Not: &Schema{}}}
+		for _, elem := range t.Elems {
+			s.PrefixItems = append(s.PrefixItems, typeSchema(elem))
+		}
+		return s
+	case tftype.KindMap:
+		return &Schema{Type: "object", AdditionalProperties: typeSchema(t.Elem)}
+	case tftype.KindObject:
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for _, name := range t.AttributeNames() {
+			attr := t.Attributes[name]
+			s.Properties[name] = typeSchema(attr.Type)
+			if !attr.Optional {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	}
+	return &Schema{}
+}
+
+type namedBlock struct {
+	// Key is the yaml key containing the block (e.g. apps)
+	Key string
+	// Definition is the name of the schema definition for the block type (e.g. app)
+	Definition string
+	Block      *config.BlockConfiguration
+}
+
+func namedBlocks(env *config.EnvConfiguration) []namedBlock {
+	result := make([]namedBlock, 0)
+	add := func(key, definition string, block *config.BlockConfiguration) {
+		result = append(result, namedBlock{Key: key, Definition: definition, Block: block})
+	}
+	for _, cur := range env.Applications {
+		add("apps", "app", &cur.BlockConfiguration)
+	}
+	for _, cur := range env.Blocks {
+		add("blocks", "block", cur)
+	}
+	for _, cur := range env.Clusters {
+		add("clusters", "cluster", &cur.BlockConfiguration)
+	}
+	for _, cur := range env.ClusterNamespaces {
+		add("cluster_namespaces", "cluster_namespace", &cur.BlockConfiguration)
+	}
+	for _, cur := range env.Datastores {
+		add("datastores", "datastore", &cur.BlockConfiguration)
+	}
+	for _, cur := range env.Domains {
+		add("domains", "domain", &cur.BlockConfiguration)
+	}
+	for _, cur := range env.Ingresses {
+		add("ingresses", "ingress", &cur.BlockConfiguration)
+	}
+	for _, cur := range env.Networks {
+		add("networks", "network", &cur.BlockConfiguration)
+	}
+	for _, cur := range env.Subdomains {
+		add("subdomains", "subdomain", &cur.BlockConfiguration)
+	}
+	return result
+}
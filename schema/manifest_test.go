@@ -0,0 +1,57 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/nullstone-io/iac"
+	"github.com/nullstone-io/iac/config"
+	moduleConfig "github.com/nullstone-io/module/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+func TestGenerateForConfigFiles(t *testing.T) {
+	files := iac.ConfigFiles{
+		Config: &config.EnvConfiguration{
+			Applications: map[string]*config.AppConfiguration{
+				"api": {
+					BlockConfiguration: config.BlockConfiguration{
+						Name: "api",
+						ModuleVersion: &types.ModuleVersion{
+							Manifest: moduleConfig.Manifest{
+								Variables: map[string]moduleConfig.Variable{
+									"cpu":   {Type: "number", Description: "CPU units", Default: float64(256)},
+									"ports": {Type: "list(number)"},
+									"tags":  {Type: "map(string)"},
+								},
+								Connections: map[string]moduleConfig.Connection{
+									"cluster-namespace": {Contract: "cluster-namespace/aws/ecs:*"},
+								},
+							},
+						},
+					},
+				},
+				"unresolved": {BlockConfiguration: config.BlockConfiguration{Name: "unresolved"}},
+			},
+		},
+	}
+
+	s := GenerateForConfigFiles(files)
+	api := s.Properties["apps"].Properties["api"]
+	require.NotNil(t, api)
+	assert.NotContains(t, s.Properties["apps"].Properties, "unresolved")
+
+	vars := api.Properties["vars"]
+	assert.Equal(t, false, vars.AdditionalProperties)
+	assert.Equal(t, &Schema{Type: "number", Description: "CPU units", Default: float64(256)}, vars.Properties["cpu"])
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Type: "number"}}, vars.Properties["ports"])
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, vars.Properties["tags"])
+
+	conns := api.Properties["connections"]
+	assert.Equal(t, false, conns.AdditionalProperties)
+	assert.Equal(t, &Schema{Ref: defsRef + "connection_constraint", Description: "Contract: cluster-namespace/aws/ecs:*"}, conns.Properties["cluster-namespace"])
+
+	// The shared definition is left untouched
+	assert.Empty(t, s.Defs["app"].Properties["vars"].Properties)
+}
@@ -0,0 +1,289 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/nullstone-io/iac/yaml"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+//go:generate go run ./internal/gen -dir ../.schema
+
+const (
+	Draft = "https://json-schema.org/draft/2020-12/schema"
+	Id    = "https://nullstone.io/.nullstone/config.yml"
+
+	defsRef = "#/$defs/"
+)
+
+var (
+	envVarNamePattern      = "^[A-Za-z_][A-Za-z0-9_]*$"
+	secretReferencePattern = "^[a-z][a-z0-9\\-]*://[^#]+(#.+)?$"
+	// connectionTargetPattern matches the scalar form of a connection: [[stack.]env.]block
+	connectionTargetPattern = "^(?:([a-zA-Z0-9\\-]+)\\.)?(?:([a-zA-Z0-9\\-]+)\\.)?([a-zA-Z0-9\\-]+)$"
+)
+
+// Schema is the subset of JSON Schema emitted for IaC files
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Id                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Deprecated           bool               `json:"deprecated,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	PatternProperties    map[string]*Schema `json:"patternProperties,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	PrefixItems          []*Schema          `json:"prefixItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Not                  *Schema            `json:"not,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// field is keyed by "<definition>.<yaml key>"
+type field struct {
+	Description string
+	Deprecated  bool
+	Enum        []string
+	Pattern     string
+}
+
+var fields = map[string]field{
+	"config.version":                {Description: "Version of the config file format; older versions are migrated when parsed", Enum: yaml.SupportedVersions()},
+	"config.events":                 {Description: "Notifications sent when actions occur in this environment"},
+	"block.module":                  {Description: "Module source in the form [<org>/]<module>"},
+	"block.module_version":          {Description: "Module version to use: 'latest', an exact version, or a constraint (e.g. '~> 0.12' or '>= 1.2, < 2.0')"},
+	"block.vars":                    {Description: "Values for the module's variables"},
+	"block.connections":             {Description: "Connections to other blocks, keyed by the module's connection name"},
+	"block.is_shared":               {Description: "Shares the block across all environments in the stack"},
+	"block.renamed_vars":            {Description: "Carries workspace values from variables removed by a module upgrade to the variables that replace them (old name: new name)"},
+	"block.metadata":                {Description: "Governance and descriptive metadata"},
+	"metadata.dataclassification":   {Description: "Data sensitivity level", Enum: classificationLevels()},
+	"app.framework":                 {Description: "Application framework"},
+	"app.environment":               {Description: "Environment variables injected into the app"},
+	"app.capabilities":              {Description: "Capabilities attached to the app, as a list or a map keyed by name"},
+	"capability.enabled":            {Description: "Set to false in an overrides file to remove a capability inherited from config.yml"},
+	"named_capability.enabled":      {Description: "Set to false in an overrides file to remove a capability inherited from config.yml"},
+	"capability.renamed_vars":       {Description: "Carries workspace values from variables removed by a module upgrade to the variables that replace them (old name: new name)"},
+	"named_capability.renamed_vars": {Description: "Carries workspace values from variables removed by a module upgrade to the variables that replace them (old name: new name)"},
+	"subdomain.dns_name":            {Description: "Deprecated: use dns.template instead", Deprecated: true},
+	"subdomain_dns.template":        {Description: "Template for the subdomain name (e.g. api.{{ NULLSTONE_ENV }})"},
+	"domain_dns.template":           {Description: "Template for the domain name"},
+	"env_variable_value.value":      {Description: "Value of the env variable"},
+	"env_variable_value.sensitive":  {Description: "Stores the value as a sensitive env variable"},
+	"env_variable_value.secret":     {Description: "Reference to a secret stored outside the repository (e.g. aws-sm://prod/db#password)", Pattern: secretReferencePattern},
+}
+
+var required = map[string][]string{
+	"connection_target": {"block_name"},
+}
+
+// Generate builds a JSON Schema for .nullstone/config.yml from yaml.EnvConfiguration
+func Generate() *Schema {
+	g := &generator{definitions: map[string]*Schema{}}
+	root := g.object(reflect.TypeOf(yaml.EnvConfiguration{}), "config")
+	root.Schema = Draft
+	root.Id = Id
+	root.Title = "Nullstone configuration specification"
+	root.Description = "The Nullstone configuration file is a YAML file defining a platform-agnostic architecture"
+	root.Defs = g.definitions
+	return root
+}
+
+// GenerateJSON renders Generate as indented JSON
+func GenerateJSON() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	encoder := json.NewEncoder(buf)
+	// Descriptions contain <, >, and & (e.g. version constraints) that must stay readable
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(Generate()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Filename is the name of the schema file for the current config version
+func Filename() string {
+	return "config." + yaml.CurrentVersion + ".json"
+}
+
+var (
+	connectionConstraintType     = reflect.TypeOf(yaml.ConnectionConstraint{})
+	capabilityConfigurationsType = reflect.TypeOf(yaml.CapabilityConfigurations{})
+	envVariableConfigurationType = reflect.TypeOf(yaml.EnvVariableConfiguration{})
+	enums                        = map[reflect.Type][]string{
+		reflect.TypeOf(types.EventAction("")): toStrings(types.AllEventActions),
+		reflect.TypeOf(types.EventStatus("")): toStrings(types.AllEventStatuses),
+	}
+)
+
+type generator struct {
+	definitions map[string]*Schema
+}
+
+func (g *generator) ref(name string, build func() *Schema) *Schema {
+	if _, ok := g.definitions[name]; !ok {
+		// Reserve the name before building to stop recursive types from looping
+		g.definitions[name] = nil
+		g.definitions[name] = build()
+	}
+	return &Schema{Ref: defsRef + name}
+}
+
+func (g *generator) typeOf(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if values, ok := enums[t]; ok {
+		return &Schema{Type: "string", Enum: values}
+	}
+
+	switch t {
+	case connectionConstraintType:
+		return g.ref("connection_constraint", func() *Schema {
+			return &Schema{OneOf: []*Schema{
+				{Type: "string", Pattern: connectionTargetPattern},
+				g.ref("connection_target", func() *Schema { return g.object(t, "connection_target") }),
+			}}
+		})
+	case envVariableConfigurationType:
+		return g.ref("env_variable", func() *Schema {
+			return &Schema{OneOf: []*Schema{
+				{Type: "string"},
+				{Type: "number"},
+				{Type: "boolean"},
+				g.ref("env_variable_value", func() *Schema { return g.object(t, "env_variable_value") }),
+			}}
+		})
+	case capabilityConfigurationsType:
+		return g.ref("capabilities", func() *Schema {
+			elem := t.Elem()
+			named := g.ref("named_capability", func() *Schema { return g.object(elem, "named_capability") })
+			unnamed := g.ref("capability", func() *Schema {
+				s := g.object(elem, "capability")
+				delete(s.Properties, "name")
+				return s
+			})
+			return &Schema{OneOf: []*Schema{
+				{Type: "object", AdditionalProperties: unnamed},
+				{Type: "array", Items: named},
+			}}
+		})
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		name := definitionName(t)
+		return g.ref(name, func() *Schema { return g.object(t, name) })
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.typeOf(t.Elem())}
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: g.typeOf(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	}
+	// interface{} accepts any value
+	return &Schema{}
+}
+
+// object builds the schema for a struct, flattening inlined structs into its properties
+func (g *generator) object(t reflect.Type, name string) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false, Required: required[name]}
+	g.addProperties(s, t, name)
+	return s
+}
+
+func (g *generator) addProperties(s *Schema, t reflect.Type, name string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		key, inline := yamlKey(f)
+		if key == "-" {
+			continue
+		}
+		if inline {
+			// Field overrides are looked up on the inlined struct's own definition
+			g.addProperties(s, f.Type, definitionName(f.Type))
+			continue
+		}
+
+		prop := g.property(f)
+		if info, ok := fields[name+"."+key]; ok {
+			prop.Description = info.Description
+			prop.Deprecated = info.Deprecated
+			if info.Enum != nil {
+				prop.Enum = info.Enum
+			}
+			if info.Pattern != "" {
+				prop.Pattern = info.Pattern
+			}
+		}
+		s.Properties[key] = prop
+	}
+}
+
+func (g *generator) property(f reflect.StructField) *Schema {
+	prop := g.typeOf(f.Type)
+	if f.Type.Kind() != reflect.Map {
+		return prop
+	}
+	// Keys of env variable maps are env variable names
+	if f.Type.Elem() == envVariableConfigurationType {
+		return &Schema{
+			Type:                 "object",
+			PatternProperties:    map[string]*Schema{envVarNamePattern: prop.AdditionalProperties.(*Schema)},
+			AdditionalProperties: false,
+		}
+	}
+	return prop
+}
+
+func yamlKey(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("yaml")
+	key, opts, _ := strings.Cut(tag, ",")
+	inline := strings.Contains(opts, "inline")
+	if key == "" && !inline {
+		key = strings.ToLower(f.Name)
+	}
+	return key, inline
+}
+
+var upperRegexp = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// definitionName converts a type name to a definition name (e.g. SubdomainDnsConfiguration => subdomain_dns)
+func definitionName(t reflect.Type) string {
+	name := strings.TrimSuffix(t.Name(), "Configuration")
+	return strings.ToLower(upperRegexp.ReplaceAllString(name, "${1}_${2}"))
+}
+
+func classificationLevels() []string {
+	return toStrings(types.AllClassificationLevels())
+}
+
+func toStrings[T ~string](values []T) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, string(v))
+	}
+	return result
+}
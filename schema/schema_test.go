@@ -0,0 +1,60 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The checked-in schema must match the yaml structs; run `go generate ./schema` after changing them.
+func TestGenerateJSON_golden(t *testing.T) {
+	want, err := os.ReadFile(filepath.Join("..", ".schema", Filename()))
+	require.NoError(t, err)
+	got, err := GenerateJSON()
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "schema is out of date, run `go generate ./schema`")
+	assert.NotContains(t, string(got), `\u003c`, "descriptions must not be HTML-escaped")
+}
+
+func TestGenerate(t *testing.T) {
+	s := Generate()
+
+	t.Run("connection constraint accepts a string or a mapping", func(t *testing.T) {
+		cc := s.Defs["connection_constraint"]
+		require.NotNil(t, cc)
+		require.Len(t, cc.OneOf, 2)
+		assert.Equal(t, "string", cc.OneOf[0].Type)
+		assert.Equal(t, defsRef+"connection_target", cc.OneOf[1].Ref)
+		assert.Equal(t, []string{"block_name"}, s.Defs["connection_target"].Required)
+	})
+
+	t.Run("capabilities accept a map or a list", func(t *testing.T) {
+		caps := s.Defs["capabilities"]
+		require.NotNil(t, caps)
+		require.Len(t, caps.OneOf, 2)
+		assert.Equal(t, "object", caps.OneOf[0].Type)
+		assert.Equal(t, "array", caps.OneOf[1].Type)
+		assert.NotContains(t, s.Defs["capability"].Properties, "name")
+		assert.Contains(t, s.Defs["named_capability"].Properties, "name")
+	})
+
+	t.Run("enums and deprecated fields", func(t *testing.T) {
+		assert.Equal(t, []string{"public", "operational", "customer-content", "restricted", "critical"},
+			s.Defs["metadata"].Properties["dataclassification"].Enum)
+		assert.NotEmpty(t, s.Defs["event"].Properties["actions"].Items.Enum)
+		assert.NotEmpty(t, s.Defs["event"].Properties["statuses"].Items.Enum)
+		assert.True(t, s.Defs["subdomain"].Properties["dns_name"].Deprecated)
+	})
+
+	t.Run("blocks are keyed by name", func(t *testing.T) {
+		apps := s.Properties["apps"]
+		require.NotNil(t, apps)
+		assert.Empty(t, apps.PatternProperties)
+		additional, ok := apps.AdditionalProperties.(*Schema)
+		require.True(t, ok)
+		assert.Equal(t, defsRef+"app", additional.Ref)
+	})
+}
@@ -3,6 +3,7 @@ package oracle
 import (
 	"encoding/json"
 	"github.com/gorilla/mux"
+	"github.com/nullstone-io/iac/core"
 	"gopkg.in/nullstone-io/go-api-client.v0/types"
 	"net/http"
 )
@@ -33,6 +34,13 @@ func MockGetModuleVersions(router *mux.Router, modules ...*types.Module) {
 	findModuleVersion := func(orgName, name, version string) *types.ModuleVersion {
 		for _, m := range modules {
 			if m.OrgName == orgName && m.Name == name {
+				if core.IsVersionConstraint(version) {
+					constraint, err := core.ParseVersionConstraint(version)
+					if err != nil {
+						return nil
+					}
+					return constraint.HighestMatch(m.Versions)
+				}
 				for _, v := range m.Versions {
 					if version == "latest" || v.Version == version {
 						return &v
@@ -0,0 +1,175 @@
+package iac
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/nullstone-io/iac/config"
+	"github.com/nullstone-io/iac/core"
+	"github.com/nullstone-io/iac/lockfile"
+	"gopkg.in/nullstone-io/go-api-client.v0/artifacts"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+// CatalogResolver is a resolver that can export a catalog snapshot (e.g. core.ApiResolver)
+type CatalogResolver interface {
+	core.ResolveResolver
+	core.ConnectionResolver
+}
+
+// ExportCatalog snapshots the modules, blocks, and workspaces that the IaC files reference
+// The catalog can be loaded into a core.SnapshotResolver to process the IaC files without access to the Nullstone API
+// This must be called before Normalize since it resolves the connections as written in the IaC files
+// Modules are exported for every IaC file since Initialize loads the modules of every overrides file
+// Connections resolve to a single env, so connections and workspaces are only looked up for config.yml and the overrides file for env
+// resolver must be scoped to env's stack and env (e.g. core.NewApiResolver(apiClient, env.StackId, env.Id))
+// Stacks and envs in the catalog only contain the ids and names used to resolve connections
+func ExportCatalog(ctx context.Context, input ConfigFiles, env types.Environment, resolver CatalogResolver) (*core.Catalog, error) {
+	e := catalogExporter{resolver: resolver, catalog: core.NewCatalog()}
+	if input.Config != nil {
+		if err := e.addModules(ctx, input.Config, input.Lock.GetBlocks("")); err != nil {
+			return nil, err
+		}
+		if err := e.addTargets(ctx, input.Config); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(input.Overrides)) {
+		if err := e.addModules(ctx, input.Overrides[name], input.Lock.GetBlocks(name)); err != nil {
+			return nil, err
+		}
+	}
+	if overrides := input.GetOverrides(env); overrides != nil {
+		if err := e.addTargets(ctx, overrides); err != nil {
+			return nil, err
+		}
+	}
+	e.catalog.Sort()
+	return e.catalog, nil
+}
+
+type catalogExporter struct {
+	resolver CatalogResolver
+	catalog  *core.Catalog
+}
+
+// addModules adds the modules used by ec along with the versions pinned by the lock file
+func (e catalogExporter) addModules(ctx context.Context, ec *config.EnvConfiguration, locked lockfile.LockedBlocks) error {
+	for _, usage := range ec.ModuleUsages() {
+		if err := e.addModule(ctx, usage.ModuleSource, usage.ModuleConstraint); err != nil {
+			return err
+		}
+		// Initialize resolves the version pinned by the lock file instead of the constraint
+		if lm := locked.Find(usage.BlockName, usage.CapabilityName); lm != nil && lm.Resolved != "" {
+			if err := e.addModule(ctx, usage.ModuleSource, lm.Resolved); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addTargets adds the blocks, connection targets, and event channels that ec references in the resolver's env
+func (e catalogExporter) addTargets(ctx context.Context, ec *config.EnvConfiguration) error {
+	// Blocks that already exist provide the workspace module for overrides that omit the module
+	for _, name := range slices.Sorted(maps.Keys(ec.BlockNames())) {
+		if err := e.addTarget(ctx, types.ConnectionTarget{BlockName: name}); err != nil {
+			return err
+		}
+	}
+	for _, ct := range ec.ConnectionTargets() {
+		if err := e.addTarget(ctx, ct); err != nil {
+			return err
+		}
+	}
+	if usesSlack(ec) {
+		channels, err := e.resolver.ListChannels(ctx, string(types.IntegrationToolSlack))
+		if err != nil {
+			return fmt.Errorf("error listing %s channels: %w", types.IntegrationToolSlack, err)
+		}
+		if e.catalog.Channels == nil {
+			e.catalog.Channels = map[string][]map[string]any{}
+		}
+		e.catalog.Channels[string(types.IntegrationToolSlack)] = channels
+	}
+	return nil
+}
+
+// addModule adds a module with all of its versions
+// The version that matches moduleConstraint is looked up by itself to ensure the catalog contains its manifest
+func (e catalogExporter) addModule(ctx context.Context, moduleSource, moduleConstraint string) error {
+	ms, err := artifacts.ParseSource(moduleSource)
+	if err != nil {
+		return fmt.Errorf("invalid module %q: %w", moduleSource, err)
+	}
+	m, mv, err := e.resolver.ResolveModuleVersion(ctx, *ms, moduleConstraint)
+	if err != nil {
+		return fmt.Errorf("error looking up module %s@%s: %w", moduleSource, moduleConstraint, err)
+	}
+	if m == nil {
+		// Initialize reports the missing module from the catalog the same way it does from the API
+		return nil
+	}
+	e.catalog.AddModule(*m)
+	if mv != nil {
+		e.catalog.AddModuleVersion(m.OrgName, m.Name, *mv)
+	}
+	return nil
+}
+
+// addTarget adds the stack, env, and block of a connection target along with the block's workspace module
+// Targets that do not exist are skipped since they are reported when the IaC files are processed with the catalog
+func (e catalogExporter) addTarget(ctx context.Context, ct types.ConnectionTarget) error {
+	effective, err := e.resolver.ResolveConnection(ctx, ct)
+	if err != nil {
+		if core.IsMissingResource(err) {
+			return nil
+		}
+		return fmt.Errorf("error resolving block %s: %w", ct.BlockName, err)
+	}
+	block, err := e.resolver.ResolveBlock(ctx, effective)
+	if err != nil {
+		if core.IsMissingResource(err) {
+			return nil
+		}
+		return fmt.Errorf("error looking up block %s: %w", ct.BlockName, err)
+	}
+	e.catalog.AddStack(types.Stack{IdModel: types.IdModel{Id: effective.StackId}, OrgName: block.OrgName, Name: effective.StackName})
+	e.catalog.AddBlock(block)
+	if effective.EnvId == nil {
+		return nil
+	}
+	e.catalog.AddEnv(types.Environment{
+		IdModel: types.IdModel{Id: *effective.EnvId},
+		Name:    effective.EnvName,
+		OrgName: block.OrgName,
+		StackId: effective.StackId,
+	})
+
+	wmc, err := e.resolver.ResolveWorkspaceModuleConfig(ctx, effective)
+	if err != nil {
+		return fmt.Errorf("error looking up workspace of block %s: %w", ct.BlockName, err)
+	}
+	if wmc.Module == "" {
+		return nil
+	}
+	e.catalog.AddWorkspaceModuleConfig(core.CatalogWorkspaceModuleConfig{
+		StackId:          effective.StackId,
+		BlockId:          effective.BlockId,
+		EnvId:            *effective.EnvId,
+		Module:           wmc.Module,
+		ModuleConstraint: wmc.ModuleConstraint,
+	})
+	return e.addModule(ctx, wmc.Module, wmc.ModuleConstraint)
+}
+
+func usesSlack(ec *config.EnvConfiguration) bool {
+	for _, event := range ec.Events {
+		if _, ok := event.Targets[string(types.EventTargetSlack)]; ok {
+			return true
+		}
+	}
+	return false
+}
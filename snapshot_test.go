@@ -0,0 +1,159 @@
+package iac
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/nullstone-io/iac/core"
+	"github.com/nullstone-io/module/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
+)
+
+func TestSnapshotResolver(t *testing.T) {
+	catalogYml := `version: 1
+modules:
+  - orgName: nullstone
+    name: aws-fargate-service
+    category: app
+    versions:
+      - version: 0.1.0
+        manifest:
+          connections:
+            network:
+              contract: network/aws/vpc
+      - version: 0.2.0
+        manifest:
+          variables:
+            image:
+              type: string
+          connections:
+            network:
+              contract: network/aws/vpc
+  - orgName: nullstone
+    name: aws-network
+    category: network
+    versions:
+      - version: 1.0.0
+        manifest: {}
+stacks:
+  - {id: 1, orgName: acme, name: core}
+  - {id: 2, orgName: acme, name: shared}
+envs:
+  - {id: 10, orgName: acme, name: dev, stackId: 1}
+  - {id: 20, orgName: acme, name: dev, stackId: 2}
+blocks:
+  - {id: 100, orgName: acme, stackId: 1, name: api}
+  - {id: 200, orgName: acme, stackId: 2, name: network}
+workspaceModuleConfigs:
+  - {stackId: 1, blockId: 100, envId: 10, module: nullstone/aws-fargate-service, moduleConstraint: "0.1.0"}
+  - {stackId: 2, blockId: 200, envId: 20, module: nullstone/aws-network, moduleConstraint: latest}
+`
+	configYml := `version: "0.2"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+    module_version: "0.1.0"
+    connections:
+      network:
+        stack_name: shared
+        block_name: network
+  worker:
+    module: nullstone/aws-fargate-service
+    module_version: "0.1.0"
+    connections:
+      network: api-network
+  api-network:
+    module: nullstone/aws-fargate-service
+    module_version: "0.1.0"
+    connections:
+      network:
+        stack_name: shared
+        block_name: network
+`
+	ctx := context.Background()
+	catalog, err := core.ParseCatalog(bytes.NewBufferString(catalogYml))
+	require.NoError(t, err)
+	env := types.Environment{IdModel: types.IdModel{Id: 10}, StackId: 1, Name: "dev"}
+	parse := func(t *testing.T) ConfigFiles {
+		input, err := ParseMap("", "acme/api", map[string]string{".nullstone/config.yml": configYml})
+		require.NoError(t, err)
+		return input
+	}
+
+	t.Run("processes IaC files offline", func(t *testing.T) {
+		input := parse(t)
+		resolver := core.NewSnapshotResolver(catalog, 1, 10)
+		// worker connects to api-network, which is declared in IaC but does not exist yet
+		resolver.BackfillMissingBlocks(ctx, input.Config.ToBlocks("acme", 1))
+		require.Empty(t, Initialize(ctx, input, resolver))
+		require.Empty(t, Normalize(ctx, input, resolver))
+		require.Empty(t, Resolve(ctx, input, resolver, input.NewIacFinder(env)))
+		require.Empty(t, Validate(input))
+
+		api := input.Config.Applications["api"]
+		assert.Equal(t, "0.1.0", api.ModuleVersion.Version)
+		envId := int64(20)
+		assert.Equal(t, types.ConnectionTarget{StackId: 2, StackName: "shared", BlockId: 200, BlockName: "network", EnvId: &envId, EnvName: "dev"},
+			api.Connections["network"].EffectiveTarget)
+		assert.Equal(t, types.CategoryNetwork, api.Connections["network"].Module.Category)
+	})
+
+	t.Run("missing connection target", func(t *testing.T) {
+		resolver := core.NewSnapshotResolver(catalog, 1, 10)
+		_, err := resolver.ResolveBlock(ctx, types.ConnectionTarget{StackName: "shared", BlockName: "cluster"})
+		assert.True(t, core.IsMissingResource(err))
+		assert.EqualError(t, err, "block shared/cluster does not exist in the catalog snapshot")
+	})
+
+	t.Run("export round trip", func(t *testing.T) {
+		exported, err := ExportCatalog(ctx, parse(t), env, core.NewSnapshotResolver(catalog, 1, 10))
+		require.NoError(t, err)
+		assert.Equal(t, catalog, exported)
+
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, exported.Write(buf))
+		parsed, err := core.ParseCatalog(buf)
+		require.NoError(t, err)
+		assert.Equal(t, exported, parsed)
+	})
+
+	t.Run("export processes every overrides file offline", func(t *testing.T) {
+		source, err := core.ParseCatalog(bytes.NewBufferString(catalogYml))
+		require.NoError(t, err)
+		source.AddModule(types.Module{
+			OrgName:  "nullstone",
+			Name:     "aws-redis",
+			Category: types.CategoryDatastore,
+			Versions: []types.ModuleVersion{{Version: "1.0.0", Manifest: config.Manifest{}}},
+		})
+		source.AddEnv(types.Environment{IdModel: types.IdModel{Id: 11}, OrgName: "acme", Name: "prod", StackId: 1})
+		source.AddWorkspaceModuleConfig(core.CatalogWorkspaceModuleConfig{StackId: 1, BlockId: 100, EnvId: 11, Module: "nullstone/aws-redis", ModuleConstraint: "1.0.0"})
+		files := map[string]string{
+			".nullstone/config.yml": configYml,
+			".nullstone/dev.yml":    "version: \"0.2\"\napps:\n  api:\n    environment:\n      LOG_LEVEL: debug\n",
+			".nullstone/prod.yml":   "version: \"0.2\"\ndatastores:\n  cache:\n    module: nullstone/aws-redis\n    module_version: \"1.0.0\"\n",
+		}
+		input, err := ParseMap("", "acme/api", files)
+		require.NoError(t, err)
+
+		exported, err := ExportCatalog(ctx, input, env, core.NewSnapshotResolver(source, 1, 10))
+		require.NoError(t, err)
+		assert.NotNil(t, exported.FindModule("nullstone", "aws-redis"), "modules in another env's overrides file are exported")
+		for _, cur := range exported.Envs {
+			assert.NotEqual(t, "prod", cur.Name, "connections and workspaces are only looked up in env")
+		}
+
+		input, err = ParseMap("", "acme/api", files)
+		require.NoError(t, err)
+		resolver := core.NewSnapshotResolver(exported, 1, 10)
+		resolver.BackfillMissingBlocks(ctx, input.Config.ToBlocks("acme", 1))
+		require.Empty(t, Initialize(ctx, input, resolver))
+		require.Empty(t, Normalize(ctx, input, resolver))
+		require.Empty(t, Resolve(ctx, input, resolver, input.NewIacFinder(env)))
+		require.Empty(t, Validate(input))
+		assert.Equal(t, "1.0.0", input.Overrides["prod"].Datastores["cache"].ModuleVersion.Version)
+	})
+}
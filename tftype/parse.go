@@ -0,0 +1,236 @@
+package tftype
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parse parses a Terraform type constraint
+// Both commas and newlines are accepted between object attributes
+func Parse(expr string) (*Type, error) {
+	p := &parser{s: expr}
+	t, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q after type", p.s[p.pos:])
+	}
+	return t, nil
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid type expression at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && strings.ContainsRune(" \t\r\n", rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space character or 0 at the end of input
+func (p *parser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *parser) expect(c byte) error {
+	if p.peek() != c {
+		if p.pos >= len(p.s) {
+			return p.errorf("expected %q, got end of input", c)
+		}
+		return p.errorf("expected %q, got %q", c, p.s[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (p *parser) ident() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) && isIdentChar(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *parser) parseType() (*Type, error) {
+	name := p.ident()
+	switch Kind(name) {
+	case KindAny, KindString, KindNumber, KindBool:
+		return &Type{Kind: Kind(name)}, nil
+	case KindList, KindSet, KindMap:
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return &Type{Kind: Kind(name), Elem: elem}, nil
+	case KindTuple:
+		return p.parseTuple()
+	case KindObject:
+		return p.parseObject()
+	case "":
+		if p.pos >= len(p.s) {
+			return nil, p.errorf("expected type, got end of input")
+		}
+		return nil, p.errorf("expected type, got %q", p.s[p.pos])
+	}
+	return nil, p.errorf("unknown type %q", name)
+}
+
+func (p *parser) parseTuple() (*Type, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	if err := p.expect('['); err != nil {
+		return nil, err
+	}
+	t := &Type{Kind: KindTuple, Elems: []*Type{}}
+	for p.peek() != ']' {
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		t.Elems = append(t.Elems, elem)
+		if p.peek() == ',' {
+			p.pos++
+		}
+	}
+	p.pos++
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (p *parser) parseObject() (*Type, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	t := &Type{Kind: KindObject, Attributes: map[string]*Attribute{}}
+	for {
+		c := p.peek()
+		if c == ',' {
+			p.pos++
+			continue
+		}
+		if c == '}' {
+			p.pos++
+			break
+		}
+		if c == 0 {
+			return nil, p.errorf("expected '}', got end of input")
+		}
+		name, err := p.attributeName()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.peek(); c != '=' && c != ':' {
+			return nil, p.errorf("expected '=' after attribute %q", name)
+		}
+		p.pos++
+		attr, err := p.parseAttribute()
+		if err != nil {
+			return nil, err
+		}
+		t.Attributes[name] = attr
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (p *parser) attributeName() (string, error) {
+	if p.peek() == '"' {
+		end := strings.IndexByte(p.s[p.pos+1:], '"')
+		if end < 0 {
+			return "", p.errorf("unterminated attribute name")
+		}
+		name := p.s[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return name, nil
+	}
+	name := p.ident()
+	if name == "" {
+		return "", p.errorf("expected attribute name, got %q", p.s[p.pos])
+	}
+	return name, nil
+}
+
+func (p *parser) parseAttribute() (*Attribute, error) {
+	start := p.pos
+	if p.ident() != "optional" || p.peek() != '(' {
+		p.pos = start
+		t, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		return &Attribute{Type: t}, nil
+	}
+
+	p.pos++
+	t, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	attr := &Attribute{Type: t, Optional: true}
+	if p.peek() == ',' {
+		p.pos++
+		if attr.Default, err = p.rawExpression(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	return attr, nil
+}
+
+// rawExpression consumes an HCL expression up to the closing paren of optional(...)
+func (p *parser) rawExpression() (string, error) {
+	p.skipSpace()
+	start := p.pos
+	depth := 0
+	for ; p.pos < len(p.s); p.pos++ {
+		switch c := p.s[p.pos]; c {
+		case '"':
+			end := strings.IndexByte(p.s[p.pos+1:], '"')
+			if end < 0 {
+				return "", p.errorf("unterminated string")
+			}
+			p.pos += end + 1
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth == 0 {
+				return strings.TrimSpace(p.s[start:p.pos]), nil
+			}
+			depth--
+		}
+	}
+	return "", p.errorf("expected ')', got end of input")
+}
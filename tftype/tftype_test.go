@@ -0,0 +1,160 @@
+package tftype
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr string
+	}{
+		{name: "primitive", expr: "string", want: "string"},
+		{name: "any", expr: " any ", want: "any"},
+		{name: "list", expr: "list(number)", want: "list(number)"},
+		{name: "nested collections", expr: "map(set(string))", want: "map(set(string))"},
+		{name: "tuple", expr: "tuple([string, number, bool])", want: "tuple([string, number, bool])"},
+		{name: "empty tuple", expr: "tuple([])", want: "tuple([])"},
+		{
+			name: "object with commas",
+			expr: "object({ enabled = bool, name = string })",
+			want: "object({enabled = bool, name = string})",
+		},
+		{
+			name: "object with newlines and colons",
+			expr: "object({\n  min: number\n  max: number\n})",
+			want: "object({max = number, min = number})",
+		},
+		{
+			name: "optional attributes",
+			expr: `object({ name = string, port = optional(number, 80), tags = optional(map(string), { env = "dev" }), note = optional(string) })`,
+			want: `object({name = string, note = optional(string), port = optional(number, 80), tags = optional(map(string), { env = "dev" })})`,
+		},
+		{
+			name: "object without separators",
+			expr: "object({ enabled : bool spa_mode : bool document : string })",
+			want: "object({document = string, enabled = bool, spa_mode = bool})",
+		},
+		{name: "whitespace", expr: "  list(string)  ", want: "list(string)"},
+		{name: "attribute named optional", expr: "object({ optional = bool })", want: "object({optional = bool})"},
+		{name: "unknown type", expr: "custom_type", wantErr: `unknown type "custom_type"`},
+		{name: "unterminated", expr: "list(string", wantErr: "expected ')', got end of input"},
+		{name: "trailing input", expr: "string)", wantErr: `unexpected ")" after type`},
+		{name: "empty", expr: "", wantErr: "expected type, got end of input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.expr)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestType_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		expr  string
+		value any
+		want  []string
+	}{
+		{name: "list of numbers", expr: "list(number)", value: []any{1, 2.5}},
+		{name: "list element mismatch", expr: "list(number)", value: []any{1, "a"}, want: []string{"type [1] number"}},
+		{name: "map value mismatch", expr: "map(bool)", value: map[string]any{"a": true, "b": "no"}, want: []string{"type b bool"}},
+		{
+			name:  "object attributes",
+			expr:  "object({ scaling = object({ min = number, max = optional(number) }) })",
+			value: map[string]any{"scaling": map[string]any{"min": "one", "extra": 1}},
+			want:  []string{"type scaling.min number"},
+		},
+		{
+			name:  "missing required attribute",
+			expr:  "object({ enabled = bool, name = optional(string) })",
+			value: map[string]any{},
+			want:  []string{"missing-attribute  object({enabled = bool, name = optional(string)})"},
+		},
+		{name: "tuple length", expr: "tuple([string, number])", value: []any{"a"}, want: []string{"tuple-length  tuple([string, number])"}},
+		{name: "tuple element", expr: "tuple([string, number])", value: []any{"a", "b"}, want: []string{"type [1] number"}},
+		{name: "any accepts anything", expr: "map(any)", value: map[string]any{"a": []any{1}}},
+		{name: "null is accepted", expr: "object({ a = string })", value: nil},
+		{name: "top-level number for string", expr: "string", value: 80, want: []string{"type  string"}},
+		{name: "nested primitive conversions", expr: "object({ port = string, replicas = number, enabled = bool })", value: map[string]any{"port": 80, "replicas": "2", "enabled": "true"}},
+		{name: "map of strings with numbers and bools", expr: "map(string)", value: map[string]any{"port": 80, "debug": false}},
+		{name: "nested unconvertible string", expr: "list(number)", value: []any{"2", "two"}, want: []string{"type [1] number"}},
+		{name: "nested unconvertible bool", expr: "map(bool)", value: map[string]any{"a": "yes"}, want: []string{"type a bool"}},
+		{name: "nested collection for string", expr: "map(string)", value: map[string]any{"a": []any{"b"}}, want: []string{"type a string"}},
+		{
+			name:  "list of objects",
+			expr:  "list(object({ name = string, port = number }))",
+			value: []any{map[string]any{"name": "a", "port": 80}, map[string]any{"name": "b", "port": "http"}},
+			want:  []string{"type [1].port number"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ, err := Parse(tt.expr)
+			require.NoError(t, err)
+			var got []string
+			for _, m := range typ.Validate(tt.value) {
+				got = append(got, string(m.Kind)+" "+m.Path.String()+" "+m.Type.String())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestType_Validate_yaml(t *testing.T) {
+	tests := []struct {
+		name       string
+		expr       string
+		yaml       string
+		compatible bool
+	}{
+		{name: "string", expr: "string", yaml: `"hello world"`, compatible: true},
+		{name: "number", expr: "number", yaml: `42`, compatible: true},
+		{name: "float", expr: "number", yaml: `3.14`, compatible: true},
+		{name: "negative", expr: "number", yaml: `-123`, compatible: true},
+		{name: "bool", expr: "bool", yaml: `true`, compatible: true},
+		{name: "number for string", expr: "string", yaml: `123`, compatible: false},
+		{name: "string for number", expr: "number", yaml: `"not a number"`, compatible: false},
+		{name: "string for bool", expr: "bool", yaml: `"not a bool"`, compatible: false},
+		{name: "list", expr: "list(string)", yaml: `["item1", "item2"]`, compatible: true},
+		{name: "set", expr: "set(string)", yaml: `["unique1", "unique2"]`, compatible: true},
+		{name: "tuple", expr: "tuple([string, number, bool])", yaml: `["string", 123, true]`, compatible: true},
+		{name: "string for list", expr: "list(string)", yaml: `"not a list"`, compatible: false},
+		{name: "number for set", expr: "set(string)", yaml: `123`, compatible: false},
+		{name: "map for tuple", expr: "tuple([string, number, bool])", yaml: `{"not": "a tuple"}`, compatible: false},
+		{name: "map", expr: "map(string)", yaml: `{key1: value1, key2: value2}`, compatible: true},
+		{name: "object", expr: "object({ name = string, enabled = bool, count = number })", yaml: `{name: test, enabled: true, count: 42}`, compatible: true},
+		{name: "string for map", expr: "map(string)", yaml: `"not a map"`, compatible: false},
+		{name: "list for object", expr: "object({ name = string })", yaml: `["not", "an", "object"]`, compatible: false},
+		{name: "null", expr: "object({ name = string })", yaml: `null`, compatible: true},
+		{
+			name:       "list of objects",
+			expr:       "list(object({ name = string, count = number, enabled = bool }))",
+			yaml:       `[{name: item1, count: 10, enabled: true}, {name: item2, count: 20, enabled: false}]`,
+			compatible: true,
+		},
+		{name: "map of any", expr: "map(any)", yaml: `{nested: {deep: {value: found}}, numbers: [1, 2, 3]}`, compatible: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value any
+			require.NoError(t, yaml.Unmarshal([]byte(tt.yaml), &value))
+			typ, err := Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.compatible, len(typ.Validate(value)) == 0)
+		})
+	}
+}
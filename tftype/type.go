@@ -0,0 +1,79 @@
+// Package tftype parses Terraform type constraints and checks values against them
+package tftype
+
+import (
+	"sort"
+	"strings"
+)
+
+type Kind string
+
+const (
+	KindAny    Kind = "any"
+	KindString Kind = "string"
+	KindNumber Kind = "number"
+	KindBool   Kind = "bool"
+	KindList   Kind = "list"
+	KindSet    Kind = "set"
+	KindMap    Kind = "map"
+	KindTuple  Kind = "tuple"
+	KindObject Kind = "object"
+)
+
+// Type is a parsed Terraform type constraint (e.g. list(object({ name = string })))
+type Type struct {
+	Kind Kind
+	// Elem is the element type of list, set, and map
+	Elem *Type
+	// Elems are the element types of a tuple
+	Elems []*Type
+	// Attributes are the attributes of an object
+	Attributes map[string]*Attribute
+}
+
+type Attribute struct {
+	Type     *Type
+	Optional bool
+	// Default is the raw HCL expression of the default in optional(type, default)
+	Default string
+}
+
+// AttributeNames returns the object's attribute names in sorted order
+func (t *Type) AttributeNames() []string {
+	names := make([]string, 0, len(t.Attributes))
+	for name := range t.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String renders the type in Terraform syntax
+func (t *Type) String() string {
+	switch t.Kind {
+	case KindList, KindSet, KindMap:
+		return string(t.Kind) + "(" + t.Elem.String() + ")"
+	case KindTuple:
+		elems := make([]string, 0, len(t.Elems))
+		for _, elem := range t.Elems {
+			elems = append(elems, elem.String())
+		}
+		return "tuple([" + strings.Join(elems, ", ") + "])"
+	case KindObject:
+		attrs := make([]string, 0, len(t.Attributes))
+		for _, name := range t.AttributeNames() {
+			attr := t.Attributes[name]
+			s := attr.Type.String()
+			if attr.Optional {
+				if attr.Default != "" {
+					s = "optional(" + s + ", " + attr.Default + ")"
+				} else {
+					s = "optional(" + s + ")"
+				}
+			}
+			attrs = append(attrs, name+" = "+s)
+		}
+		return "object({" + strings.Join(attrs, ", ") + "})"
+	}
+	return string(t.Kind)
+}
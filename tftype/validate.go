@@ -0,0 +1,186 @@
+package tftype
+
+import (
+	"sort"
+	"strconv"
+)
+
+type MismatchKind string
+
+const (
+	// MismatchType means the value is not of the expected type
+	MismatchType MismatchKind = "type"
+	// MismatchMissingAttribute means an object value is missing a required attribute
+	MismatchMissingAttribute MismatchKind = "missing-attribute"
+	// MismatchTupleLength means a tuple value has the wrong number of elements
+	MismatchTupleLength MismatchKind = "tuple-length"
+)
+
+// PathStep is an object attribute or map key (Key) or a list/set/tuple element (Index)
+type PathStep struct {
+	Key   string
+	Index *int
+}
+
+type Path []PathStep
+
+func (p Path) String() string {
+	s := ""
+	for _, step := range p {
+		if step.Index != nil {
+			s += "[" + strconv.Itoa(*step.Index) + "]"
+		} else if s == "" {
+			s = step.Key
+		} else {
+			s += "." + step.Key
+		}
+	}
+	return s
+}
+
+// Mismatch describes a value, or part of a value, that does not conform to a type
+type Mismatch struct {
+	Kind MismatchKind
+	// Path is the location of the offending value relative to the validated value
+	Path Path
+	// Type is the expected type at Path
+	Type  *Type
+	Value any
+	// Attribute is the missing attribute for MismatchMissingAttribute
+	Attribute string
+}
+
+// Validate checks a value decoded from YAML or JSON against the type
+// Null values are accepted anywhere
+// The top-level value must match a primitive type exactly
+// Nested values allow Terraform's primitive conversions (e.g. 80 for string or "true" for bool in a map(string) or object)
+func (t *Type) Validate(value any) []Mismatch {
+	return t.validate(nil, value)
+}
+
+func (t *Type) validate(path Path, value any) []Mismatch {
+	if len(path) > 0 && t.convertible(value) {
+		return nil
+	}
+	if value == nil || t.Kind == KindAny {
+		return nil
+	}
+	mismatch := func() []Mismatch {
+		return []Mismatch{{Kind: MismatchType, Path: path, Type: t, Value: value}}
+	}
+
+	switch t.Kind {
+	case KindString:
+		if _, ok := value.(string); !ok {
+			return mismatch()
+		}
+	case KindNumber:
+		if !isNumeric(value) {
+			return mismatch()
+		}
+	case KindBool:
+		if _, ok := value.(bool); !ok {
+			return mismatch()
+		}
+	case KindList, KindSet:
+		items, ok := value.([]any)
+		if !ok {
+			return mismatch()
+		}
+		var result []Mismatch
+		for i, item := range items {
+			result = append(result, t.Elem.validate(path.index(i), item)...)
+		}
+		return result
+	case KindTuple:
+		items, ok := value.([]any)
+		if !ok {
+			return mismatch()
+		}
+		if len(items) != len(t.Elems) {
+			return []Mismatch{{Kind: MismatchTupleLength, Path: path, Type: t, Value: value}}
+		}
+		var result []Mismatch
+		for i, item := range items {
+			result = append(result, t.Elems[i].validate(path.index(i), item)...)
+		}
+		return result
+	case KindMap:
+		m, ok := value.(map[string]any)
+		if !ok {
+			return mismatch()
+		}
+		var result []Mismatch
+		for _, key := range sortedKeys(m) {
+			result = append(result, t.Elem.validate(path.key(key), m[key])...)
+		}
+		return result
+	case KindObject:
+		m, ok := value.(map[string]any)
+		if !ok {
+			return mismatch()
+		}
+		// Like Terraform, attributes that are not in the type are discarded rather than rejected
+		var result []Mismatch
+		for _, name := range t.AttributeNames() {
+			attr := t.Attributes[name]
+			v, ok := m[name]
+			if !ok {
+				if !attr.Optional {
+					result = append(result, Mismatch{Kind: MismatchMissingAttribute, Path: path, Type: t, Value: value, Attribute: name})
+				}
+				continue
+			}
+			result = append(result, attr.Type.validate(path.key(name), v)...)
+		}
+		return result
+	}
+	return nil
+}
+
+// convertible returns true if Terraform converts a primitive value to the primitive type t
+func (t *Type) convertible(value any) bool {
+	switch t.Kind {
+	case KindString:
+		_, isBool := value.(bool)
+		return isBool || isNumeric(value)
+	case KindNumber:
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil
+	case KindBool:
+		return value == "true" || value == "false"
+	}
+	return false
+}
+
+func (p Path) key(key string) Path {
+	return append(p[:len(p):len(p)], PathStep{Key: key})
+}
+
+func (p Path) index(i int) Path {
+	return append(p[:len(p):len(p)], PathStep{Index: &i})
+}
+
+// isNumeric returns true for any Go numeric type that YAML or JSON might produce.
+func isNumeric(value any) bool {
+	switch value.(type) {
+	case int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return true
+	}
+	return false
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
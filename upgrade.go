@@ -0,0 +1,83 @@
+package iac
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	yaml2 "github.com/nullstone-io/iac/yaml"
+	"gopkg.in/yaml.v3"
+)
+
+// UpgradeConfig reads an IaC file, migrates it to the current IaC version, and writes the upgraded yaml to w
+// This returns the version of the input file; if it matches yaml2.CurrentVersion, the file did not need upgraded
+// Comments are preserved, but formatting may change since the yaml is re-encoded
+func UpgradeConfig(r io.Reader, w io.Writer) (string, error) {
+	var node yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&node); err != nil {
+		return "", err
+	}
+	original, err := yaml2.Migrate(&node)
+	if err != nil {
+		return original, err
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return original, err
+	}
+	return original, encoder.Close()
+}
+
+// UpgradeConfigFile migrates an IaC file to the current IaC version in place
+// The file is only rewritten if it was on an older version
+func UpgradeConfigFile(filename string) (bool, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+	buf := bytes.NewBuffer(nil)
+	original, err := UpgradeConfig(bytes.NewReader(raw), buf)
+	if err != nil {
+		return false, fmt.Errorf("cannot upgrade config file (%s): %w", filename, err)
+	}
+	if original == yaml2.CurrentVersion {
+		return false, nil
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(filename, buf.Bytes(), info.Mode())
+}
+
+// UpgradeConfigDir migrates every IaC file in dir (e.g. `.nullstone/`) to the current IaC version in place
+// The lock file is not an IaC file and is left untouched
+// This returns the list of files that were upgraded
+func UpgradeConfigDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	upgraded := make([]string, 0)
+	for _, entry := range entries {
+		filename := entry.Name()
+		if entry.IsDir() || !isYmlFile(filename) || isLockFile(filename) {
+			continue
+		}
+		fullPath := filepath.Join(dir, filename)
+		changed, err := UpgradeConfigFile(fullPath)
+		if err != nil {
+			return upgraded, err
+		}
+		if changed {
+			upgraded = append(upgraded, fullPath)
+		}
+	}
+	return upgraded, nil
+}
@@ -0,0 +1,44 @@
+package iac
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nullstone-io/iac/lockfile"
+	yaml2 "github.com/nullstone-io/iac/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradeConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("version: \"0.1\"\napps:\n  api:\n    module: nullstone/aws-fargate-service\n"), 0644))
+	currentPath := filepath.Join(dir, "prod.yml")
+	require.NoError(t, os.WriteFile(currentPath, []byte("version: \""+yaml2.CurrentVersion+"\"\n"), 0644))
+	lock := lockfile.New()
+	lock.Blocks.Set("api", "", lockfile.LockedModule{Module: "nullstone/aws-fargate-service", ModuleVersion: "latest", Resolved: "0.12.4"})
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, lock.Write(buf))
+	lockPath := filepath.Join(dir, lockfile.Filename)
+	require.NoError(t, os.WriteFile(lockPath, buf.Bytes(), 0644))
+
+	parsed, err := ParseConfigFile("", "acme/api", configPath, false)
+	require.NoError(t, err)
+	assert.Equal(t, "0.1", parsed.IacContext.Version, "parsing keeps the version as written")
+	assert.True(t, parsed.IacContext.Migrated)
+
+	upgraded, err := UpgradeConfigDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{configPath}, upgraded)
+
+	raw, err := os.ReadFile(lockPath)
+	require.NoError(t, err)
+	assert.Equal(t, buf.String(), string(raw), "lock file should not be modified")
+	parsed, err = ParseConfigFile("", "acme/api", configPath, false)
+	require.NoError(t, err)
+	assert.Equal(t, yaml2.CurrentVersion, parsed.IacContext.Version)
+	assert.False(t, parsed.IacContext.Migrated)
+}
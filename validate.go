@@ -11,6 +11,7 @@ func Validate(input ConfigFiles) core.ValidateErrors {
 	if input.Config != nil {
 		for _, err := range input.Config.Validate() {
 			err.IacContext = input.Config.IacContext
+			err.SourcePosition = input.Config.SourceMap.Find(err.ObjectPathContext)
 			errs = append(errs, err)
 		}
 	}
@@ -18,6 +19,7 @@ func Validate(input ConfigFiles) core.ValidateErrors {
 	for _, cur := range input.Overrides {
 		for _, err := range cur.Validate() {
 			err.IacContext = cur.IacContext
+			err.SourcePosition = cur.SourceMap.Find(err.ObjectPathContext)
 			errs = append(errs, err)
 		}
 	}
@@ -26,3 +28,29 @@ func Validate(input ConfigFiles) core.ValidateErrors {
 	}
 	return nil
 }
+
+// ValidateWarnings reports configuration in the parsed IaC files that is valid, but deprecated or risky
+// Warnings should be reported to the user without failing the sync
+// Each warning includes a suggested replacement snippet (see core.Diagnostic.Suggestion)
+func ValidateWarnings(input ConfigFiles) core.Diagnostics {
+	warnings := core.Diagnostics{}
+	if input.Config != nil {
+		for _, w := range input.Config.Warnings() {
+			w.IacContext = input.Config.IacContext
+			w.SourcePosition = input.Config.SourceMap.Find(w.ObjectPathContext)
+			warnings = append(warnings, w)
+		}
+	}
+
+	for _, cur := range input.Overrides {
+		for _, w := range cur.Warnings() {
+			w.IacContext = cur.IacContext
+			w.SourcePosition = cur.SourceMap.Find(w.ObjectPathContext)
+			warnings = append(warnings, w)
+		}
+	}
+	if len(warnings) > 0 {
+		return warnings
+	}
+	return nil
+}
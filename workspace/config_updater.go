@@ -11,6 +11,7 @@ import (
 
 var (
 	_ core.WorkspaceConfigUpdater  = ConfigUpdater{}
+	_ core.TemplateContextUpdater  = ConfigUpdater{}
 	_ core.CapabilityConfigUpdater = CapabilityConfigUpdater{}
 
 	DefaultModuleConstraint = "latest"
@@ -21,9 +22,9 @@ type ConfigUpdater struct {
 	TemplateVars
 }
 
-func (w ConfigUpdater) UpdateSchema(moduleSource, moduleConstraint string, moduleVersion *types.ModuleVersion) {
+func (w ConfigUpdater) UpdateSchema(moduleSource, moduleConstraint string, moduleVersion *types.ModuleVersion, renamedVariables map[string]string) core.SchemaFindings {
 	if moduleVersion == nil {
-		return
+		return nil
 	}
 	if moduleSource != "" {
 		w.Config.Source = moduleSource
@@ -40,23 +41,34 @@ func (w ConfigUpdater) UpdateSchema(moduleSource, moduleConstraint string, modul
 	if w.Config.Connections == nil {
 		w.Config.Connections = types.Connections{}
 	}
-	ModuleSchema(moduleVersion.Manifest).UpdateSchema(w.Config.Variables, w.Config.Connections)
+	return ModuleSchema(moduleVersion.Manifest).UpdateSchema(w.Config.Variables, w.Config.Connections, renamedVariables)
+}
+
+// WithTemplateContext returns an updater that renders templates for block in env
+func (w ConfigUpdater) WithTemplateContext(block types.Block, env types.Environment, repoName, repoUrl string) core.WorkspaceConfigUpdater {
+	w.TemplateVars = w.TemplateVars.WithBlock(block, env, repoName, repoUrl)
+	return w
 }
 
-func (w ConfigUpdater) UpdateVariableValue(name string, value any) {
+func (w ConfigUpdater) UpdateVariableValue(name string, value any) error {
 	existing, ok := w.Config.Variables[name]
 	if !ok {
-		return
+		return nil
+	}
+	value, err := w.TemplateVars.RenderValue(value)
+	if err != nil {
+		return fmt.Errorf("error rendering variable %q: %w", name, err)
 	}
 	// if the existing value is nil, it means it hasn't been set and we are using the default value
 	// if someone tries to set the value to the default value, we don't need to do anything
 	// this allows us to avoid some very challenging scenarios in workspace_changes
 	// without this, variables will show up as "changed" rows in the UI but the values are the same
 	if existing.Value == nil && reflect.DeepEqual(value, existing.Variable.Default) {
-		return
+		return nil
 	}
 	existing.Value = value
 	w.Config.Variables[name] = existing
+	return nil
 }
 
 func (w ConfigUpdater) UpdateConnectionTarget(name string, desired, effective types.ConnectionTarget) {
@@ -70,7 +82,15 @@ func (w ConfigUpdater) UpdateConnectionTarget(name string, desired, effective ty
 	w.Config.Connections[name] = existing
 }
 
-func (w ConfigUpdater) AddOrUpdateEnvVariable(name string, value string, sensitive bool) {
+func (w ConfigUpdater) AddOrUpdateEnvVariable(name string, value string, sensitive bool) error {
+	if !sensitive {
+		// Sensitive values are never rendered, a secret could contain "{{"
+		rendered, err := w.TemplateVars.TemplateContext().Render(value)
+		if err != nil {
+			return fmt.Errorf("error rendering env variable %q: %w", name, err)
+		}
+		value = rendered
+	}
 	envVar, ok := w.Config.EnvVariables[name]
 	// if we find the env variable, just update the value and sensitive flag
 	if ok {
@@ -87,6 +107,7 @@ func (w ConfigUpdater) AddOrUpdateEnvVariable(name string, value string, sensiti
 		w.Config.EnvVariables = types.EnvVariables{}
 	}
 	w.Config.EnvVariables[name] = envVar
+	return nil
 }
 
 func (w ConfigUpdater) RemoveEnvVariablesNotIn(envVariables map[string]string) {
@@ -108,6 +129,7 @@ func (w ConfigUpdater) GetCapabilityUpdater(identity core.CapabilityIdentity) co
 			return CapabilityConfigUpdater{
 				WorkspaceConfig: w.Config,
 				Index:           i,
+				TemplateVars:    w.TemplateVars,
 			}
 		}
 	}
@@ -119,11 +141,13 @@ func (w ConfigUpdater) AddCapability(id int64, name string) core.CapabilityConfi
 	ccu := CapabilityConfigUpdater{
 		WorkspaceConfig: w.Config,
 		Index:           len(w.Config.Capabilities) - 1,
+		TemplateVars:    w.TemplateVars,
 	}
 	return ccu
 }
 
 func (w ConfigUpdater) RemoveCapabilitiesNotIn(identities core.CapabilityIdentities) {
+	w.migrateCapabilityNames(identities)
 	result := make(types.CapabilityConfigs, 0)
 	for _, cur := range w.Config.Capabilities {
 		found := identities.Find(core.CapabilityIdentity{
@@ -139,6 +163,61 @@ func (w ConfigUpdater) RemoveCapabilitiesNotIn(identities core.CapabilityIdentit
 	w.Config.Capabilities = result
 }
 
+func (w ConfigUpdater) RemoveCapability(identity core.CapabilityIdentity) {
+	for i, cur := range w.Config.Capabilities {
+		found := identity.Match(core.CapabilityIdentity{
+			Name:              cur.Name,
+			ModuleSource:      cur.Source,
+			ConnectionTargets: cur.Connections.DesiredTargets(),
+		})
+		if found {
+			w.Config.Capabilities = append(w.Config.Capabilities[:i], w.Config.Capabilities[i+1:]...)
+			return
+		}
+	}
+}
+
+// migrateCapabilityNames names capabilities that were created without a name
+// A capability that matches a named IaC capability by module source and connections takes that name
+// Otherwise, it takes the name of the IaC capability with the same module source that shares the most connection targets
+// The rest receive the same names that IaC assigns to name-less capabilities so that they keep matching when connections change
+// TfId is left untouched so the capability is not recreated
+func (w ConfigUpdater) migrateCapabilityNames(identities core.CapabilityIdentities) {
+	names := make([]string, len(w.Config.Capabilities))
+	moduleSources := make([]string, len(w.Config.Capabilities))
+	used := map[string]bool{}
+	for i, cur := range w.Config.Capabilities {
+		names[i] = cur.Name
+		moduleSources[i] = cur.Source
+		used[cur.Name] = true
+	}
+	for i, cur := range w.Config.Capabilities {
+		if cur.Name != "" {
+			continue
+		}
+		found := identities.Find(core.CapabilityIdentity{
+			ModuleSource:      cur.Source,
+			ConnectionTargets: cur.Connections.DesiredTargets(),
+		})
+		if found != nil && found.Name != "" && !used[found.Name] {
+			names[i] = found.Name
+			used[found.Name] = true
+		}
+	}
+	for i, cur := range w.Config.Capabilities {
+		if names[i] != "" {
+			continue
+		}
+		if found := closestCapabilityIdentity(identities, cur, used); found != "" {
+			names[i] = found
+			used[found] = true
+		}
+	}
+	for i, name := range core.AssignCapabilityNames(names, moduleSources) {
+		w.Config.Capabilities[i].Name = name
+	}
+}
+
 func (w ConfigUpdater) UpdateDomainName(domainNameTemplate *string) {
 	extra := w.Config.Extra
 
@@ -190,9 +269,11 @@ func (w ConfigUpdater) UpdateDataClassification(level types.ClassificationLevel)
 type CapabilityConfigUpdater struct {
 	WorkspaceConfig *types.WorkspaceConfig
 	Index           int
+	TemplateVars
 }
 
-func (c CapabilityConfigUpdater) UpdateSchema(moduleSource, moduleConstraint string, moduleVersion *types.ModuleVersion) {
+func (c CapabilityConfigUpdater) UpdateSchema(moduleSource, moduleConstraint string, moduleVersion *types.ModuleVersion, renamedVariables map[string]string) core.SchemaFindings {
+	var findings core.SchemaFindings
 	c.doOperation(func(cc *types.CapabilityConfig) {
 		if moduleVersion == nil {
 			return
@@ -211,19 +292,28 @@ func (c CapabilityConfigUpdater) UpdateSchema(moduleSource, moduleConstraint str
 		if cc.Connections == nil {
 			cc.Connections = types.Connections{}
 		}
-		ModuleSchema(moduleVersion.Manifest).UpdateSchema(cc.Variables, cc.Connections)
+		findings = ModuleSchema(moduleVersion.Manifest).UpdateSchema(cc.Variables, cc.Connections, renamedVariables)
+		for i := range findings {
+			findings[i].CapabilityName = cc.Name
+		}
 	})
+	return findings
 }
 
-func (c CapabilityConfigUpdater) UpdateVariableValue(name string, value any) {
+func (c CapabilityConfigUpdater) UpdateVariableValue(name string, value any) error {
+	rendered, err := c.TemplateVars.RenderValue(value)
+	if err != nil {
+		return fmt.Errorf("error rendering variable %q: %w", name, err)
+	}
 	c.doOperation(func(cc *types.CapabilityConfig) {
 		existingVar, ok := cc.Variables[name]
 		if !ok {
 			return
 		}
-		existingVar.Value = value
+		existingVar.Value = rendered
 		cc.Variables[name] = existingVar
 	})
+	return nil
 }
 
 func (c CapabilityConfigUpdater) UpdateConnectionTarget(name string, desired, effective types.ConnectionTarget) {
@@ -252,3 +342,31 @@ func (c CapabilityConfigUpdater) doOperation(fn func(cc *types.CapabilityConfig)
 	fn(&capConfig)
 	c.WorkspaceConfig.Capabilities[c.Index] = capConfig
 }
+
+// closestCapabilityIdentity returns the name of the unused IaC capability with the same module source as cur that shares the most connection targets
+// An empty name is returned if no connection target matches or if several capabilities share the most connection targets
+func closestCapabilityIdentity(identities core.CapabilityIdentities, cur types.CapabilityConfig, used map[string]bool) string {
+	targets := cur.Connections.DesiredTargets()
+	best, bestCount, tied := "", 0, false
+	for _, identity := range identities {
+		if identity.Name == "" || used[identity.Name] || identity.ModuleSource != cur.Source {
+			continue
+		}
+		count := 0
+		for name, target := range identity.ConnectionTargets {
+			if other, ok := targets[name]; ok && other.Match(target) {
+				count++
+			}
+		}
+		switch {
+		case count > bestCount:
+			best, bestCount, tied = identity.Name, count, false
+		case count == bestCount && count > 0:
+			tied = true
+		}
+	}
+	if tied {
+		return ""
+	}
+	return best
+}
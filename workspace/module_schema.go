@@ -1,13 +1,23 @@
 package workspace
 
 import (
+	"cmp"
+	"slices"
+
+	"github.com/nullstone-io/iac/core"
 	"github.com/nullstone-io/module/config"
 	"gopkg.in/nullstone-io/go-api-client.v0/types"
 )
 
 type ModuleSchema config.Manifest
 
-func (s ModuleSchema) UpdateSchema(variables types.Variables, connections types.Connections) {
+// UpdateSchema syncs variables and connections to the module's manifest
+// Variables and connections that are not in the manifest are removed
+// A removed variable that is a key in renamedVariables moves its value to the new variable if the new variable is not set
+// This returns a finding for each removed variable or connection that had a value
+func (s ModuleSchema) UpdateSchema(variables types.Variables, connections types.Connections, renamedVariables map[string]string) core.SchemaFindings {
+	findings := core.SchemaFindings{}
+
 	// Add Variables defined in moduleVersion, but not in DesiredConfig
 	// Update Variable schema if in DesiredConfig
 	for k, v := range s.Variables {
@@ -19,10 +29,27 @@ func (s ModuleSchema) UpdateSchema(variables types.Variables, connections types.
 		}
 	}
 	// Remove Variables not in input moduleVersion schema
-	for k, _ := range variables {
-		if _, ok := s.Variables[k]; !ok {
-			delete(variables, k)
+	for k, existing := range variables {
+		if _, ok := s.Variables[k]; ok {
+			continue
+		}
+		delete(variables, k)
+		if !existing.HasValue() {
+			continue
+		}
+		finding := core.SchemaFinding{ChangeType: types.ChangeTypeVariable, Name: k, Action: core.SchemaFindingDropped}
+		if !existing.Sensitive {
+			finding.Value = existing.Value
 		}
+		if newName, ok := renamedVariables[k]; ok {
+			if renamed, ok := variables[newName]; ok && !renamed.HasValue() {
+				renamed.Value = existing.Value
+				variables[newName] = renamed
+				finding.Action = core.SchemaFindingRenamed
+				finding.RenamedTo = newName
+			}
+		}
+		findings = append(findings, finding)
 	}
 
 	// Add Connections defined in moduleVersion, but not in DesiredConfig
@@ -36,9 +63,23 @@ func (s ModuleSchema) UpdateSchema(variables types.Variables, connections types.
 		}
 	}
 	// Remove Connections not in input moduleVersion schema
-	for k, _ := range connections {
-		if _, ok := s.Connections[k]; !ok {
-			delete(connections, k)
+	for k, existing := range connections {
+		if _, ok := s.Connections[k]; ok {
+			continue
+		}
+		delete(connections, k)
+		if existing.DesiredTarget != nil && !existing.DesiredTarget.IsEmpty() {
+			findings = append(findings, core.SchemaFinding{
+				ChangeType: types.ChangeTypeConnection,
+				Name:       k,
+				Action:     core.SchemaFindingDropped,
+				Value:      *existing.DesiredTarget,
+			})
 		}
 	}
+
+	slices.SortFunc(findings, func(a, b core.SchemaFinding) int {
+		return cmp.Or(cmp.Compare(a.ChangeType, b.ChangeType), cmp.Compare(a.Name, b.Name))
+	})
+	return findings
 }
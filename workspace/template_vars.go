@@ -1,9 +1,13 @@
 package workspace
 
 import (
+	"cmp"
 	"maps"
 	"regexp"
 	"slices"
+
+	"github.com/nullstone-io/iac/core"
+	"gopkg.in/nullstone-io/go-api-client.v0/types"
 )
 
 var (
@@ -19,6 +23,43 @@ type TemplateVars struct {
 	StackName string
 	EnvName   string
 	EnvIsProd bool
+
+	// These are only available to templates in vars and environment values
+	EnvType   string
+	BlockName string
+	RepoName  string
+	RepoUrl   string
+}
+
+// TemplateContext returns the values for templates in vars and environment values
+func (v TemplateVars) TemplateContext() core.TemplateContext {
+	return core.TemplateContext{
+		OrgName:   v.OrgName,
+		StackName: v.StackName,
+		BlockName: v.BlockName,
+		EnvName:   v.EnvName,
+		EnvType:   v.EnvType,
+		EnvIsProd: v.EnvIsProd,
+		RepoName:  v.RepoName,
+		RepoUrl:   v.RepoUrl,
+	}
+}
+
+// RenderValue renders templates in a variable value
+func (v TemplateVars) RenderValue(value any) (any, error) {
+	return v.TemplateContext().RenderValue(value)
+}
+
+// WithBlock fills in the values that come from the block, env, and repository if they are not set already
+func (v TemplateVars) WithBlock(block types.Block, env types.Environment, repoName, repoUrl string) TemplateVars {
+	v.OrgName = cmp.Or(v.OrgName, block.OrgName, env.OrgName)
+	v.BlockName = cmp.Or(v.BlockName, block.Name)
+	v.EnvName = cmp.Or(v.EnvName, env.Name)
+	v.EnvType = cmp.Or(v.EnvType, string(env.Type))
+	v.EnvIsProd = v.EnvIsProd || env.IsProd
+	v.RepoName = cmp.Or(v.RepoName, repoName)
+	v.RepoUrl = cmp.Or(v.RepoUrl, repoUrl)
+	return v
 }
 
 func (v TemplateVars) ReplaceVars(input string) string {
@@ -5,18 +5,18 @@ import "gopkg.in/nullstone-io/go-api-client.v0/types"
 type AppConfiguration struct {
 	BlockConfiguration `yaml:",inline" json:",inline"`
 
-	Framework    string                   `yaml:"framework,omitempty" json:"framework"`
-	EnvVariables map[string]string        `yaml:"environment,omitempty" json:"envVars"`
-	Capabilities CapabilityConfigurations `yaml:"capabilities,omitempty" json:"capabilities"`
+	Framework    string                    `yaml:"framework,omitempty" json:"framework"`
+	EnvVariables EnvVariableConfigurations `yaml:"environment,omitempty" json:"envVars"`
+	Capabilities CapabilityConfigurations  `yaml:"capabilities,omitempty" json:"capabilities"`
 }
 
 func AppConfigurationFromWorkspaceConfig(stackId, envId int64, config types.WorkspaceConfig) AppConfiguration {
-	envVars := map[string]string{}
+	envVars := EnvVariableConfigurations{}
 	for name, v := range config.EnvVariables {
 		if v.Sensitive {
-			envVars[name] = RedactedValue
+			envVars[name] = EnvVariableConfiguration{Value: RedactedValue, Sensitive: true}
 		} else {
-			envVars[name] = v.Value
+			envVars[name] = EnvVariableConfiguration{Value: v.Value}
 		}
 	}
 	caps := CapabilityConfigurations{}
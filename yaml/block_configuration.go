@@ -10,6 +10,9 @@ type BlockConfiguration struct {
 	Variables        map[string]any        `yaml:"vars,omitempty" json:"vars"`
 	Connections      ConnectionConstraints `yaml:"connections,omitempty" json:"connections"`
 	IsShared         bool                  `yaml:"is_shared,omitempty" json:"isShared"`
+	// RenamedVariables maps a variable removed by a module upgrade to the variable that replaces it
+	// The workspace value is carried over to the new variable instead of being dropped
+	RenamedVariables map[string]string `yaml:"renamed_vars,omitempty" json:"renamedVars,omitempty"`
 	// Metadata holds governance/descriptive metadata (e.g. data classification).
 	Metadata *MetadataConfiguration `yaml:"metadata,omitempty" json:"metadata,omitempty"`
 }
@@ -45,12 +45,13 @@ func (c *CapabilityConfigurations) UnmarshalYAML(node *yaml.Node) error {
 			return err
 		}
 	case yaml.MappingNode:
-		m := map[string]CapabilityConfiguration{}
-		if err := node.Decode(&m); err != nil {
-			return err
-		}
-		for k, v := range m {
-			v.Name = k
+		// Decode each entry individually to preserve the order in the document
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			var v CapabilityConfiguration
+			if err := node.Content[i+1].Decode(&v); err != nil {
+				return err
+			}
+			v.Name = node.Content[i].Value
 			ccs = append(ccs, v)
 		}
 	}
@@ -75,4 +76,8 @@ type CapabilityConfiguration struct {
 	Variables        map[string]any        `yaml:"vars,omitempty" json:"vars"`
 	Connections      ConnectionConstraints `yaml:"connections,omitempty" json:"connections"`
 	Namespace        *string               `yaml:"namespace,omitempty" json:"namespace"`
+	// RenamedVariables maps a variable removed by a module upgrade to the variable that replaces it
+	RenamedVariables map[string]string `yaml:"renamed_vars,omitempty" json:"renamedVars,omitempty"`
+	// Enabled set to false removes an inherited capability in an overrides file
+	Enabled *bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
 }
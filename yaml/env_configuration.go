@@ -34,7 +34,7 @@ func ParseEnvConfiguration(data []byte) (*EnvConfiguration, error) {
 // EnvConfigurationFromWorkspaceConfig is used to generate an IaC configuration from types.WorkspaceConfig
 // Deprecated - This needs reworked
 func EnvConfigurationFromWorkspaceConfig(stackId, envId int64, block types.Block, config types.WorkspaceConfig) EnvConfiguration {
-	result := EnvConfiguration{Version: "0.1"}
+	result := EnvConfiguration{Version: CurrentVersion}
 
 	switch block.Type {
 	case string(types.BlockTypeApplication):
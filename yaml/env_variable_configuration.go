@@ -0,0 +1,60 @@
+package yaml
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	_ yaml.Marshaler   = EnvVariableConfiguration{}
+	_ yaml.Unmarshaler = &EnvVariableConfiguration{}
+)
+
+type EnvVariableConfigurations map[string]EnvVariableConfiguration
+
+// EnvVariableConfiguration is a plain value or a mapping that marks the value as sensitive
+//
+//	DB_HOST: db.internal
+//	DB_PASSWORD: { secret: "aws-sm://prod/db#password" }
+//	API_TOKEN: { value: "...", sensitive: true }
+type EnvVariableConfiguration struct {
+	Value     string `yaml:"value,omitempty" json:"value"`
+	Sensitive bool   `yaml:"sensitive,omitempty" json:"sensitive"`
+	// Secret is a reference to a secret stored outside the repository (e.g. aws-sm://prod/db#password)
+	Secret string `yaml:"secret,omitempty" json:"secret,omitempty"`
+}
+
+// envVariableMap is used to parse the mapping form of EnvVariableConfiguration
+type envVariableMap struct {
+	Value     string `yaml:"value,omitempty"`
+	Sensitive bool   `yaml:"sensitive,omitempty"`
+	Secret    string `yaml:"secret,omitempty"`
+}
+
+func (c EnvVariableConfiguration) MarshalYAML() (interface{}, error) {
+	if !c.Sensitive && c.Secret == "" {
+		return c.Value, nil
+	}
+	return envVariableMap(c), nil
+}
+
+func (c *EnvVariableConfiguration) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		var value string
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		*c = EnvVariableConfiguration{Value: value}
+	case yaml.MappingNode:
+		var tmp envVariableMap
+		if err := node.Decode(&tmp); err != nil {
+			return err
+		}
+		*c = EnvVariableConfiguration(tmp)
+	default:
+		return fmt.Errorf("line %d: environment variable must be a string or a mapping", node.Line)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// CurrentVersion is the IaC schema version produced by Migrate
+	CurrentVersion = "0.2"
+	// LegacyVersion is assumed for IaC files that do not specify a version
+	LegacyVersion = "0.1"
+)
+
+var (
+	_ error = UnsupportedVersionError{}
+
+	// Migrations is the registry of steps used to upgrade an IaC file to CurrentVersion
+	// Each step upgrades a file from exactly one version to the next
+	Migrations = []Migration{
+		{From: "0.1", To: "0.2", Apply: migrateV01ToV02},
+	}
+)
+
+// Migration upgrades the yaml of an IaC file from one schema version to another
+// Apply receives the root mapping node of the document and modifies it in place
+// Apply does not need to update the `version` field; Migrate does this after each step
+type Migration struct {
+	From  string
+	To    string
+	Apply func(root *yaml.Node) error
+}
+
+type UnsupportedVersionError struct {
+	Version   string
+	Supported []string
+}
+
+func (e UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("unsupported IaC version %q, supported versions: %s", e.Version, strings.Join(e.Supported, ", "))
+}
+
+// SupportedVersions returns every version that can be migrated to CurrentVersion
+func SupportedVersions() []string {
+	versions := make([]string, 0, len(Migrations)+1)
+	for _, m := range Migrations {
+		versions = append(versions, m.From)
+	}
+	return append(versions, CurrentVersion)
+}
+
+// Migrate upgrades a parsed IaC document to CurrentVersion
+// This returns the version declared in the document before it was upgraded
+// If the version is unknown, this returns UnsupportedVersionError and leaves the document untouched
+func Migrate(node *yaml.Node) (string, error) {
+	root := documentRoot(node)
+	if root == nil {
+		// empty document, nothing to migrate
+		return CurrentVersion, nil
+	}
+	if root.Kind != yaml.MappingNode {
+		return "", fmt.Errorf("line %d: IaC file must be a mapping", root.Line)
+	}
+
+	original := LegacyVersion
+	if versionNode := mappingValue(root, "version"); versionNode != nil && versionNode.Value != "" {
+		original = versionNode.Value
+	}
+	steps, err := migrationPath(original)
+	if err != nil {
+		return original, err
+	}
+	for _, step := range steps {
+		if err := step.Apply(root); err != nil {
+			return original, fmt.Errorf("error migrating IaC file from %s to %s: %w", step.From, step.To, err)
+		}
+		setVersion(root, step.To)
+	}
+	return original, nil
+}
+
+func migrationPath(version string) ([]Migration, error) {
+	steps := make([]Migration, 0)
+	for cur := version; cur != CurrentVersion; {
+		found := false
+		for _, m := range Migrations {
+			if m.From == cur {
+				steps = append(steps, m)
+				cur = m.To
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, UnsupportedVersionError{Version: version, Supported: SupportedVersions()}
+		}
+	}
+	return steps, nil
+}
+
+// migrateV01ToV02 rewrites deprecated shapes from 0.1:
+// - `subdomains.<name>.dns_name` => `subdomains.<name>.dns.template` (with `.{{ NULLSTONE_ENV }}` suffix)
+// - `apps.<name>.capabilities` as a list of named capabilities => map of capabilities keyed by name
+// Capabilities without a name are left as-is since their name is used to identify them in Nullstone
+func migrateV01ToV02(root *yaml.Node) error {
+	if subdomains := mappingValue(root, "subdomains"); subdomains != nil && subdomains.Kind == yaml.MappingNode {
+		for i := 1; i < len(subdomains.Content); i += 2 {
+			migrateSubdomainDnsName(subdomains.Content[i])
+		}
+	}
+	if apps := mappingValue(root, "apps"); apps != nil && apps.Kind == yaml.MappingNode {
+		for i := 1; i < len(apps.Content); i += 2 {
+			if app := apps.Content[i]; app.Kind == yaml.MappingNode {
+				if caps := mappingValue(app, "capabilities"); caps != nil {
+					migrateNamedCapabilities(caps)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func migrateSubdomainDnsName(subdomain *yaml.Node) {
+	if subdomain.Kind != yaml.MappingNode {
+		return
+	}
+	dnsName := mappingValue(subdomain, "dns_name")
+	if dnsName == nil {
+		return
+	}
+	removeMappingKey(subdomain, "dns_name")
+	if dnsName.Value == "" {
+		return
+	}
+	dns := mappingValue(subdomain, "dns")
+	if dns == nil || dns.Kind != yaml.MappingNode {
+		dns = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setMappingValue(subdomain, "dns", dns)
+	}
+	if template := mappingValue(dns, "template"); template != nil && template.Value != "" {
+		// dns.template takes precedence over the deprecated dns_name
+		return
+	}
+	template := dnsName.Value
+	if !strings.Contains(template, "{{ NULLSTONE_ENV }}") {
+		template = fmt.Sprintf("%s.{{ NULLSTONE_ENV }}", template)
+	}
+	setMappingValue(dns, "template", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: template})
+}
+
+func migrateNamedCapabilities(caps *yaml.Node) {
+	if caps.Kind != yaml.SequenceNode || len(caps.Content) == 0 {
+		return
+	}
+	for _, item := range caps.Content {
+		if item.Kind != yaml.MappingNode {
+			return
+		}
+		if name := mappingValue(item, "name"); name == nil || name.Value == "" {
+			return
+		}
+	}
+
+	content := make([]*yaml.Node, 0, len(caps.Content)*2)
+	for _, item := range caps.Content {
+		name := mappingValue(item, "name")
+		removeMappingKey(item, "name")
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name.Value, HeadComment: item.HeadComment}
+		item.HeadComment = ""
+		content = append(content, key, item)
+	}
+	caps.Kind = yaml.MappingNode
+	caps.Tag = "!!map"
+	caps.Style = 0
+	caps.Content = content
+}
+
+func documentRoot(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return resolveAlias(node.Content[0])
+	}
+	return resolveAlias(node)
+}
+
+func setVersion(root *yaml.Node, version string) {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: version, Style: yaml.DoubleQuotedStyle}
+	if existing := mappingValue(root, "version"); existing != nil {
+		existing.Kind, existing.Tag, existing.Value, existing.Style = value.Kind, value.Tag, value.Value, value.Style
+		return
+	}
+	// version is always the first field in an IaC file
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return resolveAlias(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+func removeMappingKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package yaml
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := map[string]struct {
+		content      string
+		wantOriginal string
+		want         string
+		wantErr      error
+	}{
+		"current version": {
+			content: `version: "0.2"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+`,
+			wantOriginal: "0.2",
+			want: `version: "0.2"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+`,
+		},
+		"missing version is treated as 0.1": {
+			content: `apps:
+  api:
+    module: nullstone/aws-fargate-service
+`,
+			wantOriginal: "0.1",
+			want: `version: "0.2"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+`,
+		},
+		"dns_name": {
+			content: `version: "0.1"
+subdomains:
+  api-subdomain:
+    module: nullstone/aws-subdomain
+    dns_name: api
+  docs-subdomain:
+    module: nullstone/aws-subdomain
+    dns_name: docs
+    dns:
+      template: documentation
+`,
+			wantOriginal: "0.1",
+			want: `version: "0.2"
+subdomains:
+  api-subdomain:
+    module: nullstone/aws-subdomain
+    dns:
+      template: api.{{ NULLSTONE_ENV }}
+  docs-subdomain:
+    module: nullstone/aws-subdomain
+    dns:
+      template: documentation
+`,
+		},
+		"named capability list": {
+			content: `version: "0.1"
+apps:
+  api:
+    capabilities:
+      # signs cookies
+      - name: keys
+        module: nullstone/jwt-keys
+      - name: lb
+        module: nullstone/aws-load-balancer
+  web:
+    capabilities:
+      - name: keys
+        module: nullstone/jwt-keys
+      - module: nullstone/aws-load-balancer
+`,
+			wantOriginal: "0.1",
+			want: `version: "0.2"
+apps:
+  api:
+    capabilities:
+      # signs cookies
+      keys:
+        module: nullstone/jwt-keys
+      lb:
+        module: nullstone/aws-load-balancer
+  web:
+    capabilities:
+      - name: keys
+        module: nullstone/jwt-keys
+      - module: nullstone/aws-load-balancer
+`,
+		},
+		"unsupported version": {
+			content: `version: "9.9"
+`,
+			wantOriginal: "9.9",
+			wantErr:      UnsupportedVersionError{Version: "9.9", Supported: []string{"0.1", "0.2"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.content), &node))
+			original, err := Migrate(&node)
+			assert.Equal(t, test.wantOriginal, original)
+			if test.wantErr != nil {
+				assert.Equal(t, test.wantErr, err)
+				return
+			}
+			require.NoError(t, err)
+
+			buf := bytes.NewBuffer(nil)
+			encoder := yaml.NewEncoder(buf)
+			encoder.SetIndent(2)
+			require.NoError(t, encoder.Encode(&node))
+			assert.Equal(t, test.want, buf.String())
+
+			var parsed EnvConfiguration
+			require.NoError(t, node.Decode(&parsed))
+			assert.Equal(t, CurrentVersion, parsed.Version)
+		})
+	}
+}
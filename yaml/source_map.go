@@ -0,0 +1,86 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nullstone-io/iac/core"
+	"gopkg.in/yaml.v3"
+)
+
+// BuildSourceMap walks a parsed yaml document and records the position of every object by its object path
+// Object paths match core.ObjectPathContext.Context() (e.g. `apps.api.vars.cpu`, `apps.api.capabilities[0]`)
+func BuildSourceMap(node *yaml.Node) core.SourceMap {
+	sm := core.SourceMap{}
+	node = documentRoot(node)
+	if node == nil {
+		return sm
+	}
+	walkSourceNode(sm, "", node)
+	return sm
+}
+
+func walkSourceNode(sm core.SourceMap, path string, node *yaml.Node) {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], resolveAlias(node.Content[i+1])
+			subPath := joinObjectPath(path, key.Value)
+			sm[subPath] = nodePosition(key, value)
+			walkSourceNode(sm, subPath, value)
+			if key.Value == "capabilities" && value.Kind == yaml.MappingNode {
+				// CapabilityConfigurations accepts a map of capabilities, but they are referenced by index
+				// CapabilityConfigurations.UnmarshalYAML preserves document order, so the index matches the position in the map
+				for j := 0; j+1 < len(value.Content); j += 2 {
+					capPath := fmt.Sprintf("%s[%d]", subPath, j/2)
+					capValue := resolveAlias(value.Content[j+1])
+					sm[capPath] = nodePosition(value.Content[j], capValue)
+					walkSourceNode(sm, capPath, capValue)
+				}
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			item = resolveAlias(item)
+			subPath := fmt.Sprintf("%s[%d]", path, i)
+			sm[subPath] = nodePosition(item, item)
+			walkSourceNode(sm, subPath, item)
+		}
+	}
+}
+
+func joinObjectPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// nodePosition returns the position starting at start and ending at the last line of value
+func nodePosition(start, value *yaml.Node) core.SourcePosition {
+	return core.SourcePosition{
+		Line:    start.Line,
+		Column:  start.Column,
+		EndLine: lastLine(value),
+	}
+}
+
+func lastLine(node *yaml.Node) int {
+	node = resolveAlias(node)
+	if len(node.Content) == 0 {
+		if node.Kind == yaml.ScalarNode && (node.Style&(yaml.LiteralStyle|yaml.FoldedStyle)) != 0 {
+			// Block scalars start on the line after the indicator and span one line per line of content
+			return node.Line + strings.Count(strings.TrimSuffix(node.Value, "\n"), "\n") + 1
+		}
+		return node.Line
+	}
+	return lastLine(node.Content[len(node.Content)-1])
+}
@@ -0,0 +1,88 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/nullstone-io/iac/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestBuildSourceMap(t *testing.T) {
+	content := `version: "0.1"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+    vars:
+      cpu: 256
+    connections:
+      cluster-namespace: namespace0
+      subdomain:
+        block_name: api-subdomain
+    capabilities:
+      keys:
+        module: nullstone/jwt-keys
+      cookies:
+        module: nullstone/rails-cookies
+        vars:
+          secret: |
+            line1
+            line2
+  web:
+    capabilities:
+      - module: nullstone/aws-load-balancer
+`
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(content), &node))
+	sm := BuildSourceMap(&node)
+
+	tests := map[string]struct {
+		pc   core.ObjectPathContext
+		want core.SourcePosition
+	}{
+		"block": {
+			pc:   core.NewObjectPathContextKey("apps", "api"),
+			want: core.SourcePosition{Line: 3, Column: 3, EndLine: 19},
+		},
+		"variable": {
+			pc:   core.NewObjectPathContextKey("apps", "api").SubKey("vars", "cpu"),
+			want: core.SourcePosition{Line: 6, Column: 7, EndLine: 6},
+		},
+		"scalar connection": {
+			pc:   core.NewObjectPathContextKey("apps", "api").SubKey("connections", "cluster-namespace"),
+			want: core.SourcePosition{Line: 8, Column: 7, EndLine: 8},
+		},
+		"mapping connection": {
+			pc:   core.NewObjectPathContextKey("apps", "api").SubKey("connections", "subdomain"),
+			want: core.SourcePosition{Line: 9, Column: 7, EndLine: 10},
+		},
+		"capability map by index": {
+			pc:   core.NewObjectPathContextKey("apps", "api").SubIndex("capabilities", 1).SubField("module"),
+			want: core.SourcePosition{Line: 15, Column: 9, EndLine: 15},
+		},
+		"capability block scalar": {
+			pc:   core.NewObjectPathContextKey("apps", "api").SubIndex("capabilities", 1).SubKey("vars", "secret"),
+			want: core.SourcePosition{Line: 17, Column: 11, EndLine: 19},
+		},
+		"capability sequence": {
+			pc:   core.NewObjectPathContextKey("apps", "web").SubIndex("capabilities", 0),
+			want: core.SourcePosition{Line: 22, Column: 9, EndLine: 22},
+		},
+		"missing field falls back to parent": {
+			pc:   core.NewObjectPathContextKey("apps", "web").SubField("module"),
+			want: core.SourcePosition{Line: 20, Column: 3, EndLine: 22},
+		},
+		"unknown block": {
+			pc:   core.NewObjectPathContextKey("datastores", "db"),
+			want: core.SourcePosition{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, sm.Find(test.pc))
+		})
+	}
+}
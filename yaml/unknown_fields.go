@@ -0,0 +1,209 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	_ error = UnknownFieldError{}
+
+	connectionConstraintType     = reflect.TypeOf(ConnectionConstraint{})
+	envVariableConfigurationType = reflect.TypeOf(EnvVariableConfiguration{})
+	capabilityConfigurationsType = reflect.TypeOf(CapabilityConfigurations{})
+	unmarshalerType              = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+)
+
+// UnknownFieldError describes a key in an IaC file that does not map to any known field
+type UnknownFieldError struct {
+	// Path is the object path of the object containing the unknown key (e.g. `apps.api`)
+	Path string
+	// Key is the unknown key (e.g. `enviroment`)
+	Key string
+	// Suggestion is the closest known field, if one is similar enough to Key
+	Suggestion string
+	Line       int
+	Column     int
+}
+
+func (e UnknownFieldError) Error() string {
+	location := e.Key
+	if e.Path != "" {
+		location = fmt.Sprintf("%s.%s", e.Path, e.Key)
+	}
+	msg := fmt.Sprintf("line %d: unknown field %q (%s)", e.Line, e.Key, location)
+	if e.Suggestion != "" {
+		msg = fmt.Sprintf("%s, did you mean %q?", msg, e.Suggestion)
+	}
+	return msg
+}
+
+// FindUnknownFields walks a parsed yaml document and reports every key that does not exist in EnvConfiguration
+// Unlike yaml.Decoder.KnownFields, this continues through custom unmarshalers (ConnectionConstraint, CapabilityConfigurations)
+// and reports every unknown key instead of stopping at the first one
+func FindUnknownFields(node *yaml.Node) []UnknownFieldError {
+	node = documentRoot(node)
+	if node == nil {
+		return nil
+	}
+	var errs []UnknownFieldError
+	findUnknownFields(&errs, "", node, reflect.TypeOf(EnvConfiguration{}))
+	return errs
+}
+
+func findUnknownFields(errs *[]UnknownFieldError, path string, node *yaml.Node, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case connectionConstraintType:
+		// ConnectionConstraint accepts a scalar ("stack.env.block") or a mapping
+		if node.Kind == yaml.MappingNode {
+			findUnknownFields(errs, path, node, reflect.TypeOf(connectionConstraintMap{}))
+		}
+		return
+	case envVariableConfigurationType:
+		// EnvVariableConfiguration accepts a scalar value or a mapping
+		if node.Kind == yaml.MappingNode {
+			findUnknownFields(errs, path, node, reflect.TypeOf(envVariableMap{}))
+		}
+		return
+	case capabilityConfigurationsType:
+		// CapabilityConfigurations accepts a sequence or a mapping of capabilities, both are referenced by index
+		itemType := t.Elem()
+		switch node.Kind {
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				findUnknownFields(errs, fmt.Sprintf("%s[%d]", path, i), resolveAlias(item), itemType)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				findUnknownFields(errs, fmt.Sprintf("%s[%d]", path, i/2), resolveAlias(node.Content[i+1]), itemType)
+			}
+		}
+		return
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		// We don't know the shape accepted by other custom unmarshalers
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := knownFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], resolveAlias(node.Content[i+1])
+			if key.Value == "<<" {
+				// merge keys are expanded by the decoder, check the merged values against this type
+				findUnknownFields(errs, path, value, t)
+				continue
+			}
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				*errs = append(*errs, UnknownFieldError{
+					Path:       path,
+					Key:        key.Value,
+					Suggestion: suggestField(key.Value, fields),
+					Line:       key.Line,
+					Column:     key.Column,
+				})
+				continue
+			}
+			findUnknownFields(errs, joinObjectPath(path, key.Value), value, fieldType)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			findUnknownFields(errs, joinObjectPath(path, node.Content[i].Value), resolveAlias(node.Content[i+1]), t.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			findUnknownFields(errs, fmt.Sprintf("%s[%d]", path, i), resolveAlias(item), t.Elem())
+		}
+	}
+}
+
+// knownFields returns the yaml keys accepted by struct type t, including keys from inlined structs
+func knownFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if strings.Contains(opts, "inline") {
+			for k, v := range knownFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// suggestField finds the known field that most closely matches the unknown key
+func suggestField(key string, fields map[string]reflect.Type) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Only suggest a field if it's reasonably close to the key or the key is a truncated field (e.g. `block` => `block_name`)
+	key = strings.ToLower(key)
+	maxDist := max(2, len(key)/3)
+	best, bestDist := "", -1
+	for _, name := range names {
+		dist := levenshtein(key, name)
+		if dist > maxDist && !strings.HasPrefix(name, key) {
+			continue
+		}
+		if bestDist == -1 || dist < bestDist {
+			best, bestDist = name, dist
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
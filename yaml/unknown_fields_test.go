@@ -0,0 +1,102 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFindUnknownFields(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		want    []UnknownFieldError
+	}{
+		"valid": {
+			content: `version: "0.1"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+    module_version: 0.1.0
+    vars:
+      anything_goes: here
+    environment:
+      KEY: value
+    connections:
+      cluster-namespace: namespace0
+      subdomain:
+        stack_name: core
+        block_name: api-subdomain
+    capabilities:
+      keys:
+        module: nullstone/jwt-keys
+subdomains:
+  api-subdomain:
+    module: nullstone/aws-subdomain
+    dns:
+      template: api
+    metadata:
+      dataclassification: public
+`,
+			want: nil,
+		},
+		"typos with suggestions": {
+			content: `version: "0.1"
+apps:
+  api:
+    module: nullstone/aws-fargate-service
+    module_verison: 0.1.0
+    enviroment:
+      KEY: value
+`,
+			want: []UnknownFieldError{
+				{Path: "apps.api", Key: "module_verison", Suggestion: "module_version", Line: 5, Column: 5},
+				{Path: "apps.api", Key: "enviroment", Suggestion: "environment", Line: 6, Column: 5},
+			},
+		},
+		"unknown key without a suggestion": {
+			content: `apps:
+  api:
+    module: nullstone/aws-fargate-service
+    replicas: 3
+`,
+			want: []UnknownFieldError{
+				{Path: "apps.api", Key: "replicas", Line: 4, Column: 5},
+			},
+		},
+		"through custom unmarshalers": {
+			content: `apps:
+  api:
+    connections:
+      subdomain:
+        block: api-subdomain
+    capabilities:
+      - module: nullstone/jwt-keys
+        namespce: primary
+`,
+			want: []UnknownFieldError{
+				{Path: "apps.api.connections.subdomain", Key: "block", Suggestion: "block_name", Line: 5, Column: 9},
+				{Path: "apps.api.capabilities[0]", Key: "namespce", Suggestion: "namespace", Line: 8, Column: 9},
+			},
+		},
+		"nested in subdomain": {
+			content: `subdomains:
+  api-subdomain:
+    dns:
+      templte: api
+`,
+			want: []UnknownFieldError{
+				{Path: "subdomains.api-subdomain.dns", Key: "templte", Suggestion: "template", Line: 4, Column: 7},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.content), &node))
+			assert.Equal(t, test.want, FindUnknownFields(&node))
+		})
+	}
+}